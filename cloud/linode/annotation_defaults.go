@@ -0,0 +1,172 @@
+package linode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// defaultAnnotationsSyncTimeout bounds how long startup waits for the default
+// annotations ConfigMaps to be listed.
+const defaultAnnotationsSyncTimeout = 30 * time.Second
+
+// defaultAnnotations holds the default annotations which getServiceAnnotation falls back
+// to. It is package-level as the functions reading a service's configuration only have
+// the service to go on.
+var defaultAnnotations annotationDefaults
+
+// perServiceAnnotations identify resources which belong to a single service, so they
+// cannot be given defaults.
+var perServiceAnnotations = map[string]bool{
+	annLinodeNodeBalancerID: true,
+	annLinodeFirewallID:     true,
+}
+
+// annotationDefaults holds default service annotations by namespace, read from the
+// ConfigMaps named by --default-annotations-configmap. The one in clusterNamespace holds
+// the defaults for the whole cluster; those in other namespaces, the defaults for
+// services in their namespace. The zero value holds no defaults.
+type annotationDefaults struct {
+	mu               sync.RWMutex
+	clusterNamespace string
+	byNamespace      map[string]map[string]string
+}
+
+// get returns the default for the annotation with the given name for services in
+// namespace, preferring the namespace's defaults to the cluster's.
+func (d *annotationDefaults) get(namespace, name string) (string, bool) {
+	if perServiceAnnotations[name] {
+		return "", false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if namespace != d.clusterNamespace {
+		if val, ok := lookupAnnotation(d.byNamespace[namespace], name); ok {
+			return val, ok
+		}
+	}
+	return lookupAnnotation(d.byNamespace[d.clusterNamespace], name)
+}
+
+// forNamespace returns the defaults which apply to services in namespace, preferring
+// the namespace's defaults to the cluster's.
+func (d *annotationDefaults) forNamespace(namespace string) map[string]string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	annotations := make(map[string]string)
+	for name, val := range d.byNamespace[d.clusterNamespace] {
+		annotations[name] = val
+	}
+	if namespace != d.clusterNamespace {
+		for name, val := range d.byNamespace[namespace] {
+			annotations[name] = val
+		}
+	}
+	return annotations
+}
+
+// set replaces the defaults for namespace with the annotations in data. Keys which are
+// not Linode load balancer annotations, or cannot be defaulted, are ignored.
+func (d *annotationDefaults) set(namespace string, data map[string]string) {
+	annotations := make(map[string]string, len(data))
+	var ignored []string
+	for key, val := range data {
+		if !strings.HasPrefix(key, annLinodeAnnotationPrefix) && !strings.HasPrefix(key, annLinodeGAAnnotationPrefix) {
+			ignored = append(ignored, key)
+			continue
+		}
+		if perServiceAnnotations[key] || perServiceAnnotations[annLinodeAnnotationPrefix+strings.TrimPrefix(key, annLinodeGAAnnotationPrefix)] {
+			ignored = append(ignored, key)
+			continue
+		}
+		annotations[key] = val
+	}
+	if len(ignored) > 0 {
+		sort.Strings(ignored)
+		klog.Warningf("ignoring default annotations %s in namespace %s, which are not Linode load balancer annotations or cannot be defaulted",
+			strings.Join(ignored, ", "), namespace)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.byNamespace == nil {
+		d.byNamespace = make(map[string]map[string]string)
+	}
+	d.byNamespace[namespace] = annotations
+}
+
+// delete removes the defaults for namespace.
+func (d *annotationDefaults) delete(namespace string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.byNamespace, namespace)
+}
+
+// parseDefaultAnnotationsConfigMap splits a --default-annotations-configmap reference
+// into its namespace and name.
+func parseDefaultAnnotationsConfigMap(ref string) (namespace, name string, err error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid default annotations ConfigMap %q, must be namespace/name", ref)
+	}
+	return parts[0], parts[1], nil
+}
+
+// watchDefaultAnnotations keeps d in sync with the ConfigMaps named by ref, the cluster's
+// in ref's namespace and each namespace's in that namespace, until stopCh is closed.
+func (d *annotationDefaults) watchDefaultAnnotations(kubeClient kubernetes.Interface, ref string, stopCh <-chan struct{}) error {
+	clusterNamespace, name, err := parseDefaultAnnotationsConfigMap(ref)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	d.clusterNamespace = clusterNamespace
+	d.mu.Unlock()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", name).String()
+		}))
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if configMap, ok := obj.(*v1.ConfigMap); ok {
+				d.set(configMap.Namespace, configMap.Data)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			if configMap, ok := obj.(*v1.ConfigMap); ok {
+				d.set(configMap.Namespace, configMap.Data)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if configMap, ok := obj.(*v1.ConfigMap); ok {
+				d.delete(configMap.Namespace)
+			}
+		},
+	})
+
+	factory.Start(stopCh)
+	if err := wait.PollImmediate(100*time.Millisecond, defaultAnnotationsSyncTimeout, func() (bool, error) {
+		return informer.HasSynced(), nil
+	}); err != nil {
+		return fmt.Errorf("failed to sync default annotations ConfigMaps named %s within %s", name, defaultAnnotationsSyncTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package linode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_getServiceAnnotationDefaults(t *testing.T) {
+	defer func() { defaultAnnotations = annotationDefaults{} }()
+	defaultAnnotations = annotationDefaults{clusterNamespace: "kube-system"}
+	defaultAnnotations.set("kube-system", map[string]string{
+		annLinodeDefaultProtocol:   "http",
+		annLinodeThrottle:          "10",
+		annLinodeDefaultAlgorithm:  "leastconn",
+		annLinodeNodeBalancerID:    "123",
+		"example.com/unrelated":    "true",
+		annLinodeDefaultStickiness: "table",
+	})
+	defaultAnnotations.set("apps", map[string]string{
+		annLinodeThrottle: "5",
+		annLinodeGAAnnotationPrefix + "default-algorithm": "source",
+	})
+
+	newService := func(namespace string, annotations map[string]string) *v1.Service {
+		return &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: namespace, Annotations: annotations}}
+	}
+	testcases := []struct {
+		name     string
+		service  *v1.Service
+		ann      string
+		expected string
+		found    bool
+	}{
+		{"cluster default", newService("apps", nil), annLinodeDefaultProtocol, "http", true},
+		{"namespace default over cluster default", newService("apps", nil), annLinodeThrottle, "5", true},
+		{"namespace GA default over cluster default", newService("apps", nil), annLinodeDefaultAlgorithm, "source", true},
+		{"service over namespace default", newService("apps", map[string]string{annLinodeThrottle: "0"}), annLinodeThrottle, "0", true},
+		{"service over cluster default", newService("apps", map[string]string{annLinodeGAAnnotationPrefix + "default-protocol": "tcp"}), annLinodeDefaultProtocol, "tcp", true},
+		{"other namespace only gets cluster defaults", newService("web", nil), annLinodeThrottle, "10", true},
+		{"cluster namespace gets cluster defaults", newService("kube-system", nil), annLinodeDefaultAlgorithm, "leastconn", true},
+		{"per-service annotation is not defaulted", newService("apps", nil), annLinodeNodeBalancerID, "", false},
+		{"unset everywhere", newService("apps", nil), annLinodeHealthCheckType, "", false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			val, ok := getServiceAnnotation(test.service, test.ann)
+			if val != test.expected || ok != test.found {
+				t.Errorf("expected %q (found: %t), got %q (%t)", test.expected, test.found, val, ok)
+			}
+		})
+	}
+
+	defaultAnnotations.delete("apps")
+	if val, _ := getServiceAnnotation(newService("apps", nil), annLinodeThrottle); val != "10" {
+		t.Errorf("expected the cluster default once the namespace defaults are deleted, got %q", val)
+	}
+}
+
+func TestWatchDefaultAnnotations(t *testing.T) {
+	defer func() { defaultAnnotations = annotationDefaults{} }()
+
+	kubeClient := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ccm-defaults", Namespace: "kube-system"},
+			Data:       map[string]string{annLinodeThrottle: "10"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ccm-defaults", Namespace: "apps"},
+			Data:       map[string]string{annLinodeThrottle: "5"},
+		},
+	)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	if err := defaultAnnotations.watchDefaultAnnotations(kubeClient, "ccm-defaults", stopCh); err == nil {
+		t.Fatal("expected a ConfigMap reference without a namespace to be rejected")
+	}
+	if err := defaultAnnotations.watchDefaultAnnotations(kubeClient, "kube-system/ccm-defaults", stopCh); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "apps"}}
+	expectThrottle := func(expected string) {
+		t.Helper()
+		if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			val, _ := getServiceAnnotation(service, annLinodeThrottle)
+			return val == expected, nil
+		}); err != nil {
+			val, _ := getServiceAnnotation(service, annLinodeThrottle)
+			t.Fatalf("expected the throttle to default to %q, got %q", expected, val)
+		}
+	}
+	expectThrottle("5")
+
+	if err := kubeClient.CoreV1().ConfigMaps("apps").Delete(context.TODO(), "ccm-defaults", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectThrottle("10")
+
+	if _, err := kubeClient.CoreV1().ConfigMaps("kube-system").Update(context.TODO(), &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ccm-defaults", Namespace: "kube-system"},
+		Data:       map[string]string{annLinodeThrottle: "15"},
+	}, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectThrottle("15")
+}
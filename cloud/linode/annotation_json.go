@@ -0,0 +1,201 @@
+package linode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// unmarshalAnnotationJSON decodes raw, the value of the JSON annotation name, into v, a
+// pointer to a struct. As every other annotation is a string, values of the wrong JSON
+// type are converted where the meaning is clear: numbers and booleans given as strings,
+// and numbers given for string fields. Unknown fields are ignored. Errors name the
+// annotation and the offending field or position.
+func unmarshalAnnotationJSON(name, raw string, v interface{}) error {
+	if strings.TrimSpace(raw) == "" {
+		return fmt.Errorf("invalid %s annotation: the value is empty, expected a JSON object", name)
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("invalid %s annotation: %s", name, describeJSONError(raw, err))
+	}
+	end := decoder.InputOffset()
+	if err := decoder.Decode(new(interface{})); err != io.EOF {
+		extra := len(raw[end:]) - len(strings.TrimLeft(raw[end:], " \t\r\n"))
+		return fmt.Errorf("invalid %s annotation: unexpected data after the JSON object at character %d", name, end+int64(extra)+1)
+	}
+
+	value, err := coerceAnnotationValue(value, reflect.TypeOf(v).Elem(), "")
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation: %s", name, err)
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation: %s", name, err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("invalid %s annotation: %s", name, describeJSONError(string(b), err))
+	}
+	return nil
+}
+
+// describeJSONError rewords the errors of decoding raw to say where the problem is.
+func describeJSONError(raw string, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return fmt.Sprintf("malformed JSON at character %d: %s", syntaxErr.Offset, syntaxErr)
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Sprintf("malformed JSON: it ends at character %d before the object is closed", len(raw))
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Sprintf("%q must be %s, got a JSON %s", typeErr.Field, describeJSONType(typeErr.Type), typeErr.Value)
+	}
+	return err.Error()
+}
+
+// coerceAnnotationValue returns value, decoded with json.Number for numbers, converted
+// to suit the Go type t where that is unambiguous. path names value in errors.
+func coerceAnnotationValue(value interface{}, t reflect.Type, path string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if value == nil {
+		return nil, nil
+	}
+
+	mismatch := func() error {
+		if path == "" {
+			return fmt.Errorf("expected %s, got %s", describeJSONType(t), describeJSONValue(value))
+		}
+		return fmt.Errorf("%q must be %s, got %s", path, describeJSONType(t), describeJSONValue(value))
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case json.Number:
+			return v.String(), nil
+		}
+		return nil, mismatch()
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := value.(type) {
+		case json.Number:
+			if _, err := v.Int64(); err != nil {
+				return nil, mismatch()
+			}
+			return v, nil
+		case string:
+			if _, err := strconv.Atoi(strings.TrimSpace(v)); err != nil {
+				return nil, mismatch()
+			}
+			return json.Number(strings.TrimSpace(v)), nil
+		}
+		return nil, mismatch()
+
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, mismatch()
+			}
+			return b, nil
+		}
+		return nil, mismatch()
+
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		coerced := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if coerced[i], err = coerceAnnotationValue(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+
+	case reflect.Struct:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, mismatch()
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := strings.Split(field.Tag.Get("json"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			// encoding/json matches keys to fields without regard to case
+			for key, fieldValue := range fields {
+				if !strings.EqualFold(key, tag) {
+					continue
+				}
+				fieldPath := tag
+				if path != "" {
+					fieldPath = path + "." + tag
+				}
+				coerced, err := coerceAnnotationValue(fieldValue, field.Type, fieldPath)
+				if err != nil {
+					return nil, err
+				}
+				fields[key] = coerced
+			}
+		}
+		return fields, nil
+	}
+	return value, nil
+}
+
+// describeJSONType describes the JSON values accepted for the Go type t.
+func describeJSONType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "a whole number"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Slice:
+		return "a list"
+	case reflect.Struct, reflect.Map:
+		return "an object"
+	}
+	return t.String()
+}
+
+// describeJSONValue describes a decoded JSON value for errors.
+func describeJSONValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,140 @@
+package linode
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annLinodeBackendAddressType selects which of a node's addresses the service's
+	// NodeBalancer uses to reach it, overriding Options.BackendAddressType.
+	annLinodeBackendAddressType = "service.beta.kubernetes.io/linode-loadbalancer-backend-address-type"
+
+	// annLinodeBackendVPCSubnetID is the ID of the VPC subnet whose addresses the
+	// service's vpc backends are reached on. linodego's NodeBalancerNodeCreateOptions
+	// has no subnet field yet, so it is rejected.
+	annLinodeBackendVPCSubnetID = "service.beta.kubernetes.io/linode-loadbalancer-backend-vpc-subnet-id"
+
+	backendAddressTypeInternal = "internal"
+	backendAddressTypeExternal = "external"
+	backendAddressTypeVPC      = "vpc"
+
+	eventReasonNodeAddressMissing = "NodeAddressMissing"
+)
+
+var errVPCAddressUnsupported = errors.New("VPC backend addresses are not supported by this version of the Linode API client")
+
+// nodeAddressMissingError is returned by a resolver when a node lacks the address type
+// it resolves to.
+type nodeAddressMissingError struct {
+	node        string
+	addressType v1.NodeAddressType
+}
+
+func (e nodeAddressMissingError) Error() string {
+	return fmt.Sprintf("node %s has no %s", e.node, e.addressType)
+}
+
+// BackendAddressResolver returns the address NodeBalancers use to reach a node.
+type BackendAddressResolver interface {
+	BackendAddress(node *v1.Node) (string, error)
+}
+
+// internalIPResolver resolves nodes to their internal IP.
+type internalIPResolver struct{}
+
+func (internalIPResolver) BackendAddress(node *v1.Node) (string, error) {
+	return getNodeInternalIP(node), nil
+}
+
+// externalIPResolver resolves nodes to their external IP.
+type externalIPResolver struct{}
+
+func (externalIPResolver) BackendAddress(node *v1.Node) (string, error) {
+	address := getNodeAddress(node, v1.NodeExternalIP)
+	if address == "" {
+		return "", nodeAddressMissingError{node: node.Name, addressType: v1.NodeExternalIP}
+	}
+	return address, nil
+}
+
+// vpcIPResolver resolves nodes to their VPC address. linodego does not expose VPCs yet,
+// so it always fails.
+type vpcIPResolver struct{}
+
+func (vpcIPResolver) BackendAddress(node *v1.Node) (string, error) {
+	return "", errVPCAddressUnsupported
+}
+
+// annotationOverrideResolver resolves nodes to the address in their backend IP
+// annotation if that is a valid IP, and otherwise defers to fallback.
+type annotationOverrideResolver struct {
+	fallback BackendAddressResolver
+}
+
+func (r annotationOverrideResolver) BackendAddress(node *v1.Node) (string, error) {
+	if backendIP, ok := node.Annotations[annLinodeNodeBackendIP]; ok {
+		if ip := net.ParseIP(backendIP); ip != nil {
+			return ip.String(), nil
+		}
+		klog.Warningf("ignoring invalid %s annotation %q on node (%s)", annLinodeNodeBackendIP, backendIP, node.Name)
+	}
+	return r.fallback.BackendAddress(node)
+}
+
+// filterAddressableNodes returns the nodes resolver finds an address for. Nodes without
+// one are skipped with a warning event rather than failing the whole NodeBalancer.
+func (l *loadbalancers) filterAddressableNodes(service *v1.Service, resolver BackendAddressResolver, nodes []*v1.Node) []*v1.Node {
+	addressable := make([]*v1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		var missing nodeAddressMissingError
+		if _, err := resolver.BackendAddress(node); errors.As(err, &missing) {
+			klog.Warningf("service (%s) skipping backend: %s", getServiceNn(service), err)
+			l.recordEvent(service, v1.EventTypeWarning, eventReasonNodeAddressMissing, "skipping backend: %s", err)
+			continue
+		}
+		addressable = append(addressable, node)
+	}
+	return addressable
+}
+
+// newBackendAddressResolver returns the resolver for addressType. Nodes' backend IP
+// annotations always take precedence over the selected address type.
+func newBackendAddressResolver(addressType string) (BackendAddressResolver, error) {
+	var resolver BackendAddressResolver
+	switch addressType {
+	case "", backendAddressTypeInternal:
+		resolver = internalIPResolver{}
+	case backendAddressTypeExternal:
+		resolver = externalIPResolver{}
+	case backendAddressTypeVPC:
+		return nil, errVPCAddressUnsupported
+	default:
+		return nil, fmt.Errorf("invalid backend address type %q, must be one of %q, %q or %q",
+			addressType, backendAddressTypeInternal, backendAddressTypeExternal, backendAddressTypeVPC)
+	}
+	return annotationOverrideResolver{fallback: resolver}, nil
+}
+
+// getBackendAddressResolver returns the resolver for service's backends, chosen by its
+// backend-address-type annotation or else Options.BackendAddressType.
+func getBackendAddressResolver(service *v1.Service) (BackendAddressResolver, error) {
+	addressType, ok := getServiceAnnotation(service, annLinodeBackendAddressType)
+	if !ok {
+		addressType = Options.BackendAddressType
+	}
+	if rawSubnetID, ok := getServiceAnnotation(service, annLinodeBackendVPCSubnetID); ok {
+		if id, err := strconv.Atoi(rawSubnetID); err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid %s annotation %q: must be a subnet ID", annLinodeBackendVPCSubnetID, rawSubnetID)
+		}
+		if addressType != backendAddressTypeVPC {
+			return nil, fmt.Errorf("%s annotation only applies to the %q backend address type, not %q", annLinodeBackendVPCSubnetID, backendAddressTypeVPC, addressType)
+		}
+	}
+	return newBackendAddressResolver(addressType)
+}
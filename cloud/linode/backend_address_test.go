@@ -0,0 +1,196 @@
+package linode
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newBackendAddressTestNode(annotations map[string]string) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node-1",
+			Annotations: annotations,
+		},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{
+				{
+					Type:    v1.NodeInternalIP,
+					Address: "10.0.0.1",
+				},
+				{
+					Type:    v1.NodeExternalIP,
+					Address: "203.0.113.1",
+				},
+			},
+		},
+	}
+}
+
+func TestBackendAddressResolvers(t *testing.T) {
+	testcases := []struct {
+		name     string
+		resolver BackendAddressResolver
+		node     *v1.Node
+		address  string
+		err      error
+	}{
+		{
+			"internal",
+			internalIPResolver{},
+			newBackendAddressTestNode(nil),
+			"10.0.0.1",
+			nil,
+		},
+		{
+			"internal missing",
+			internalIPResolver{},
+			&v1.Node{},
+			"",
+			nil,
+		},
+		{
+			"external",
+			externalIPResolver{},
+			newBackendAddressTestNode(nil),
+			"203.0.113.1",
+			nil,
+		},
+		{
+			"external without external ip",
+			externalIPResolver{},
+			&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			"",
+			nodeAddressMissingError{node: "node-1", addressType: v1.NodeExternalIP},
+		},
+		{
+			"vpc",
+			vpcIPResolver{},
+			newBackendAddressTestNode(nil),
+			"",
+			errVPCAddressUnsupported,
+		},
+		{
+			"annotation override without annotation",
+			annotationOverrideResolver{fallback: externalIPResolver{}},
+			newBackendAddressTestNode(nil),
+			"203.0.113.1",
+			nil,
+		},
+		{
+			"annotation override ipv4",
+			annotationOverrideResolver{fallback: internalIPResolver{}},
+			newBackendAddressTestNode(map[string]string{annLinodeNodeBackendIP: "192.168.1.10"}),
+			"192.168.1.10",
+			nil,
+		},
+		{
+			"annotation override ipv6",
+			annotationOverrideResolver{fallback: internalIPResolver{}},
+			newBackendAddressTestNode(map[string]string{annLinodeNodeBackendIP: "2600:3c00::1"}),
+			"2600:3c00::1",
+			nil,
+		},
+		{
+			"annotation override invalid",
+			annotationOverrideResolver{fallback: internalIPResolver{}},
+			newBackendAddressTestNode(map[string]string{annLinodeNodeBackendIP: "not-an-ip"}),
+			"10.0.0.1",
+			nil,
+		},
+		{
+			"annotation override takes precedence over vpc",
+			annotationOverrideResolver{fallback: vpcIPResolver{}},
+			newBackendAddressTestNode(map[string]string{annLinodeNodeBackendIP: "192.168.1.10"}),
+			"192.168.1.10",
+			nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			address, err := test.resolver.BackendAddress(test.node)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if address != test.address {
+				t.Errorf("expected backend address %q, got %q", test.address, address)
+			}
+		})
+	}
+}
+
+func Test_filterAddressableNodes(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{recorder: recorder}
+	service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	addressed := newBackendAddressTestNode(nil)
+	unaddressed := &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}}
+
+	nodes := lb.filterAddressableNodes(service, annotationOverrideResolver{fallback: externalIPResolver{}}, []*v1.Node{addressed, unaddressed})
+	if len(nodes) != 1 || nodes[0] != addressed {
+		t.Errorf("expected only the node with an external IP, got %v", nodes)
+	}
+
+	close(recorder.Events)
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	if len(events) != 1 || !strings.HasPrefix(events[0], v1.EventTypeWarning+" "+eventReasonNodeAddressMissing) || !strings.Contains(events[0], "node-2") {
+		t.Errorf("expected a %s event naming node-2, got %v", eventReasonNodeAddressMissing, events)
+	}
+}
+
+func Test_getBackendAddressResolver(t *testing.T) {
+	defer func(addressType string) { Options.BackendAddressType = addressType }(Options.BackendAddressType)
+
+	node := newBackendAddressTestNode(nil)
+	testcases := []struct {
+		name        string
+		optionType  string
+		annotations map[string]string
+		address     string
+		wantErr     bool
+	}{
+		{"default", "", nil, "10.0.0.1", false},
+		{"option internal", backendAddressTypeInternal, nil, "10.0.0.1", false},
+		{"option external", backendAddressTypeExternal, nil, "203.0.113.1", false},
+		{"annotation overrides option", backendAddressTypeInternal, map[string]string{annLinodeBackendAddressType: backendAddressTypeExternal}, "203.0.113.1", false},
+		{"vpc", backendAddressTypeVPC, nil, "", true},
+		{"invalid annotation", "", map[string]string{annLinodeBackendAddressType: "public"}, "", true},
+		{"vpc subnet", backendAddressTypeVPC, map[string]string{annLinodeBackendVPCSubnetID: "1234"}, "", true},
+		{"vpc subnet without vpc", backendAddressTypeInternal, map[string]string{annLinodeBackendVPCSubnetID: "1234"}, "", true},
+		{"invalid vpc subnet", backendAddressTypeVPC, map[string]string{annLinodeBackendVPCSubnetID: "subnet-a"}, "", true},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			Options.BackendAddressType = test.optionType
+			service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+
+			resolver, err := getBackendAddressResolver(service)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			address, err := resolver.BackendAddress(node)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if address != test.address {
+				t.Errorf("expected backend address %q, got %q", test.address, address)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package linode
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// notReadySince returns when node last became NotReady, or false if it is Ready or its
+// readiness is not known.
+func notReadySince(node *v1.Node) (time.Time, bool) {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			if condition.Status == v1.ConditionTrue || condition.LastTransitionTime.IsZero() {
+				return time.Time{}, false
+			}
+			return condition.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// holdNotReadyBackends keeps the backends of the existing config, config, whose nodes
+// the plan drops for having turned NotReady less than Options.NotReadyNodeGracePeriod
+// ago. They are kept in drain mode, taking no new connections, so that a node which
+// misses a few heartbeats does not lose its connections; once it is Ready again it is
+// planned in accept mode as usual. It returns the plan to apply and whether backends
+// were held, in which case later reconciles remove them once the grace period is over.
+func (l *loadbalancers) holdNotReadyBackends(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer, config *linodego.NodeBalancerConfig, plan plannedConfig) (plannedConfig, bool, error) {
+	grace := Options.NotReadyNodeGracePeriod
+	if grace <= 0 || config == nil {
+		return plan, false, nil
+	}
+
+	current, err := l.client.ListNodeBalancerNodes(ctx, nb.ID, config.ID, nil)
+	if err != nil {
+		return plan, false, err
+	}
+	wanted := make(map[string]bool, len(plan.nodes))
+	for _, node := range plan.nodes {
+		wanted[node.Address] = true
+	}
+
+	var held []linodego.NodeBalancerNodeCreateOptions
+	for _, backend := range current {
+		if wanted[backend.Address] {
+			continue
+		}
+		if err := l.retrieveKubeClient(); err != nil {
+			return plan, false, err
+		}
+		// Backends are labeled with the name of their node
+		node, err := l.kubeClient.CoreV1().Nodes().Get(ctx, backend.Label, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return plan, false, err
+		}
+		since, notReady := notReadySince(node)
+		if !notReady || time.Since(since) >= grace {
+			continue
+		}
+
+		klog.V(2).Infof("draining backend %s of NodeBalancer (%d) config for port %d of service (%s) instead of removing it, as node %s has been NotReady for %s",
+			backend.Address, nb.ID, plan.port, getServiceNn(service), node.Name, time.Since(since).Round(time.Second))
+		held = append(held, linodego.NodeBalancerNodeCreateOptions{
+			Address: backend.Address,
+			Label:   backend.Label,
+			Weight:  backend.Weight,
+			Mode:    linodego.ModeDrain,
+		})
+	}
+	if len(held) == 0 {
+		return plan, false, nil
+	}
+
+	nodes := append(append([]linodego.NodeBalancerNodeCreateOptions(nil), plan.nodes...), held...)
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Label != nodes[j].Label {
+			return nodes[i].Label < nodes[j].Label
+		}
+		return nodes[i].Address < nodes[j].Address
+	})
+	plan.nodes = nodes
+	return plan, true, nil
+}
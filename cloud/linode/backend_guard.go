@@ -0,0 +1,91 @@
+package linode
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const eventReasonBackendRemovalCapped = "BackendRemovalCapped"
+
+// capBackendRemoval returns desired, topped up with backends from current which desired
+// drops, so that at least ratio of current's backends remain. Backends are kept back in
+// order of label and address, so the same ones are kept across reconciles. The number
+// of backends kept back is also returned.
+func capBackendRemoval(desired []linodego.NodeBalancerNodeCreateOptions, current []linodego.NodeBalancerNode, ratio float64) ([]linodego.NodeBalancerNodeCreateOptions, int) {
+	minBackends := int(math.Ceil(ratio * float64(len(current))))
+	if ratio <= 0 || len(desired) >= minBackends {
+		return desired, 0
+	}
+
+	wanted := make(map[string]bool, len(desired))
+	for _, node := range desired {
+		wanted[node.Address] = true
+	}
+	var dropped []linodego.NodeBalancerNode
+	for _, node := range current {
+		if !wanted[node.Address] {
+			dropped = append(dropped, node)
+		}
+	}
+	sort.Slice(dropped, func(i, j int) bool {
+		if dropped[i].Label != dropped[j].Label {
+			return dropped[i].Label < dropped[j].Label
+		}
+		return dropped[i].Address < dropped[j].Address
+	})
+
+	capped := append([]linodego.NodeBalancerNodeCreateOptions(nil), desired...)
+	kept := 0
+	for _, node := range dropped {
+		if len(capped) >= minBackends {
+			break
+		}
+		capped = append(capped, linodego.NodeBalancerNodeCreateOptions{
+			Address: node.Address,
+			Label:   node.Label,
+			Weight:  node.Weight,
+			Mode:    node.Mode,
+		})
+		kept++
+	}
+	sort.Slice(capped, func(i, j int) bool {
+		if capped[i].Label != capped[j].Label {
+			return capped[i].Label < capped[j].Label
+		}
+		return capped[i].Address < capped[j].Address
+	})
+	return capped, kept
+}
+
+// guardBackendRemoval caps how many of the backends of the existing config, config, the
+// plan may remove in one reconcile when Options.MinBackendRatio is set, so that a node
+// list which is briefly missing most nodes does not take the service down. It returns
+// the plan to apply and whether it was capped; a capped plan is finished by later
+// reconciles, one step at a time.
+func (l *loadbalancers) guardBackendRemoval(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer, config *linodego.NodeBalancerConfig, plan plannedConfig) (plannedConfig, bool, error) {
+	ratio := Options.MinBackendRatio
+	if ratio <= 0 || config == nil {
+		return plan, false, nil
+	}
+
+	current, err := l.client.ListNodeBalancerNodes(ctx, nb.ID, config.ID, nil)
+	if err != nil {
+		return plan, false, err
+	}
+	nodes, kept := capBackendRemoval(plan.nodes, current, ratio)
+	if kept == 0 {
+		return plan, false, nil
+	}
+
+	klog.Warningf("capping removal of backends from NodeBalancer (%d) config for port %d of service (%s): keeping %d backends which would have been removed",
+		nb.ID, plan.port, getServiceNn(service), kept)
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonBackendRemovalCapped,
+		"port %d would go from %d to %d backends; keeping %d of them until a later reconcile", plan.port, len(current), len(plan.nodes), len(nodes))
+	plan.nodes = nodes
+	return plan, true, nil
+}
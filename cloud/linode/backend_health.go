@@ -0,0 +1,64 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const eventReasonBackendHealth = "NodeBalancerBackendHealth"
+
+// summarizeBackendHealth returns a summary such as "3/5 backends up" of the backend
+// statuses of configs, and whether any backend is down.
+func summarizeBackendHealth(configs []linodego.NodeBalancerConfig) (string, bool) {
+	var up, down int
+	for _, config := range configs {
+		if config.NodesStatus == nil {
+			continue
+		}
+		up += config.NodesStatus.Up
+		down += config.NodesStatus.Down
+	}
+	return fmt.Sprintf("%d/%d backends up", up, up+down), down > 0
+}
+
+// reportBackendHealth emits an event summarizing the health of nb's backends when
+// Options.BackendHealthReportInterval is set. A summary is only reported when it has
+// changed, and at most once per interval for each service.
+func (l *loadbalancers) reportBackendHealth(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer) {
+	interval := Options.BackendHealthReportInterval
+	if interval <= 0 {
+		return
+	}
+
+	serviceNn := getServiceNn(service)
+	state := l.states.get(serviceNn)
+	if !state.backendHealthReportedAt.IsZero() && time.Since(state.backendHealthReportedAt) < interval {
+		return
+	}
+
+	configs, err := l.client.ListNodeBalancerConfigs(ctx, nb.ID, nil)
+	if err != nil {
+		klog.Warningf("failed to get backend health of NodeBalancer (%d) for service (%s): %s", nb.ID, serviceNn, err)
+		return
+	}
+
+	summary, degraded := summarizeBackendHealth(configs)
+	if summary == state.backendHealth {
+		return
+	}
+
+	eventType := v1.EventTypeNormal
+	if degraded {
+		eventType = v1.EventTypeWarning
+	}
+	l.recordEvent(service, eventType, eventReasonBackendHealth, "NodeBalancer (%d): %s", nb.ID, summary)
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.backendHealth = summary
+		state.backendHealthReportedAt = time.Now()
+	})
+}
@@ -0,0 +1,145 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_summarizeBackendHealth(t *testing.T) {
+	testcases := []struct {
+		name     string
+		configs  []linodego.NodeBalancerConfig
+		summary  string
+		degraded bool
+	}{
+		{
+			"no configs",
+			nil,
+			"0/0 backends up",
+			false,
+		},
+		{
+			"all up",
+			[]linodego.NodeBalancerConfig{
+				{NodesStatus: &linodego.NodeBalancerNodeStatus{Up: 2}},
+				{NodesStatus: &linodego.NodeBalancerNodeStatus{Up: 2}},
+			},
+			"4/4 backends up",
+			false,
+		},
+		{
+			"some down",
+			[]linodego.NodeBalancerConfig{
+				{NodesStatus: &linodego.NodeBalancerNodeStatus{Up: 2, Down: 1}},
+				{NodesStatus: &linodego.NodeBalancerNodeStatus{Up: 1, Down: 1}},
+				{},
+			},
+			"3/5 backends up",
+			true,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			summary, degraded := summarizeBackendHealth(test.configs)
+			if summary != test.summary || degraded != test.degraded {
+				t.Errorf("expected (%q, %t), got (%q, %t)", test.summary, test.degraded, summary, degraded)
+			}
+		})
+	}
+}
+
+func TestReportBackendHealth(t *testing.T) {
+	defer func(interval time.Duration) { Options.BackendHealthReportInterval = interval }(Options.BackendHealthReportInterval)
+
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: "us-west"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	passive := true
+	cfg, err := client.CreateNodeBalancerConfig(context.TODO(), nb.ID, linodego.NodeBalancerConfigCreateOptions{
+		Port:         80,
+		CheckPassive: &passive,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setStatus := func(up, down int) {
+		fakeAPI.nbc[strconv.Itoa(cfg.ID)].NodesStatus = &linodego.NodeBalancerNodeStatus{Up: up, Down: down}
+	}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "health",
+			Namespace: "default",
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+	drain := func() []string {
+		var events []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				events = append(events, event)
+			default:
+				return events
+			}
+		}
+	}
+
+	setStatus(3, 2)
+	Options.BackendHealthReportInterval = 0
+	lb.reportBackendHealth(context.TODO(), svc, nb)
+	if events := drain(); len(events) != 0 {
+		t.Fatalf("expected no events while reporting is disabled, got %v", events)
+	}
+
+	Options.BackendHealthReportInterval = time.Hour
+	lb.reportBackendHealth(context.TODO(), svc, nb)
+	events := drain()
+	if len(events) != 1 || !strings.Contains(events[0], "3/5 backends up") || !strings.HasPrefix(events[0], v1.EventTypeWarning) {
+		t.Fatalf("expected a warning event reporting 3/5 backends up, got %v", events)
+	}
+
+	setStatus(5, 0)
+	lb.reportBackendHealth(context.TODO(), svc, nb)
+	if events := drain(); len(events) != 0 {
+		t.Fatalf("expected reporting to be rate-limited, got %v", events)
+	}
+
+	lb.states.update(getServiceNn(svc), func(state *serviceState) {
+		state.backendHealthReportedAt = time.Now().Add(-2 * time.Hour)
+	})
+	lb.reportBackendHealth(context.TODO(), svc, nb)
+	events = drain()
+	if len(events) != 1 || !strings.Contains(events[0], "5/5 backends up") || !strings.HasPrefix(events[0], v1.EventTypeNormal) {
+		t.Fatalf("expected a normal event reporting 5/5 backends up, got %v", events)
+	}
+
+	lb.states.update(getServiceNn(svc), func(state *serviceState) {
+		state.backendHealthReportedAt = time.Now().Add(-2 * time.Hour)
+	})
+	lb.reportBackendHealth(context.TODO(), svc, nb)
+	if events := drain(); len(events) != 0 {
+		t.Fatalf("expected an unchanged summary not to be reported, got %v", events)
+	}
+}
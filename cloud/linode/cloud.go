@@ -1,14 +1,23 @@
 package linode
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"text/template"
+	"time"
 
 	"github.com/linode/linodego"
 	"github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/scheme"
+	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -16,14 +25,46 @@ const (
 	ProviderName   = "linode"
 	accessTokenEnv = "LINODE_API_TOKEN"
 	regionEnv      = "LINODE_REGION"
+
+	// regionValidationTimeout bounds the Linode API lookups made at startup, such as those
+	// validating regions.
+	regionValidationTimeout = 30 * time.Second
 )
 
 // Options is a configuration object for this cloudprovider implementation.
 // We expect it to be initialized with flags external to this package, likely in
 // main.go
 var Options struct {
-	KubeconfigFlag *pflag.Flag
-	LinodeGoDebug  bool
+	KubeconfigFlag                      *pflag.Flag
+	LinodeGoDebug                       bool
+	RecreateNodeBalancersOnRegionChange bool
+	ReconcileNodesOnChangeOnly          bool
+	TransientErrorGracePeriod           time.Duration
+	AllowCrossNamespaceTLSSecrets       bool
+	HealthCheckIntervalJitter           int
+	BackendHealthReportInterval         time.Duration
+	BackendAddressType                  string
+	NodePortRange                       utilnet.PortRange
+	RejectOutOfRangeNodePorts           bool
+	FallbackRegions                     []string
+	IPPollTimeout                       time.Duration
+	IPPollInterval                      time.Duration
+	MinBackendRatio                     float64
+	ReconcileBackoffBase                time.Duration
+	ReconcileBackoffMax                 time.Duration
+	DebugStateAddress                   string
+	LookupCacheTTL                      time.Duration
+	NodeBalancerLabelTemplate           string
+	DefaultAnnotationsConfigMap         string
+	NodeBalancerDrainPeriod             time.Duration
+	NotReadyNodeGracePeriod             time.Duration
+	TLSSecretFetchTimeout               time.Duration
+	MaxConnectionThrottle               int
+	DetectMaxConnectionThrottle         bool
+	MinReconcileInterval                time.Duration
+	TLSValidation                       string
+	NoBackendNodesPolicy                string
+	MigrateLegacyNodeBalancers          bool
 }
 
 type linodeCloud struct {
@@ -53,6 +94,37 @@ func newCloud() (cloudprovider.Interface, error) {
 		return nil, fmt.Errorf("%s must be set in the environment (use a k8s secret)", regionEnv)
 	}
 
+	if _, err := newBackendAddressResolver(Options.BackendAddressType); err != nil {
+		return nil, err
+	}
+	if Options.DefaultAnnotationsConfigMap != "" {
+		if _, _, err := parseDefaultAnnotationsConfigMap(Options.DefaultAnnotationsConfigMap); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateNodeBalancerDrainPeriod(Options.NodeBalancerDrainPeriod); err != nil {
+		return nil, err
+	}
+	if err := validateIPPoll(Options.IPPollTimeout, Options.IPPollInterval); err != nil {
+		return nil, err
+	}
+	if err := validateMaxConnectionThrottle(Options.MaxConnectionThrottle); err != nil {
+		return nil, err
+	}
+	if err := validateTLSValidation(Options.TLSValidation); err != nil {
+		return nil, err
+	}
+	if err := validateNoBackendNodesPolicy(Options.NoBackendNodesPolicy); err != nil {
+		return nil, err
+	}
+	var labelTemplate *template.Template
+	if Options.NodeBalancerLabelTemplate != "" {
+		var err error
+		if labelTemplate, err = parseNodeBalancerLabelTemplate(Options.NodeBalancerLabelTemplate); err != nil {
+			return nil, fmt.Errorf("--nodebalancer-label-template: %s", err)
+		}
+	}
+
 	linodeClient := linodego.NewClient(nil)
 	linodeClient.SetToken(apiToken)
 	if Options.LinodeGoDebug {
@@ -60,22 +132,81 @@ func newCloud() (cloudprovider.Interface, error) {
 	}
 	linodeClient.SetUserAgent(fmt.Sprintf("linode-cloud-controller-manager %s", linodego.DefaultUserAgent))
 
+	objectStorage, err := newObjectStorageClientFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	lookups := newLookupCache(&linodeClient)
+	if err := validateRegions(lookups, append([]string{region}, Options.FallbackRegions...)); err != nil {
+		return nil, err
+	}
+	if Options.DetectMaxConnectionThrottle && Options.MaxConnectionThrottle == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), regionValidationTimeout)
+		Options.MaxConnectionThrottle = detectMaxConnectionThrottle(ctx, &linodeClient)
+		cancel()
+	}
+
+	lbs := newLoadbalancers(&linodeClient, region)
+	lbs.(*loadbalancers).objectStorage = objectStorage
+	lbs.(*loadbalancers).lookups = lookups
+	lbs.(*loadbalancers).labelTemplate = labelTemplate
+
 	// Return struct that satisfies cloudprovider.Interface
 	return &linodeCloud{
 		client:        &linodeClient,
 		instances:     newInstances(&linodeClient),
 		zones:         newZones(&linodeClient, region),
-		loadbalancers: newLoadbalancers(&linodeClient, region),
+		loadbalancers: lbs,
 	}, nil
 }
 
+// validateRegions checks the configured region and fallback regions against a freshly
+// fetched list of regions. Regions cannot be checked while the Linode API is unreachable,
+// which is only logged so that the CCM can still start.
+func validateRegions(lookups *lookupCache, regions []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), regionValidationTimeout)
+	defer cancel()
+
+	for i, region := range regions {
+		err := lookups.validateRegion(ctx, region, i == 0)
+		if _, ok := err.(unknownRegionError); ok {
+			return fmt.Errorf("invalid %s or --nodebalancer-fallback-regions: %w", regionEnv, err)
+		}
+		if err != nil {
+			klog.Warningf("could not validate region %s: %s", region, err)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (c *linodeCloud) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, stopCh <-chan struct{}) {
 	kubeclient := clientBuilder.ClientOrDie("linode-shared-informers")
 	sharedInformer := informers.NewSharedInformerFactory(kubeclient, 0)
 	serviceInformer := sharedInformer.Core().V1().Services()
 
-	serviceController := newServiceController(c.loadbalancers.(*loadbalancers), serviceInformer)
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&v1core.EventSinkImpl{Interface: kubeclient.CoreV1().Events("")})
+	lb := c.loadbalancers.(*loadbalancers)
+	lb.recorder = broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "linode-cloud-controller-manager"})
+
+	if Options.DefaultAnnotationsConfigMap != "" {
+		// Services reconciled before the defaults are synced would lose them, so wait
+		if err := defaultAnnotations.watchDefaultAnnotations(kubeclient, Options.DefaultAnnotationsConfigMap, stopCh); err != nil {
+			klog.Errorf("failed to watch default annotations: %s", err)
+		}
+	}
+
+	if lb.refresh != nil {
+		lb.refresh.watchTLSSecrets(kubeclient, stopCh)
+	}
+
+	serviceController := newServiceController(lb, serviceInformer)
 	go serviceController.Run(stopCh)
+
+	if Options.DebugStateAddress != "" {
+		go lb.serveDebugState(stopCh)
+	}
 }
 
 func (c *linodeCloud) LoadBalancer() (cloudprovider.LoadBalancer, bool) {
@@ -0,0 +1,113 @@
+package linode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"sort"
+	"strings"
+
+	"github.com/linode/linodego"
+)
+
+// configFingerprintFields are the parts of a NodeBalancer config which reconciles keep in
+// line with the service. Only these are hashed by configFingerprint, in this form, so
+// that changes to the linodego types do not change the fingerprints of configs which are
+// already up to date.
+type configFingerprintFields struct {
+	Port            int                           `json:"port"`
+	Protocol        linodego.ConfigProtocol       `json:"protocol"`
+	ProxyProtocol   linodego.ConfigProxyProtocol  `json:"proxy_protocol"`
+	Algorithm       linodego.ConfigAlgorithm      `json:"algorithm"`
+	Stickiness      linodego.ConfigStickiness     `json:"stickiness"`
+	Check           linodego.ConfigCheck          `json:"check"`
+	CheckInterval   int                           `json:"check_interval"`
+	CheckTimeout    int                           `json:"check_timeout"`
+	CheckAttempts   int                           `json:"check_attempts"`
+	CheckPath       string                        `json:"check_path"`
+	CheckBody       string                        `json:"check_body"`
+	CheckPassive    bool                          `json:"check_passive"`
+	CipherSuite     linodego.ConfigCipher         `json:"cipher_suite"`
+	CertFingerprint string                        `json:"cert_fingerprint"`
+	Nodes           []configFingerprintNodeFields `json:"nodes"`
+}
+
+type configFingerprintNodeFields struct {
+	Address string            `json:"address"`
+	Label   string            `json:"label"`
+	Weight  int               `json:"weight"`
+	Mode    linodego.NodeMode `json:"mode"`
+}
+
+// configFingerprint returns a digest of desired, a config and its backends, by which
+// reconciles tell whether the config needs to be rebuilt. Backends are hashed in order
+// of label and address, and the TLS certificate and key by their decoded PEM blocks, so
+// that neither the order of the nodes nor the formatting of a secret changes it.
+func configFingerprint(desired linodego.NodeBalancerConfigCreateOptions) (string, error) {
+	fields := configFingerprintFields{
+		Port:            desired.Port,
+		Protocol:        desired.Protocol,
+		ProxyProtocol:   desired.ProxyProtocol,
+		Algorithm:       desired.Algorithm,
+		Stickiness:      desired.Stickiness,
+		Check:           desired.Check,
+		CheckInterval:   desired.CheckInterval,
+		CheckTimeout:    desired.CheckTimeout,
+		CheckAttempts:   desired.CheckAttempts,
+		CheckPath:       desired.CheckPath,
+		CheckBody:       desired.CheckBody,
+		CheckPassive:    desired.CheckPassive != nil && *desired.CheckPassive,
+		CipherSuite:     desired.CipherSuite,
+		CertFingerprint: certFingerprint(desired.SSLCert, desired.SSLKey),
+	}
+	for _, node := range desired.Nodes {
+		fields.Nodes = append(fields.Nodes, configFingerprintNodeFields{
+			Address: node.Address,
+			Label:   node.Label,
+			Weight:  node.Weight,
+			Mode:    node.Mode,
+		})
+	}
+	sort.Slice(fields.Nodes, func(i, j int) bool {
+		if fields.Nodes[i].Label != fields.Nodes[j].Label {
+			return fields.Nodes[i].Label < fields.Nodes[j].Label
+		}
+		return fields.Nodes[i].Address < fields.Nodes[j].Address
+	})
+
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:configMetadataHashLength], nil
+}
+
+// certFingerprint returns a digest of a PEM encoded certificate chain and key, or "" if
+// there are none. Only the PEM blocks are hashed, so whitespace around them is ignored;
+// input which is not PEM is hashed without its surrounding whitespace.
+func certFingerprint(cert, key string) string {
+	if cert == "" && key == "" {
+		return ""
+	}
+	h := sha256.New()
+	for _, data := range []string{cert, key} {
+		rest := []byte(data)
+		found := false
+		for {
+			var block *pem.Block
+			if block, rest = pem.Decode(rest); block == nil {
+				break
+			}
+			found = true
+			h.Write([]byte(block.Type))
+			h.Write(block.Bytes)
+		}
+		if !found {
+			h.Write([]byte(strings.TrimSpace(data)))
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_configFingerprint(t *testing.T) {
+	passive := true
+	opts := linodego.NodeBalancerConfigCreateOptions{
+		Port:         443,
+		Protocol:     linodego.ProtocolHTTPS,
+		Algorithm:    linodego.AlgorithmRoundRobin,
+		Check:        linodego.CheckHTTP,
+		CheckPath:    "/healthz",
+		CheckPassive: &passive,
+		SSLCert:      testCert,
+		SSLKey:       testKey,
+		Nodes: []linodego.NodeBalancerNodeCreateOptions{
+			{Address: "10.0.0.1:30000", Label: "node-1", Weight: 100, Mode: "accept"},
+			{Address: "10.0.0.2:30000", Label: "node-2", Weight: 100, Mode: "accept"},
+		},
+	}
+	fingerprint, err := configFingerprint(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fingerprint) != configMetadataHashLength {
+		t.Errorf("expected a %d character fingerprint, got %q", configMetadataHashLength, fingerprint)
+	}
+
+	unchanged := map[string]func(o *linodego.NodeBalancerConfigCreateOptions){
+		"nodes reordered": func(o *linodego.NodeBalancerConfigCreateOptions) {
+			o.Nodes = []linodego.NodeBalancerNodeCreateOptions{o.Nodes[1], o.Nodes[0]}
+		},
+		"cert reformatted": func(o *linodego.NodeBalancerConfigCreateOptions) {
+			o.SSLCert = "\n" + o.SSLCert + "\n\n"
+			o.SSLKey = strings.TrimSpace(o.SSLKey)
+		},
+	}
+	changed := map[string]func(o *linodego.NodeBalancerConfigCreateOptions){
+		"check path":  func(o *linodego.NodeBalancerConfigCreateOptions) { o.CheckPath = "/ready" },
+		"passive":     func(o *linodego.NodeBalancerConfigCreateOptions) { o.CheckPassive = nil },
+		"stickiness":  func(o *linodego.NodeBalancerConfigCreateOptions) { o.Stickiness = linodego.StickinessTable },
+		"cert":        func(o *linodego.NodeBalancerConfigCreateOptions) { o.SSLCert = "" },
+		"node weight": func(o *linodego.NodeBalancerConfigCreateOptions) { o.Nodes[0].Weight = 50 },
+	}
+	for name, mutate := range unchanged {
+		o := opts
+		o.Nodes = append([]linodego.NodeBalancerNodeCreateOptions(nil), opts.Nodes...)
+		mutate(&o)
+		if got, _ := configFingerprint(o); got != fingerprint {
+			t.Errorf("%s: expected the fingerprint to stay %q, got %q", name, fingerprint, got)
+		}
+	}
+	for name, mutate := range changed {
+		o := opts
+		o.Nodes = append([]linodego.NodeBalancerNodeCreateOptions(nil), opts.Nodes...)
+		mutate(&o)
+		if got, _ := configFingerprint(o); got == fingerprint {
+			t.Errorf("%s: expected the fingerprint to change from %q", name, fingerprint)
+		}
+	}
+}
+
+func TestUpdateLoadBalancerFingerprintMatch(t *testing.T) {
+	var configWrites []string
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && strings.Contains(r.URL.Path, "/configs") {
+			configWrites = append(configWrites, r.Method+" "+r.URL.Path)
+		}
+		fakeAPI.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeHealthCheckType: string(linodego.CheckHTTP),
+				annLinodeCheckPath:       "/healthz",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}},
+		},
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+	}
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	lb.kubeClient = fake.NewSimpleClientset(svc)
+
+	configWrites = nil
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if len(configWrites) != 0 {
+		t.Errorf("expected no config to be written when its fingerprint matches, got %v", configWrites)
+	}
+
+	svc.Annotations[annLinodeCheckPath] = "/ready"
+	configWrites = nil
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if len(configWrites) == 0 {
+		t.Error("expected the config to be rebuilt once its fingerprint changed")
+	}
+}
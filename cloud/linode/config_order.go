@@ -0,0 +1,33 @@
+package linode
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// annLinodeConfigOrder sets the order in which the NodeBalancer's configs are
+	// created: configOrderPort, the default, or configOrderService.
+	annLinodeConfigOrder = "service.beta.kubernetes.io/linode-loadbalancer-config-order"
+
+	// configOrderPort creates configs in order of port number.
+	configOrderPort = "port"
+	// configOrderService creates configs in the order the service lists its ports, so
+	// that the Cloud Manager, which lists configs in the order they were created, shows
+	// them in the same order as the service.
+	configOrderService = "service"
+)
+
+// configOrderedServicePorts returns the service's ports in the order their configs are
+// created and applied, as set by the config-order annotation.
+func configOrderedServicePorts(service *v1.Service) ([]v1.ServicePort, error) {
+	order, _ := getServiceAnnotation(service, annLinodeConfigOrder)
+	switch order {
+	case "", configOrderPort:
+		return sortedServicePorts(service), nil
+	case configOrderService:
+		return append([]v1.ServicePort(nil), service.Spec.Ports...), nil
+	}
+	return nil, fmt.Errorf("invalid value %q for annotation %s, must be %q or %q", order, annLinodeConfigOrder, configOrderPort, configOrderService)
+}
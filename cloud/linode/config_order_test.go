@@ -0,0 +1,102 @@
+package linode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestConfigOrderMatchesServicePorts(t *testing.T) {
+	// createdPorts records the ports of configs in the order they are created, whether
+	// with the NodeBalancer or on their own
+	var createdPorts []int
+	configsPath := regexp.MustCompile("^/nodebalancers/[0-9]+/configs$")
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, _ := ioutil.ReadAll(r.Body)
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			switch {
+			case r.URL.Path == "/nodebalancers":
+				var create linodego.NodeBalancerCreateOptions
+				if err := json.Unmarshal(body, &create); err != nil {
+					t.Fatal(err)
+				}
+				for _, config := range create.Configs {
+					createdPorts = append(createdPorts, config.Port)
+				}
+			case configsPath.MatchString(r.URL.Path):
+				var create linodego.NodeBalancerConfigCreateOptions
+				if err := json.Unmarshal(body, &create); err != nil {
+					t.Fatal(err)
+				}
+				createdPorts = append(createdPorts, create.Port)
+			}
+		}
+		fakeAPI.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeConfigOrder: configOrderService,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "https", Protocol: "TCP", Port: int32(8443), NodePort: int32(30000)},
+				{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30001)},
+			},
+		},
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+	}
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	lb.kubeClient = fake.NewSimpleClientset(svc)
+
+	svc.Spec.Ports = append(svc.Spec.Ports,
+		v1.ServicePort{Name: "metrics", Protocol: "TCP", Port: int32(9000), NodePort: int32(30002)},
+		v1.ServicePort{Name: "admin", Protocol: "TCP", Port: int32(1000), NodePort: int32(30003)},
+	)
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+
+	if expected := []int{8443, 80, 9000, 1000}; !reflect.DeepEqual(createdPorts, expected) {
+		t.Errorf("expected configs to be created in service port order %v, got %v", expected, createdPorts)
+	}
+
+	svc.Annotations[annLinodeConfigOrder] = "alphabetical"
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err == nil {
+		t.Error("expected an error for an invalid config-order annotation")
+	}
+}
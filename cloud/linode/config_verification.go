@@ -0,0 +1,92 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+)
+
+const eventReasonNodeBalancerConfigMismatch = "NodeBalancerConfigMismatch"
+
+// configMismatchError describes how the live configs of a NodeBalancer diverge from the
+// one config per port a reconcile should leave it with.
+type configMismatchError struct {
+	nodeBalancerID int
+	missing        []int
+	duplicated     []int
+	unexpected     []int
+}
+
+func (e configMismatchError) Error() string {
+	var problems []string
+	describe := func(what string, ports []int) {
+		if len(ports) == 0 {
+			return
+		}
+		list := make([]string, 0, len(ports))
+		for _, port := range ports {
+			list = append(list, fmt.Sprint(port))
+		}
+		problems = append(problems, fmt.Sprintf("%s port(s) %s", what, strings.Join(list, ", ")))
+	}
+	describe("no config for", e.missing)
+	describe("more than one config for", e.duplicated)
+	describe("configs for undeclared", e.unexpected)
+	return fmt.Sprintf("NodeBalancer (%d) has %s after reconciling", e.nodeBalancerID, strings.Join(problems, "; "))
+}
+
+// diffNodeBalancerConfigs returns a configMismatchError if configs are not exactly one
+// config for each of ports, or nil if they are. A port declared for more than one
+// protocol is served by a single config, as a NodeBalancer config listens on a port for
+// one protocol family.
+func diffNodeBalancerConfigs(nodeBalancerID int, configs []linodego.NodeBalancerConfig, ports []int) *configMismatchError {
+	counts := make(map[int]int, len(configs))
+	for _, config := range configs {
+		counts[config.Port]++
+	}
+	expected := make(map[int]bool, len(ports))
+	for _, port := range ports {
+		expected[port] = true
+	}
+
+	mismatch := configMismatchError{nodeBalancerID: nodeBalancerID}
+	for port := range expected {
+		if counts[port] == 0 {
+			mismatch.missing = append(mismatch.missing, port)
+		}
+	}
+	for port, count := range counts {
+		switch {
+		case !expected[port]:
+			mismatch.unexpected = append(mismatch.unexpected, port)
+		case count > 1:
+			mismatch.duplicated = append(mismatch.duplicated, port)
+		}
+	}
+	if len(mismatch.missing)+len(mismatch.duplicated)+len(mismatch.unexpected) == 0 {
+		return nil
+	}
+	sort.Ints(mismatch.missing)
+	sort.Ints(mismatch.duplicated)
+	sort.Ints(mismatch.unexpected)
+	return &mismatch
+}
+
+// verifyNodeBalancerConfigs lists the live configs of nb, returning a
+// configMismatchError if they are not exactly one per port of the service and of the
+// services sharing nb with it.
+func (l *loadbalancers) verifyNodeBalancerConfigs(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer) (*configMismatchError, error) {
+	configs, err := l.client.ListNodeBalancerConfigs(ctx, nb.ID, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configs of NodeBalancer (%d) to verify them: %s", nb.ID, err)
+	}
+	ports := l.sharedPorts(service, nb)
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, int(port.Port))
+	}
+	return diffNodeBalancerConfigs(nb.ID, configs, ports), nil
+}
@@ -0,0 +1,119 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestUpdateLoadBalancerVerifiesConfigs(t *testing.T) {
+	fakeAPI := newFake(t)
+
+	// The first config rebuilt is lost straight after, as if part of the reconcile had
+	// silently not been applied
+	lost := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fakeAPI.ServeHTTP(w, r)
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/rebuild") && !lost {
+			lost = true
+			fakeAPI.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, strings.TrimSuffix(r.URL.Path, "/rebuild"), nil))
+		}
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "verify",
+			Namespace: "default",
+			UID:       "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)},
+				{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30001)},
+			},
+		},
+	}
+	node := func(name, address string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: address}},
+			},
+		}
+	}
+
+	recorder := record.NewFakeRecorder(100)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+	status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, []*v1.Node{node("node-1", "10.0.0.1")})
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *status
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new node rebuilds both configs, the first of which is lost
+	nodes := []*v1.Node{node("node-1", "10.0.0.1"), node("node-2", "10.0.0.2")}
+	if _, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("expected the lost config to be restored, got %s", err)
+	}
+	if !lost {
+		t.Fatal("expected a config to have been rebuilt")
+	}
+
+	configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mismatch := diffNodeBalancerConfigs(nb.ID, configs, []int{80, 443}); mismatch != nil {
+		t.Errorf("expected one config per port, got %s", mismatch)
+	}
+	for _, config := range configs {
+		backends, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, config.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backends) != 2 {
+			t.Errorf("expected the config for port %d to have 2 backends, got %d", config.Port, len(backends))
+		}
+	}
+
+	close(recorder.Events)
+	var mismatchEvent string
+	for event := range recorder.Events {
+		if strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonNodeBalancerConfigMismatch) {
+			mismatchEvent = event
+		}
+	}
+	if !strings.Contains(mismatchEvent, "no config for port(s)") {
+		t.Errorf("expected a %s event naming the missing port, got %q", eventReasonNodeBalancerConfigMismatch, mismatchEvent)
+	}
+}
+
+func Test_diffNodeBalancerConfigs(t *testing.T) {
+	configs := []linodego.NodeBalancerConfig{{Port: 80}, {Port: 80}, {Port: 8080}}
+	mismatch := diffNodeBalancerConfigs(1, configs, []int{80, 443, 443})
+	if mismatch == nil {
+		t.Fatal("expected a mismatch")
+	}
+	expected := "NodeBalancer (1) has no config for port(s) 443; more than one config for port(s) 80; configs for undeclared port(s) 8080 after reconciling"
+	if mismatch.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, mismatch.Error())
+	}
+	if mismatch := diffNodeBalancerConfigs(1, configs[1:2], []int{80, 80}); mismatch != nil {
+		t.Errorf("expected a port declared twice to need one config, got %s", mismatch)
+	}
+}
@@ -0,0 +1,73 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/linode/linodego"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// defaultMaxConnectionThrottle is the highest Client Connection Throttle NodeBalancers
+	// accept unless the account is known to allow more.
+	defaultMaxConnectionThrottle = 20
+
+	// connectionThrottleCapabilityPrefix prefixes the account capability, such as
+	// "NodeBalancer Throttle Max 50", which advertises the account's maximum Client
+	// Connection Throttle.
+	connectionThrottleCapabilityPrefix = "NodeBalancer Throttle Max "
+)
+
+// maxConnectionThrottle returns the highest Client Connection Throttle services may set:
+// Options.MaxConnectionThrottle once it is configured or detected, or else
+// defaultMaxConnectionThrottle.
+func maxConnectionThrottle() int {
+	if Options.MaxConnectionThrottle > 0 {
+		return Options.MaxConnectionThrottle
+	}
+	return defaultMaxConnectionThrottle
+}
+
+// validateMaxConnectionThrottle returns an error if max is not a usable
+// --max-connection-throttle.
+func validateMaxConnectionThrottle(max int) error {
+	if max < 0 {
+		return fmt.Errorf("--max-connection-throttle must not be negative, got %d", max)
+	}
+	return nil
+}
+
+// detectMaxConnectionThrottle returns the maximum Client Connection Throttle advertised
+// by the capabilities of the account client belongs to. Accounts which do not advertise
+// one, or whose capabilities cannot be fetched, get defaultMaxConnectionThrottle.
+func detectMaxConnectionThrottle(ctx context.Context, client *linodego.Client) int {
+	// linodego's Account does not include the account's capabilities
+	var account struct {
+		Capabilities []string `json:"capabilities"`
+	}
+	resp, err := client.R(ctx).SetResult(&account).Get("account")
+	if err == nil && resp.IsError() {
+		err = fmt.Errorf("%s", resp.Status())
+	}
+	if err != nil {
+		klog.Warningf("failed to detect the maximum NodeBalancer connection throttle, using %d: %s", defaultMaxConnectionThrottle, err)
+		return defaultMaxConnectionThrottle
+	}
+
+	for _, capability := range account.Capabilities {
+		if !strings.HasPrefix(capability, connectionThrottleCapabilityPrefix) {
+			continue
+		}
+		max, err := strconv.Atoi(strings.TrimPrefix(capability, connectionThrottleCapabilityPrefix))
+		if err != nil || max <= 0 {
+			klog.Warningf("ignoring account capability %q, using a maximum NodeBalancer connection throttle of %d", capability, defaultMaxConnectionThrottle)
+			return defaultMaxConnectionThrottle
+		}
+		klog.Infof("detected a maximum NodeBalancer connection throttle of %d", max)
+		return max
+	}
+	return defaultMaxConnectionThrottle
+}
@@ -0,0 +1,65 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_detectMaxConnectionThrottle(t *testing.T) {
+	defer func(max int) { Options.MaxConnectionThrottle = max }(Options.MaxConnectionThrottle)
+
+	testcases := []struct {
+		name     string
+		status   int
+		body     string
+		expected int
+	}{
+		{"advertised", http.StatusOK, `{"capabilities": ["Linodes", "NodeBalancers", "NodeBalancer Throttle Max 50"]}`, 50},
+		{"not advertised", http.StatusOK, `{"capabilities": ["Linodes", "NodeBalancers"]}`, defaultMaxConnectionThrottle},
+		{"invalid", http.StatusOK, `{"capabilities": ["NodeBalancer Throttle Max lots"]}`, defaultMaxConnectionThrottle},
+		{"unauthorized", http.StatusUnauthorized, `{"errors": [{"reason": "Invalid Token"}]}`, defaultMaxConnectionThrottle},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/account" {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer ts.Close()
+
+			client := linodego.NewClient(http.DefaultClient)
+			client.SetBaseURL(ts.URL)
+
+			max := detectMaxConnectionThrottle(context.TODO(), &client)
+			if max != test.expected {
+				t.Fatalf("expected a maximum of %d, got %d", test.expected, max)
+			}
+
+			// The detected maximum governs clamping of the throttle annotation
+			Options.MaxConnectionThrottle = max
+			for annotation, expected := range map[string]int{"45": 45, "80": max, "": 20} {
+				if expected > max {
+					expected = max
+				}
+				svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+				if annotation != "" {
+					svc.Annotations = map[string]string{annLinodeThrottle: annotation}
+				}
+				if throttle := getConnectionThrottle(svc); throttle != expected {
+					t.Errorf("throttle %q: expected %d, got %d", annotation, expected, throttle)
+				}
+			}
+		})
+	}
+}
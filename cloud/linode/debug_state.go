@@ -0,0 +1,81 @@
+package linode
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// debugStatePath is where the debug state handler is served.
+const debugStatePath = "/debug/services"
+
+// serviceDebugState is the JSON form of a service's reconcile state.
+type serviceDebugState struct {
+	Service             string     `json:"service"`
+	NodeBalancerID      int        `json:"nodeBalancerID,omitempty"`
+	Backends            int        `json:"backends"`
+	LastReconcile       *time.Time `json:"lastReconcile,omitempty"`
+	LastError           string     `json:"lastError,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures,omitempty"`
+	RetryAt             *time.Time `json:"retryAt,omitempty"`
+}
+
+// debugStateHandler serves the reconcile state the CCM holds in memory for each service,
+// ordered by namespaced name, so it can be inspected without access to the cluster.
+func (l *loadbalancers) debugStateHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		states := l.states.all()
+		services := make([]serviceDebugState, 0, len(states))
+		for serviceNn, state := range states {
+			debugState := serviceDebugState{
+				Service:             serviceNn,
+				NodeBalancerID:      state.nodeBalancerID,
+				Backends:            state.backends,
+				LastError:           state.lastReconcileError,
+				ConsecutiveFailures: state.reconcileFailures,
+			}
+			if !state.lastReconcile.IsZero() {
+				lastReconcile := state.lastReconcile
+				debugState.LastReconcile = &lastReconcile
+			}
+			if state.reconcileRetryAt.After(time.Now()) {
+				retryAt := state.reconcileRetryAt
+				debugState.RetryAt = &retryAt
+			}
+			services = append(services, debugState)
+		}
+		sort.Slice(services, func(i, j int) bool { return services[i].Service < services[j].Service })
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(services); err != nil {
+			klog.Errorf("failed to write debug state: %s", err)
+		}
+	})
+}
+
+// serveDebugState serves the debug state handler on Options.DebugStateAddress until
+// stopCh is closed. The CCM's own server does not accept additional handlers, so the
+// handler gets a listener of its own.
+func (l *loadbalancers) serveDebugState(stopCh <-chan struct{}) {
+	mux := http.NewServeMux()
+	mux.Handle(debugStatePath, l.debugStateHandler())
+	server := &http.Server{Addr: Options.DebugStateAddress, Handler: mux}
+
+	go func() {
+		<-stopCh
+		_ = server.Close()
+	}()
+
+	klog.Infof("serving debug state on %s%s", Options.DebugStateAddress, debugStatePath)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		klog.Errorf("failed to serve debug state on %s: %s", Options.DebugStateAddress, err)
+	}
+}
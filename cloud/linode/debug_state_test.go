@@ -0,0 +1,98 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDebugStateHandler(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
+	newService := func(name string, protocol v1.Protocol) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "debug",
+				UID:       "foobar123",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{
+					{
+						Name:     "test",
+						Protocol: protocol,
+						Port:     int32(80),
+						NodePort: int32(30000),
+					},
+				},
+			},
+		}
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.2"}},
+			},
+		},
+	}
+
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", newService("healthy", v1.ProtocolTCP), nodes); err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", newService("failing", v1.ProtocolUDP), nodes); err == nil {
+		t.Fatal("expected EnsureLoadBalancer to reject a UDP port")
+	}
+	nbs, err := client.ListNodeBalancers(context.TODO(), nil)
+	if err != nil || len(nbs) != 1 {
+		t.Fatalf("expected a single NodeBalancer, got %d: %v", len(nbs), err)
+	}
+
+	rec := httptest.NewRecorder()
+	lb.debugStateHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, debugStatePath, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected a JSON response, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var services []serviceDebugState
+	if err := json.NewDecoder(rec.Body).Decode(&services); err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("expected the state of 2 services, got %+v", services)
+	}
+
+	failing, healthy := services[0], services[1]
+	if failing.Service != "debug/failing" || failing.LastReconcile == nil || !strings.Contains(failing.LastError, "UDP") || failing.NodeBalancerID != 0 {
+		t.Errorf("unexpected state of the failing service: %+v", failing)
+	}
+	if healthy.Service != "debug/healthy" || healthy.LastReconcile == nil || healthy.LastError != "" ||
+		healthy.NodeBalancerID != nbs[0].ID || healthy.Backends != len(nodes) {
+		t.Errorf("unexpected state of the healthy service: %+v", healthy)
+	}
+
+	rec = httptest.NewRecorder()
+	lb.debugStateHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, debugStatePath, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected POST to be rejected, got %d", rec.Code)
+	}
+}
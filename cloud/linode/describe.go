@@ -0,0 +1,140 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annLinodeDescribe is a nonce; each time it changes, the CCM describes the service's
+	// live NodeBalancer in a NodeBalancerDescribed event, for debugging.
+	annLinodeDescribe = "service.beta.kubernetes.io/linode-loadbalancer-describe"
+
+	eventReasonNodeBalancerDescribed = "NodeBalancerDescribed"
+)
+
+// DescribeService returns a human-readable summary of the live state of the
+// NodeBalancer serving service, as reported by the Linode API: its addresses, its
+// configs and their backends, and the Cloud Firewalls attached to it.
+func (l *loadbalancers) DescribeService(ctx context.Context, clusterName string, service *v1.Service) (string, error) {
+	nb, err := l.getNodeBalancerForService(ctx, clusterName, service)
+	if err != nil {
+		return "", err
+	}
+
+	configs, err := l.client.ListNodeBalancerConfigs(ctx, nb.ID, nil)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Port < configs[j].Port })
+
+	firewalls, err := l.getFirewallsForNodeBalancer(ctx, nb.ID)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Service:       %s\n", getServiceNn(service))
+	fmt.Fprintf(&b, "NodeBalancer:  %s (%d)\n", stringValue(nb.Label), nb.ID)
+	fmt.Fprintf(&b, "Region:        %s\n", nb.Region)
+	fmt.Fprintf(&b, "IPv4:          %s\n", stringValue(nb.IPv4))
+	fmt.Fprintf(&b, "Hostname:      %s\n", stringValue(nb.Hostname))
+	fmt.Fprintf(&b, "Throttle:      %d\n", nb.ClientConnThrottle)
+
+	b.WriteString("Configs:\n")
+	if len(configs) == 0 {
+		b.WriteString("  <none>\n")
+	}
+	for _, config := range configs {
+		fmt.Fprintf(&b, "  Port %d/%s (%d): algorithm=%s stickiness=%s proxy_protocol=%s check=%s interval=%ds timeout=%ds attempts=%d passive=%t",
+			config.Port, config.Protocol, config.ID, config.Algorithm, config.Stickiness, config.ProxyProtocol, config.Check,
+			config.CheckInterval, config.CheckTimeout, config.CheckAttempts, config.CheckPassive)
+		if config.CheckPath != "" {
+			fmt.Fprintf(&b, " path=%s", redactCheckPath(config.CheckPath))
+		}
+		b.WriteString("\n")
+
+		nodes, err := l.client.ListNodeBalancerNodes(ctx, nb.ID, config.ID, nil)
+		if err != nil {
+			return "", err
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Address < nodes[j].Address })
+		if len(nodes) == 0 {
+			b.WriteString("    Backends: <none>\n")
+			continue
+		}
+		b.WriteString("    Backends:\n")
+		for _, node := range nodes {
+			fmt.Fprintf(&b, "      %s %s mode=%s status=%s\n", node.Label, node.Address, node.Mode, node.Status)
+		}
+	}
+
+	b.WriteString("Firewalls:\n")
+	if len(firewalls) == 0 {
+		b.WriteString("  <none>\n")
+	}
+	for _, firewall := range firewalls {
+		fmt.Fprintf(&b, "  %s (%d): %s\n", firewall.Label, firewall.ID, firewall.Status)
+	}
+
+	return b.String(), nil
+}
+
+// describeOnRequest records the service's DescribeService summary in a Normal event when
+// its describe annotation is set to a nonce the service has not been described for. The
+// nonce is only recorded once the description succeeds, so a service whose NodeBalancer
+// does not exist yet is described once it does.
+func (l *loadbalancers) describeOnRequest(ctx context.Context, clusterName string, service *v1.Service) {
+	nonce, _ := getServiceAnnotation(service, annLinodeDescribe)
+	serviceNn := getServiceNn(service)
+	if nonce == "" || l.states.get(serviceNn).describedNonce == nonce {
+		return
+	}
+
+	description, err := l.DescribeService(ctx, clusterName, service)
+	if err != nil {
+		klog.Warningf("failed to describe NodeBalancer for service (%s) as requested by %s: %s", serviceNn, annLinodeDescribe, err)
+		return
+	}
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.describedNonce = nonce
+	})
+	l.recordEvent(service, v1.EventTypeNormal, eventReasonNodeBalancerDescribed, "%s", description)
+}
+
+// getFirewallsForNodeBalancer returns the Cloud Firewalls which have the NodeBalancer
+// as a device.
+func (l *loadbalancers) getFirewallsForNodeBalancer(ctx context.Context, nodeBalancerID int) ([]linodego.Firewall, error) {
+	firewalls, err := l.client.ListFirewalls(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var attached []linodego.Firewall
+	for _, firewall := range firewalls {
+		devices, err := l.client.ListFirewallDevices(ctx, firewall.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, device := range devices {
+			if device.Entity.Type == linodego.FirewallDeviceNodeBalancer && device.Entity.ID == nodeBalancerID {
+				attached = append(attached, firewall)
+				break
+			}
+		}
+	}
+	return attached, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
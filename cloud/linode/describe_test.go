@@ -0,0 +1,171 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestDescribeService(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "describe",
+			Namespace: "default",
+			UID:       "foobar123",
+			Annotations: map[string]string{
+				annLinodeHealthCheckType: "http",
+				annLinodeCheckPath:       "/healthz",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.addFirewall(linodego.Firewall{ID: 4321, Label: "allow-office", Status: linodego.FirewallEnabled},
+		linodego.FirewallDevice{ID: 1, Entity: linodego.FirewallDeviceEntity{ID: nb.ID, Type: linodego.FirewallDeviceNodeBalancer}})
+	fake.addFirewall(linodego.Firewall{ID: 9876, Label: "unrelated", Status: linodego.FirewallEnabled},
+		linodego.FirewallDevice{ID: 2, Entity: linodego.FirewallDeviceEntity{ID: nb.ID, Type: linodego.FirewallDeviceLinode}})
+
+	description, err := lb.DescribeService(context.TODO(), "linodelb", svc)
+	if err != nil {
+		t.Fatalf("DescribeService returned an error: %s", err)
+	}
+
+	for _, expected := range []string{
+		fmt.Sprintf("NodeBalancer:  %s (%d)", *nb.Label, nb.ID),
+		"IPv4:          " + lbStatus.Ingress[0].IP,
+		"Port 80/tcp",
+		"check=http",
+		"path=/healthz",
+		"node-1 10.0.0.1:30000 mode=accept",
+		"allow-office (4321): enabled",
+	} {
+		if !strings.Contains(description, expected) {
+			t.Errorf("expected description to contain %q, got:\n%s", expected, description)
+		}
+	}
+	if strings.Contains(description, "unrelated") {
+		t.Errorf("expected description not to contain firewalls of other devices, got:\n%s", description)
+	}
+}
+
+func TestEnsureLoadBalancerDescribeOnRequest(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	recorder := record.NewFakeRecorder(100)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "describe",
+			Namespace: "default",
+			UID:       "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+			},
+		},
+	}
+
+	describedEvents := func() []string {
+		var events []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				if strings.HasPrefix(event, "Normal "+eventReasonNodeBalancerDescribed) {
+					events = append(events, event)
+				}
+			default:
+				return events
+			}
+		}
+	}
+
+	for _, step := range []struct {
+		nonce  string
+		events int
+	}{
+		{"", 0},
+		{"1", 1},
+		{"1", 0},
+		{"2", 1},
+	} {
+		svc.Annotations = map[string]string{annLinodeDescribe: step.nonce}
+		lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+		svc.Status.LoadBalancer = *lbStatus
+
+		events := describedEvents()
+		if len(events) != step.events {
+			t.Fatalf("nonce %q: expected %d %s event(s), got %v", step.nonce, step.events, eventReasonNodeBalancerDescribed, events)
+		}
+		for _, event := range events {
+			if !strings.Contains(event, "node-1 10.0.0.1:30000 mode=accept") {
+				t.Errorf("expected the event to describe the backends, got %q", event)
+			}
+		}
+	}
+}
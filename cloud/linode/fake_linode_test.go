@@ -24,6 +24,8 @@ type fakeAPI struct {
 	nb       map[string]*linodego.NodeBalancer
 	nbc      map[string]*linodego.NodeBalancerConfig
 	nbn      map[string]*linodego.NodeBalancerNode
+	fw       map[string]*linodego.Firewall
+	fwd      map[int][]linodego.FirewallDevice
 
 	requests map[fakeRequest]struct{}
 }
@@ -79,6 +81,8 @@ func newFake(t *testing.T) *fakeAPI {
 		nb:       make(map[string]*linodego.NodeBalancer),
 		nbc:      make(map[string]*linodego.NodeBalancerConfig),
 		nbn:      make(map[string]*linodego.NodeBalancerNode),
+		fw:       make(map[string]*linodego.Firewall),
+		fwd:      make(map[int][]linodego.FirewallDevice),
 		requests: make(map[fakeRequest]struct{}),
 	}
 }
@@ -168,6 +172,47 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 			}
+		case "networking":
+			rx, _ := regexp.Compile("/networking/firewalls/[0-9]+/devices")
+			if rx.MatchString(urlPath) {
+				parts := strings.Split(urlPath[1:], "/")
+				fwID, err := strconv.Atoi(parts[2])
+				if err != nil {
+					f.t.Fatal(err)
+				}
+				resp := linodego.FirewallDevicesPagedResponse{
+					PageOptions: &linodego.PageOptions{Page: 1, Pages: 1},
+					Data:        f.fwd[fwID],
+				}
+				rr, _ := json.Marshal(resp)
+				_, _ = w.Write(rr)
+				return
+			}
+			rx, _ = regexp.Compile("/networking/firewalls/[0-9]+")
+			if rx.MatchString(urlPath) {
+				fw, found := f.fw[filepath.Base(urlPath)]
+				if !found {
+					f.writeNotFound(w)
+					return
+				}
+				rr, _ := json.Marshal(fw)
+				_, _ = w.Write(rr)
+				return
+			}
+			rx, _ = regexp.Compile("/networking/firewalls")
+			if rx.MatchString(urlPath) {
+				data := []linodego.Firewall{}
+				for _, fw := range f.fw {
+					data = append(data, *fw)
+				}
+				resp := linodego.FirewallsPagedResponse{
+					PageOptions: &linodego.PageOptions{Page: 1, Pages: 1},
+					Data:        data,
+				}
+				rr, _ := json.Marshal(resp)
+				_, _ = w.Write(rr)
+				return
+			}
 		case "nodebalancers":
 			rx, _ := regexp.Compile("/nodebalancers/[0-9]+/configs/[0-9]+/nodes/[0-9]+")
 			if rx.MatchString(urlPath) {
@@ -244,8 +289,14 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				data := []linodego.NodeBalancerConfig{}
 				filter := r.Header.Get("X-Filter")
 				if filter == "" {
+					nbID, err := strconv.Atoi(strings.Split(urlPath[1:], "/")[1])
+					if err != nil {
+						f.t.Fatal(err)
+					}
 					for _, n := range f.nbc {
-						data = append(data, *n)
+						if n.NodeBalancerID == nbID {
+							data = append(data, *n)
+						}
 					}
 				} else {
 					var fs filterStruct
@@ -329,6 +380,35 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	case "POST":
 		tp := filepath.Base(r.URL.Path)
+		if tp == "firewalls" {
+			fwco := linodego.FirewallCreateOptions{}
+			if err := json.NewDecoder(r.Body).Decode(&fwco); err != nil {
+				f.t.Fatal(err)
+			}
+
+			fw := linodego.Firewall{
+				ID:     rand.Intn(9999),
+				Label:  fwco.Label,
+				Status: linodego.FirewallEnabled,
+				Tags:   fwco.Tags,
+				Rules:  fwco.Rules,
+			}
+			devices := make([]linodego.FirewallDevice, 0, len(fwco.Devices.NodeBalancers))
+			for _, id := range fwco.Devices.NodeBalancers {
+				devices = append(devices, linodego.FirewallDevice{
+					ID:     rand.Intn(9999),
+					Entity: linodego.FirewallDeviceEntity{ID: id, Type: linodego.FirewallDeviceNodeBalancer},
+				})
+			}
+			f.addFirewall(fw, devices...)
+
+			resp, err := json.Marshal(fw)
+			if err != nil {
+				f.t.Fatal(err)
+			}
+			_, _ = w.Write(resp)
+			return
+		}
 		if tp == "nodebalancers" {
 			nbco := linodego.NodeBalancerCreateOptions{}
 			if err := json.NewDecoder(r.Body).Decode(&nbco); err != nil {
@@ -343,6 +423,7 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				Region:   nbco.Region,
 				IPv4:     &ip,
 				Hostname: &hostname,
+				Tags:     nbco.Tags,
 			}
 
 			if nbco.ClientConnThrottle != nil {
@@ -560,6 +641,9 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 					delete(f.nbn, k)
 				}
 			}
+		} else if strings.Contains(r.URL.Path, "firewalls") {
+			delete(f.fw, idRaw)
+			delete(f.fwd, id)
 		} else if strings.Contains(r.URL.Path, "nodebalancers") {
 			delete(f.nb, idRaw)
 
@@ -576,8 +660,47 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	case "PUT":
-		if strings.Contains(r.URL.Path, "nodes") {
-			f.t.Fatal("PUT ...nodes is not supported by the mock API")
+		if strings.Contains(r.URL.Path, "firewalls") {
+			parts := strings.Split(r.URL.Path[1:], "/")
+			fw, found := f.fw[parts[2]]
+			if !found || len(parts) != 4 || parts[3] != "rules" {
+				f.writeNotFound(w)
+				return
+			}
+			fw.Rules = linodego.FirewallRuleSet{}
+			if err := json.NewDecoder(r.Body).Decode(&fw.Rules); err != nil {
+				f.t.Fatal(err)
+			}
+
+			resp, err := json.Marshal(fw.Rules)
+			if err != nil {
+				f.t.Fatal(err)
+			}
+			_, _ = w.Write(resp)
+			return
+		} else if strings.Contains(r.URL.Path, "nodes") {
+			nbn, found := f.nbn[filepath.Base(r.URL.Path)]
+			if !found {
+				f.writeNotFound(w)
+				return
+			}
+			nbnuo := new(linodego.NodeBalancerNodeUpdateOptions)
+			if err := json.NewDecoder(r.Body).Decode(nbnuo); err != nil {
+				f.t.Fatal(err)
+			}
+			if nbnuo.Mode != "" {
+				nbn.Mode = nbnuo.Mode
+			}
+			if nbnuo.Weight != 0 {
+				nbn.Weight = nbnuo.Weight
+			}
+
+			resp, err := json.Marshal(nbn)
+			if err != nil {
+				f.t.Fatal(err)
+			}
+			_, _ = w.Write(resp)
+			return
 		} else if strings.Contains(r.URL.Path, "configs") {
 			parts := strings.Split(r.URL.Path[1:], "/")
 			nbcco := new(linodego.NodeBalancerConfigUpdateOptions)
@@ -653,6 +776,9 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				if nbuo.Label != nil {
 					nb.Label = nbuo.Label
 				}
+				if nbuo.Tags != nil {
+					nb.Tags = *nbuo.Tags
+				}
 
 				f.nb[strconv.Itoa(nb.ID)] = nb
 				resp, err := json.Marshal(nb)
@@ -676,6 +802,23 @@ func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (f *fakeAPI) writeNotFound(w http.ResponseWriter) {
+	w.WriteHeader(404)
+	resp := linodego.APIError{
+		Errors: []linodego.APIErrorReason{
+			{Reason: "Not Found"},
+		},
+	}
+	rr, _ := json.Marshal(resp)
+	_, _ = w.Write(rr)
+}
+
+// addFirewall adds a firewall with the given devices to the fake API.
+func (f *fakeAPI) addFirewall(fw linodego.Firewall, devices ...linodego.FirewallDevice) {
+	f.fw[strconv.Itoa(fw.ID)] = &fw
+	f.fwd[fw.ID] = devices
+}
+
 func randString(n int) string {
 	const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	b := make([]byte, n)
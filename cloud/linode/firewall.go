@@ -0,0 +1,316 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	servicehelpers "k8s.io/cloud-provider/service/helpers"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annLinodeFirewallAllowNodes, when true, makes the Cloud Firewall the CCM manages for
+	// a service with source ranges also admit the addresses of the cluster's nodes, so
+	// that clients in the cluster can reach the service through its NodeBalancer.
+	annLinodeFirewallAllowNodes = "service.beta.kubernetes.io/linode-loadbalancer-firewall-allow-nodes"
+
+	// firewallRulesAllowAll is the rules key of a service with no source ranges, which
+	// needs no Cloud Firewall.
+	firewallRulesAllowAll = "allow-all"
+
+	// maxFirewallRuleAddresses and maxFirewallInboundRules are the most addresses a Cloud
+	// Firewall rule, and the most inbound rules a Cloud Firewall, may hold.
+	maxFirewallRuleAddresses = 255
+	maxFirewallInboundRules  = 25
+)
+
+// firewallLabel returns the label of the Cloud Firewall the CCM manages for the
+// NodeBalancer with the given ID.
+func firewallLabel(nodeBalancerID int) string {
+	return fmt.Sprintf("ccm-nodebalancer-%d", nodeBalancerID)
+}
+
+// getFirewallIDAnnotation returns the ID of the externally managed Cloud Firewall the
+// service is annotated with, if any.
+func getFirewallIDAnnotation(service *v1.Service) (int, bool) {
+	rawID, _ := getServiceAnnotation(service, annLinodeFirewallID)
+	id, err := strconv.Atoi(rawID)
+	return id, err == nil && id != 0
+}
+
+// shouldFirewallAllowNodes reports whether the service's firewall should admit the
+// addresses of the cluster's nodes.
+func shouldFirewallAllowNodes(service *v1.Service) bool {
+	raw, ok := getServiceAnnotation(service, annLinodeFirewallAllowNodes)
+	if !ok {
+		return false
+	}
+	allow, err := strconv.ParseBool(raw)
+	return err == nil && allow
+}
+
+// makeFirewallRules returns the inbound rules restricting the service's ports to its
+// load balancer source ranges, and to the addresses of nodes if the service is annotated
+// with firewall-allow-nodes, and a key identifying them. allowAll is true when the
+// service does not restrict its sources.
+func makeFirewallRules(service *v1.Service, nodes []*v1.Node) (rules linodego.FirewallRuleSet, key string, allowAll bool, err error) {
+	sourceRanges, err := servicehelpers.GetLoadBalancerSourceRanges(service)
+	if err != nil {
+		return rules, "", false, err
+	}
+	if servicehelpers.IsAllowAll(sourceRanges) {
+		return rules, firewallRulesAllowAll, true, nil
+	}
+
+	addresses := linodego.NetworkAddresses{IPv4: []string{}, IPv6: []string{}}
+	for cidr, ipnet := range sourceRanges {
+		if ipnet.IP.To4() != nil {
+			addresses.IPv4 = append(addresses.IPv4, cidr)
+		} else {
+			addresses.IPv6 = append(addresses.IPv6, cidr)
+		}
+	}
+	sort.Strings(addresses.IPv4)
+	sort.Strings(addresses.IPv6)
+
+	ports := make([]string, 0, len(service.Spec.Ports))
+	for _, port := range sortedServicePorts(service) {
+		ports = append(ports, strconv.Itoa(int(port.Port)))
+	}
+
+	rules.Inbound = []linodego.FirewallRule{{
+		Ports:     strings.Join(ports, ","),
+		Protocol:  linodego.TCP,
+		Addresses: addresses,
+	}}
+	if shouldFirewallAllowNodes(service) {
+		rules.Inbound = append(rules.Inbound, makeNodeFirewallRules(strings.Join(ports, ","), nodes)...)
+	}
+	if len(rules.Inbound) > maxFirewallInboundRules {
+		return rules, "", false, fmt.Errorf("%d node addresses need %d Firewall rules, more than the %d allowed",
+			countFirewallRuleAddresses(rules.Inbound[1:]), len(rules.Inbound), maxFirewallInboundRules)
+	}
+	key, err = firewallRulesKey(rules)
+	return rules, key, false, err
+}
+
+// makeNodeFirewallRules returns inbound rules admitting the internal and external
+// addresses of nodes to ports. Addresses are deduplicated and sorted, so the rules only
+// change with the set of addresses, and split into rules of at most
+// maxFirewallRuleAddresses addresses.
+func makeNodeFirewallRules(ports string, nodes []*v1.Node) []linodego.FirewallRule {
+	seen := make(map[string]bool)
+	var ipv4, ipv6 []string
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if addr.Type != v1.NodeInternalIP && addr.Type != v1.NodeExternalIP {
+				continue
+			}
+			ip := net.ParseIP(addr.Address)
+			switch {
+			case ip == nil:
+			case ip.To4() != nil && !seen[ip.String()]:
+				ipv4 = append(ipv4, ip.String()+"/32")
+			case ip.To4() == nil && !seen[ip.String()]:
+				ipv6 = append(ipv6, ip.String()+"/128")
+			}
+			if ip != nil {
+				seen[ip.String()] = true
+			}
+		}
+	}
+	sort.Strings(ipv4)
+	sort.Strings(ipv6)
+
+	var rules []linodego.FirewallRule
+	for start := 0; start < len(ipv4); start += maxFirewallRuleAddresses {
+		rules = append(rules, linodego.FirewallRule{
+			Ports:     ports,
+			Protocol:  linodego.TCP,
+			Addresses: linodego.NetworkAddresses{IPv4: firewallAddressChunk(ipv4, start), IPv6: []string{}},
+		})
+	}
+	for start := 0; start < len(ipv6); start += maxFirewallRuleAddresses {
+		rules = append(rules, linodego.FirewallRule{
+			Ports:     ports,
+			Protocol:  linodego.TCP,
+			Addresses: linodego.NetworkAddresses{IPv4: []string{}, IPv6: firewallAddressChunk(ipv6, start)},
+		})
+	}
+	return rules
+}
+
+// firewallAddressChunk returns the up to maxFirewallRuleAddresses addresses of cidrs
+// from start.
+func firewallAddressChunk(cidrs []string, start int) []string {
+	end := start + maxFirewallRuleAddresses
+	if end > len(cidrs) {
+		end = len(cidrs)
+	}
+	return cidrs[start:end]
+}
+
+// countFirewallRuleAddresses returns the number of addresses across rules.
+func countFirewallRuleAddresses(rules []linodego.FirewallRule) int {
+	count := 0
+	for _, rule := range rules {
+		count += len(rule.Addresses.IPv4) + len(rule.Addresses.IPv6)
+	}
+	return count
+}
+
+// firewallRulesKey returns a key identifying rules, treating missing and empty address
+// lists alike.
+func firewallRulesKey(rules linodego.FirewallRuleSet) (string, error) {
+	normalize := func(in []linodego.FirewallRule) []linodego.FirewallRule {
+		out := make([]linodego.FirewallRule, 0, len(in))
+		for _, rule := range in {
+			if rule.Addresses.IPv4 == nil {
+				rule.Addresses.IPv4 = []string{}
+			}
+			if rule.Addresses.IPv6 == nil {
+				rule.Addresses.IPv6 = []string{}
+			}
+			out = append(out, rule)
+		}
+		return out
+	}
+	b, err := json.Marshal(linodego.FirewallRuleSet{
+		Inbound:  normalize(rules.Inbound),
+		Outbound: normalize(rules.Outbound),
+	})
+	return string(b), err
+}
+
+// reconcileFirewall makes the Cloud Firewall attached to nb allow only the service's
+// load balancer source ranges, creating it, updating its rules or deleting it as needed.
+// It is idempotent, and does not touch the NodeBalancer itself. Services annotated with
+// an externally managed firewall are left to that firewall.
+func (l *loadbalancers) reconcileFirewall(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node, nb *linodego.NodeBalancer) error {
+	serviceNn := getServiceNn(service)
+	if id, ok := getFirewallIDAnnotation(service); ok {
+		klog.V(2).Infof("skipping Firewall reconcile for service (%s) as it uses the externally managed Firewall (%d)", serviceNn, id)
+		return nil
+	}
+	rules, key, allowAll, err := makeFirewallRules(service, nodes)
+	if err != nil {
+		return fmt.Errorf("failed to make Firewall rules for service (%s): %s", serviceNn, err)
+	}
+
+	// Services without source ranges only need firewalls listed when one was created
+	// for them, so that most reconciles need neither the API call nor the firewall
+	// scope. A firewall whose source ranges were removed while the CCM was not running
+	// is instead deleted along with its NodeBalancer.
+	if allowAll && l.states.get(serviceNn).managedFirewallID == 0 {
+		l.states.update(serviceNn, func(state *serviceState) {
+			state.firewallRules = key
+		})
+		return nil
+	}
+
+	firewall, err := l.getManagedFirewall(ctx, clusterName, nb.ID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case allowAll && firewall == nil:
+	case allowAll:
+		if err := l.client.DeleteFirewall(ctx, firewall.ID); err != nil {
+			return fmt.Errorf("failed to delete Firewall (%d) for service (%s): %s", firewall.ID, serviceNn, err)
+		}
+		klog.Infof("deleted Firewall (%d) for service (%s) as it no longer has source ranges", firewall.ID, serviceNn)
+		firewall = nil
+	case firewall == nil:
+		firewall, err = l.client.CreateFirewall(ctx, linodego.FirewallCreateOptions{
+			Label:   firewallLabel(nb.ID),
+			Rules:   rules,
+			Tags:    []string{makeClusterTag(clusterName)},
+			Devices: linodego.DevicesCreationOptions{NodeBalancers: []int{nb.ID}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create Firewall for service (%s): %s", serviceNn, err)
+		}
+		klog.Infof("created Firewall (%d) for service (%s)", firewall.ID, serviceNn)
+	default:
+		currentKey, err := firewallRulesKey(firewall.Rules)
+		if err != nil {
+			return err
+		}
+		if currentKey != key {
+			if _, err := l.client.UpdateFirewallRules(ctx, firewall.ID, rules); err != nil {
+				return fmt.Errorf("failed to update rules of Firewall (%d) for service (%s): %s", firewall.ID, serviceNn, err)
+			}
+			klog.Infof("updated rules of Firewall (%d) for service (%s)", firewall.ID, serviceNn)
+		}
+	}
+
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.firewallRules = key
+		state.managedFirewallID = 0
+		if firewall != nil {
+			state.managedFirewallID = firewall.ID
+		}
+	})
+	return nil
+}
+
+// firewallUpToDate reports whether the service's source ranges, and nodes if it admits
+// them, match the firewall rules last reconciled for it, so reconcileFirewall has
+// nothing to do.
+func (l *loadbalancers) firewallUpToDate(service *v1.Service, nodes []*v1.Node) bool {
+	if _, ok := getFirewallIDAnnotation(service); ok {
+		return true
+	}
+	_, key, _, err := makeFirewallRules(service, nodes)
+	return err == nil && key == l.states.get(getServiceNn(service)).firewallRules
+}
+
+// getManagedFirewall returns the Cloud Firewall the CCM manages for the NodeBalancer
+// with the given ID, or nil if there is none. Managed firewalls carry the NodeBalancer's
+// label and the cluster tag, so a firewall created by anyone else is never returned.
+func (l *loadbalancers) getManagedFirewall(ctx context.Context, clusterName string, nodeBalancerID int) (*linodego.Firewall, error) {
+	firewalls, err := l.client.ListFirewalls(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	label := firewallLabel(nodeBalancerID)
+	clusterTag := makeClusterTag(clusterName)
+	for i := range firewalls {
+		if firewalls[i].Label == label && hasTag(firewalls[i].Tags, clusterTag) {
+			return &firewalls[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// deleteManagedFirewall deletes the Cloud Firewall the CCM manages for the NodeBalancer
+// with the given ID, if any, ahead of deleting the NodeBalancer. The externally managed
+// firewall the service is annotated with is never deleted, and a firewall which is
+// already gone is not an error.
+func (l *loadbalancers) deleteManagedFirewall(ctx context.Context, clusterName string, service *v1.Service, nodeBalancerID int) error {
+	firewall, err := l.getManagedFirewall(ctx, clusterName, nodeBalancerID)
+	if err != nil || firewall == nil {
+		return err
+	}
+	if id, ok := getFirewallIDAnnotation(service); ok && id == firewall.ID {
+		klog.Infof("skipping deletion of Firewall (%d) of NodeBalancer (%d) as it is managed externally", firewall.ID, nodeBalancerID)
+		return nil
+	}
+	if err := l.client.DeleteFirewall(ctx, firewall.ID); err != nil {
+		if apiErr, ok := err.(*linodego.Error); ok && apiErr.Code == http.StatusNotFound {
+			return nil
+		}
+		return fmt.Errorf("failed to delete Firewall (%d) of NodeBalancer (%d): %s", firewall.ID, nodeBalancerID, err)
+	}
+	klog.Infof("successfully deleted Firewall (%d) of NodeBalancer (%d)", firewall.ID, nodeBalancerID)
+	return nil
+}
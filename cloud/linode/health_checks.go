@@ -0,0 +1,441 @@
+package linode
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	annLinodeCheckPath       = "service.beta.kubernetes.io/linode-loadbalancer-check-path"
+	annLinodeCheckBody       = "service.beta.kubernetes.io/linode-loadbalancer-check-body"
+	annLinodeHealthCheckType = "service.beta.kubernetes.io/linode-loadbalancer-check-type"
+
+	// annLinodeCheckBodyMatch is how the check-body regex must match the response body:
+	// anywhere in it (substring), or the whole of it (full).
+	annLinodeCheckBodyMatch = "service.beta.kubernetes.io/linode-loadbalancer-check-body-match"
+
+	annLinodeHealthCheckInterval = "service.beta.kubernetes.io/linode-loadbalancer-check-interval"
+	annLinodeHealthCheckTimeout  = "service.beta.kubernetes.io/linode-loadbalancer-check-timeout"
+	annLinodeHealthCheckAttempts = "service.beta.kubernetes.io/linode-loadbalancer-check-attempts"
+	annLinodeHealthCheckPassive  = "service.beta.kubernetes.io/linode-loadbalancer-check-passive"
+
+	// annLinodeCheckExpectedCodes is a comma-separated list of the HTTP status codes
+	// expected from a healthy backend. linodego's NodeBalancerConfigCreateOptions has
+	// no field for them, as NodeBalancers accept any 2xx or 3xx, so it is rejected.
+	annLinodeCheckExpectedCodes = "service.beta.kubernetes.io/linode-loadbalancer-check-expected-codes"
+
+	// annLinodeCheckUserAgent is the User-Agent header http and http_body checks would
+	// send. linodego's NodeBalancerConfigCreateOptions has no check header field, so
+	// it is rejected.
+	annLinodeCheckUserAgent = "service.beta.kubernetes.io/linode-loadbalancer-check-user-agent"
+
+	// annLinodeHealthCheck is the annotation holding the complete health check
+	// configuration as JSON. It takes precedence over the individual check-*
+	// annotations, and can itself be overridden per port in the port config annotation.
+	annLinodeHealthCheck = "service.beta.kubernetes.io/linode-loadbalancer-healthcheck"
+)
+
+// How a http_body check's body regex must match the response body.
+const (
+	checkBodyMatchSubstring = "substring"
+	checkBodyMatchFull      = "full"
+)
+
+// maxHealthCheckInterval is the longest check interval, in seconds, the Linode Cloud
+// Manager offers. The API itself does not limit the interval, but jitter does not
+// increase an interval beyond it.
+const maxHealthCheckInterval = 3600
+
+// healthCheckAnnotation is the JSON representation of a health check, used by the
+// healthcheck annotation and the healthcheck key of the port config annotation.
+// Unset fields are inherited from the less specific configuration.
+type healthCheckAnnotation struct {
+	Type      string `json:"type"`
+	Path      string `json:"path"`
+	Body      string `json:"body"`
+	BodyMatch string `json:"body-match"`
+	Interval  *int   `json:"interval"`
+	Timeout   *int   `json:"timeout"`
+	Attempts  *int   `json:"attempts"`
+	Passive   *bool  `json:"passive"`
+
+	ExpectedCodes []int  `json:"expected-codes"`
+	UserAgent     string `json:"user-agent"`
+}
+
+// healthCheck is the resolved health check configuration for a NodeBalancer config.
+type healthCheck struct {
+	Type      linodego.ConfigCheck
+	Path      string
+	Body      string
+	BodyMatch string
+	Interval  int
+	Timeout   int
+	Attempts  int
+	Passive   bool
+
+	ExpectedCodes []int
+	UserAgent     string
+}
+
+// Levels of health check configuration, from least to most specific.
+const (
+	healthCheckLevelDefault = iota
+	healthCheckLevelCheckAnnotations
+	healthCheckLevelHealthCheckAnnotation
+	healthCheckLevelPortConfig
+)
+
+// healthCheckLevels records the most specific level of configuration which set the type,
+// path and body of a health check.
+type healthCheckLevels struct {
+	typ, path, body, bodyMatch int
+}
+
+func (l *healthCheckLevels) apply(level int, ann healthCheckAnnotation) {
+	if ann.Type != "" {
+		l.typ = level
+	}
+	if ann.Path != "" {
+		l.path = level
+	}
+	if ann.Body != "" {
+		l.body = level
+	}
+	if ann.BodyMatch != "" {
+		l.bodyMatch = level
+	}
+}
+
+// getHealthCheck resolves the health check for a port of service. The port config's
+// healthcheck overrides the service's healthcheck annotation, which in turn overrides
+// the individual check-* annotations.
+func getHealthCheck(service *v1.Service, portConfig portConfig) (healthCheck, error) {
+	health, err := getHealthCheckFromAnnotations(service)
+	if err != nil {
+		return health, err
+	}
+
+	checkType, _ := getServiceAnnotation(service, annLinodeHealthCheckType)
+	var levels healthCheckLevels
+	levels.apply(healthCheckLevelCheckAnnotations, healthCheckAnnotation{
+		Type:      checkType,
+		Path:      health.Path,
+		Body:      health.Body,
+		BodyMatch: health.BodyMatch,
+	})
+
+	if raw, ok := getServiceAnnotation(service, annLinodeHealthCheck); ok {
+		var ann healthCheckAnnotation
+		if err := unmarshalAnnotationJSON(annLinodeHealthCheck, raw, &ann); err != nil {
+			return health, err
+		}
+		health.apply(ann)
+		levels.apply(healthCheckLevelHealthCheckAnnotation, ann)
+	}
+
+	if portConfig.HealthCheck != nil {
+		health.apply(*portConfig.HealthCheck)
+		levels.apply(healthCheckLevelPortConfig, *portConfig.HealthCheck)
+	}
+
+	if (health.Type == linodego.CheckHTTP || health.Type == linodego.CheckHTTPBody) && health.Path == "" {
+		health.Path = "/"
+	}
+
+	if err := health.validate(); err != nil {
+		return health, fmt.Errorf("invalid health check for port %d: %s", portConfig.Port, err)
+	}
+	if err := health.validateUnused(levels); err != nil {
+		return health, fmt.Errorf("invalid health check for port %d: %s", portConfig.Port, err)
+	}
+	return health, nil
+}
+
+// getHealthCheckFromAnnotations returns the health check described by the individual
+// check-* annotations, with defaults for those which are not set.
+func getHealthCheckFromAnnotations(service *v1.Service) (healthCheck, error) {
+	path, _ := getServiceAnnotation(service, annLinodeCheckPath)
+	body, _ := getServiceAnnotation(service, annLinodeCheckBody)
+	bodyMatch, _ := getServiceAnnotation(service, annLinodeCheckBodyMatch)
+	userAgent, _ := getServiceAnnotation(service, annLinodeCheckUserAgent)
+	health := healthCheck{
+		Path:      path,
+		Body:      body,
+		BodyMatch: bodyMatch,
+		UserAgent: userAgent,
+		Interval:  5,
+		Timeout:   3,
+		Attempts:  2,
+		Passive:   true,
+	}
+
+	var err error
+	if health.Type, err = getHealthCheckType(service); err != nil {
+		return health, err
+	}
+
+	if ci, ok := getServiceAnnotation(service, annLinodeHealthCheckInterval); ok {
+		if health.Interval, err = strconv.Atoi(ci); err != nil {
+			return health, err
+		}
+	}
+
+	if ct, ok := getServiceAnnotation(service, annLinodeHealthCheckTimeout); ok {
+		if health.Timeout, err = strconv.Atoi(ct); err != nil {
+			return health, err
+		}
+	}
+
+	if ca, ok := getServiceAnnotation(service, annLinodeHealthCheckAttempts); ok {
+		if health.Attempts, err = strconv.Atoi(ca); err != nil {
+			return health, err
+		}
+	}
+
+	if cp, ok := getServiceAnnotation(service, annLinodeHealthCheckPassive); ok {
+		if health.Passive, err = strconv.ParseBool(cp); err != nil {
+			return health, err
+		}
+	}
+
+	if codes, ok := getServiceAnnotation(service, annLinodeCheckExpectedCodes); ok {
+		if health.ExpectedCodes, err = parseStatusCodes(codes); err != nil {
+			return health, fmt.Errorf("invalid %s annotation: %s", annLinodeCheckExpectedCodes, err)
+		}
+	}
+
+	return health, nil
+}
+
+func getHealthCheckType(service *v1.Service) (linodego.ConfigCheck, error) {
+	hType, ok := getServiceAnnotation(service, annLinodeHealthCheckType)
+	if !ok {
+		return linodego.CheckConnection, nil
+	}
+	if !isValidHealthCheckType(hType) {
+		return "", fmt.Errorf("invalid health check type: %q specified in annotation: %q", hType, annLinodeHealthCheckType)
+	}
+	return linodego.ConfigCheck(hType), nil
+}
+
+func isValidHealthCheckType(hType string) bool {
+	switch linodego.ConfigCheck(hType) {
+	case linodego.CheckNone, linodego.CheckConnection, linodego.CheckHTTP, linodego.CheckHTTPBody:
+		return true
+	}
+	return false
+}
+
+// apply overrides the fields of h with those set in ann.
+func (h *healthCheck) apply(ann healthCheckAnnotation) {
+	if ann.Type != "" {
+		h.Type = linodego.ConfigCheck(ann.Type)
+	}
+	if ann.Path != "" {
+		h.Path = ann.Path
+	}
+	if ann.Body != "" {
+		h.Body = ann.Body
+	}
+	if ann.BodyMatch != "" {
+		h.BodyMatch = ann.BodyMatch
+	}
+	if ann.Interval != nil {
+		h.Interval = *ann.Interval
+	}
+	if ann.Timeout != nil {
+		h.Timeout = *ann.Timeout
+	}
+	if ann.Attempts != nil {
+		h.Attempts = *ann.Attempts
+	}
+	if ann.Passive != nil {
+		h.Passive = *ann.Passive
+	}
+	if ann.ExpectedCodes != nil {
+		h.ExpectedCodes = ann.ExpectedCodes
+	}
+	if ann.UserAgent != "" {
+		h.UserAgent = ann.UserAgent
+	}
+}
+
+// validate checks that h is a health check the Linode API will accept.
+func (h healthCheck) validate() error {
+	if !isValidHealthCheckType(string(h.Type)) {
+		return fmt.Errorf("invalid type %q", h.Type)
+	}
+	if h.Type == linodego.CheckHTTPBody && h.Body == "" {
+		return fmt.Errorf("for health check type http_body need body regex annotation %v", annLinodeCheckBody)
+	}
+	switch h.BodyMatch {
+	case "", checkBodyMatchSubstring:
+	case checkBodyMatchFull:
+		if _, err := regexp.Compile(h.bodyPattern()); err != nil {
+			return fmt.Errorf("body %q cannot be matched in full: %s", h.Body, err)
+		}
+	default:
+		return fmt.Errorf("invalid body match %q, must be %q or %q", h.BodyMatch, checkBodyMatchSubstring, checkBodyMatchFull)
+	}
+	// The API only limits the timeout and attempts, and requires the interval to exceed
+	// the timeout. Checks of type none are never run, and their settings were never
+	// validated before, so they are left alone.
+	if h.Type != linodego.CheckNone {
+		if h.Timeout < 1 || h.Timeout > 30 {
+			return fmt.Errorf("timeout must be between 1 and 30, got %d", h.Timeout)
+		}
+		if h.Timeout >= h.Interval {
+			return fmt.Errorf("timeout (%d) must be less than interval (%d)", h.Timeout, h.Interval)
+		}
+		if h.Attempts < 1 || h.Attempts > 30 {
+			return fmt.Errorf("attempts must be between 1 and 30, got %d", h.Attempts)
+		}
+	}
+	if err := validateCheckUserAgent(h.UserAgent); err != nil {
+		return err
+	}
+	if err := validateCheckExpectedCodes(h.Type, h.ExpectedCodes); err != nil {
+		return err
+	}
+	if h.Type == linodego.CheckHTTP || h.Type == linodego.CheckHTTPBody {
+		if checkPathHasCredentials(h.Path) {
+			return fmt.Errorf("path %q includes credentials, but NodeBalancer health checks cannot authenticate to back-ends", redactCheckPath(h.Path))
+		}
+	}
+	return nil
+}
+
+// validateCheckUserAgent checks a health check user agent, such as one set by the
+// check-user-agent annotation or the user-agent key of a healthcheck. NodeBalancer
+// health checks send a fixed request, which neither the API nor the check path can add
+// headers to, so any user agent is rejected; one which could not be sent as a header is
+// reported as such first.
+func validateCheckUserAgent(userAgent string) error {
+	if userAgent == "" {
+		return nil
+	}
+	for _, r := range userAgent {
+		if r < ' ' || r == 0x7f {
+			return fmt.Errorf("user agent %q is not a valid header value: it contains control characters", userAgent)
+		}
+	}
+	return fmt.Errorf("user agent %q cannot be set: NodeBalancer health checks do not support custom headers; filter health checks on their path instead", userAgent)
+}
+
+// validateCheckExpectedCodes checks the expected codes of a health check of type
+// checkType, such as those set by the check-expected-codes annotation or the
+// expected-codes key of a healthcheck. NodeBalancer http and http_body checks treat every
+// 2xx and 3xx response as healthy, which the API cannot change, so any codes are
+// rejected; codes for other check types, and codes which are not HTTP statuses, are
+// reported as such first.
+func validateCheckExpectedCodes(checkType linodego.ConfigCheck, codes []int) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	if checkType != linodego.CheckHTTP && checkType != linodego.CheckHTTPBody {
+		return fmt.Errorf("expected codes %v are only used by http and http_body checks, not %s checks", codes, checkType)
+	}
+	for _, code := range codes {
+		if http.StatusText(code) == "" {
+			return fmt.Errorf("expected code %d is not a valid HTTP status", code)
+		}
+	}
+	return fmt.Errorf("expected codes %v cannot be set: NodeBalancer health checks treat every 2xx and 3xx response as healthy; use a check-body or a dedicated check-path to tell back-ends apart instead", codes)
+}
+
+// validateUnused checks that h does not set a path or body its type ignores, which would
+// otherwise be silently dropped. A path or body inherited from less specific
+// configuration than the type is allowed, so that a port can switch a service's http
+// check to a connection check without unsetting the path.
+func (h healthCheck) validateUnused(levels healthCheckLevels) error {
+	if h.Type != linodego.CheckHTTP && h.Type != linodego.CheckHTTPBody && levels.path != healthCheckLevelDefault && levels.path >= levels.typ {
+		return fmt.Errorf("path %q is only used by http and http_body checks, not %s checks", redactCheckPath(h.Path), h.Type)
+	}
+	if h.Type != linodego.CheckHTTPBody && levels.body != healthCheckLevelDefault && levels.body >= levels.typ {
+		return fmt.Errorf("body %q is only used by http_body checks, not %s checks", h.Body, h.Type)
+	}
+	if h.Type != linodego.CheckHTTPBody && levels.bodyMatch != healthCheckLevelDefault && levels.bodyMatch >= levels.typ {
+		return fmt.Errorf("body match %q is only used by http_body checks, not %s checks", h.BodyMatch, h.Type)
+	}
+	return nil
+}
+
+// bodyPattern returns the regex the NodeBalancer searches the response body for. The
+// NodeBalancer matches it anywhere in the body, so a full match anchors it at both ends.
+func (h healthCheck) bodyPattern() string {
+	if h.BodyMatch == checkBodyMatchFull {
+		return "^(" + h.Body + ")$"
+	}
+	return h.Body
+}
+
+// splitCheckPathUserinfo splits the userinfo, such as "user:password", from a check path
+// written as "[scheme://]userinfo@host/path" or "userinfo@/path".
+func splitCheckPathUserinfo(path string) (prefix, userinfo, rest string, ok bool) {
+	if i := strings.Index(path, "://"); i >= 0 {
+		prefix, path = path[:i+3], path[i+3:]
+	}
+	authority := path
+	if i := strings.Index(path, "/"); i >= 0 {
+		authority = path[:i]
+	}
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return "", "", "", false
+	}
+	return prefix, path[:at], path[at+1:], true
+}
+
+func checkPathHasCredentials(path string) bool {
+	_, _, _, ok := splitCheckPathUserinfo(path)
+	return ok
+}
+
+// redactCheckPath returns path with any credentials replaced, so that it is safe to log
+// or include in events.
+func redactCheckPath(path string) string {
+	prefix, _, rest, ok := splitCheckPathUserinfo(path)
+	if !ok {
+		return path
+	}
+	return prefix + "REDACTED@" + rest
+}
+
+// jitterCheckInterval returns interval increased by up to maxJitter seconds. The jitter
+// is derived from the service's UID and the port, so each config gets a different but
+// stable interval, which spreads out the checks of services with the same settings.
+// Jitter does not increase the interval beyond maxHealthCheckInterval, and longer
+// intervals are left as they are.
+func jitterCheckInterval(service *v1.Service, port, interval, maxJitter int) int {
+	if maxJitter <= 0 || interval >= maxHealthCheckInterval {
+		return interval
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", service.UID, port)))
+	jitter := int(binary.BigEndian.Uint32(sum[:4]) % uint32(maxJitter+1))
+	if interval+jitter > maxHealthCheckInterval {
+		return maxHealthCheckInterval
+	}
+	return interval + jitter
+}
+
+// parseStatusCodes parses a comma-separated list of HTTP status codes.
+func parseStatusCodes(raw string) ([]int, error) {
+	var codes []int
+	for _, field := range strings.Split(raw, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a status code", field)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
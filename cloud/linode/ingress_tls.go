@@ -0,0 +1,77 @@
+package linode
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// annLinodeIngressTLS lists TLS secrets in the "host:secretName,..." form used by
+// ingress controllers, to ease migrating from them. NodeBalancers serve a single
+// certificate per port and do not support SNI, so every host must name the same secret,
+// whose certificate covers them all. It is used by https ports which set neither
+// tls-secret-name nor tls-object-storage.
+const annLinodeIngressTLS = "service.beta.kubernetes.io/linode-loadbalancer-ingress-tls"
+
+// getIngressTLSSecretName returns the TLS secret named by the service's ingress-tls
+// annotation, or an empty name if the service has none.
+func getIngressTLSSecretName(service *v1.Service) (string, error) {
+	raw, ok := getServiceAnnotation(service, annLinodeIngressTLS)
+	if !ok {
+		return "", nil
+	}
+	secretName, _, err := parseIngressTLS(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s annotation: %s", annLinodeIngressTLS, err)
+	}
+	return secretName, nil
+}
+
+// parseIngressTLS parses a list of "host:secretName" entries, separated by commas, which
+// must all name the same secret. The secret may be given as "namespace/name". Hosts are
+// returned sorted.
+func parseIngressTLS(raw string) (secretName string, hosts []string, err error) {
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 2 {
+			return "", nil, fmt.Errorf("%q must be of the form host:secretName", entry)
+		}
+		host, secret := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		var msgs []string
+		if strings.HasPrefix(host, "*.") {
+			msgs = validation.IsWildcardDNS1123Subdomain(host)
+		} else {
+			msgs = validation.IsDNS1123Subdomain(host)
+		}
+		if len(msgs) > 0 {
+			return "", nil, fmt.Errorf("invalid host %q: %s", host, strings.Join(msgs, ", "))
+		}
+		if seen[host] {
+			return "", nil, fmt.Errorf("host %q is listed more than once", host)
+		}
+		seen[host] = true
+
+		if _, _, err := parseTLSSecretRef(secret, ""); err != nil {
+			return "", nil, fmt.Errorf("invalid secret for host %q: %s", host, err)
+		}
+		if secretName != "" && secret != secretName {
+			return "", nil, fmt.Errorf("hosts name both secret %q and %q, but NodeBalancers serve a single certificate per port and do not support SNI", secretName, secret)
+		}
+		secretName = secret
+		hosts = append(hosts, host)
+	}
+	if secretName == "" {
+		return "", nil, fmt.Errorf("no host:secretName entries")
+	}
+	sort.Strings(hosts)
+	return secretName, hosts, nil
+}
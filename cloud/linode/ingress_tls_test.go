@@ -0,0 +1,108 @@
+package linode
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_parseIngressTLS(t *testing.T) {
+	testcases := []struct {
+		name       string
+		raw        string
+		secretName string
+		hosts      []string
+		wantErr    string
+	}{
+		{"single host", "app.example.com:app-tls", "app-tls", []string{"app.example.com"}, ""},
+		{"hosts sharing a secret", " www.example.com:app-tls, *.example.com:app-tls,", "app-tls", []string{"*.example.com", "www.example.com"}, ""},
+		{"secret in another namespace", "app.example.com:certs/app-tls", "certs/app-tls", []string{"app.example.com"}, ""},
+		{"hosts with different secrets", "a.example.com:a-tls,b.example.com:b-tls", "", nil, "do not support SNI"},
+		{"missing secret", "app.example.com", "", nil, "host:secretName"},
+		{"invalid host", "App_1.example.com:app-tls", "", nil, "invalid host"},
+		{"invalid secret", "app.example.com:App_TLS", "", nil, "invalid secret"},
+		{"duplicate host", "app.example.com:app-tls,app.example.com:app-tls", "", nil, "more than once"},
+		{"empty", " , ", "", nil, "no host:secretName entries"},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			secretName, hosts, err := parseIngressTLS(test.raw)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if secretName != test.secretName || !reflect.DeepEqual(hosts, test.hosts) {
+				t.Errorf("expected secret %q for hosts %v, got %q for %v", test.secretName, test.hosts, secretName, hosts)
+			}
+		})
+	}
+}
+
+func TestBuildNodeBalancerConfigIngressTLS(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	if _, err := kubeClient.CoreV1().Secrets("apps").Create(context.TODO(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-tls", Namespace: "apps"},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte(testCert),
+			v1.TLSPrivateKeyKey: []byte(testKey),
+		},
+		Type: v1.SecretTypeTLS,
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	lb := &loadbalancers{kubeClient: kubeClient}
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "https",
+			Namespace: "apps",
+			UID:       "abc123",
+			Annotations: map[string]string{
+				annLinodeIngressTLS:                "app.example.com:app-tls,www.example.com:app-tls",
+				annLinodePortConfigPrefix + "443":  `{"protocol": "https"}`,
+				annLinodePortConfigPrefix + "8443": `{"protocol": "https", "tls-secret-name": "other-tls"}`,
+			},
+		},
+	}
+
+	config, err := lb.buildNodeBalancerConfig(context.TODO(), service, 443)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Protocol != linodego.ProtocolHTTPS || config.SSLCert != strings.TrimSpace(testCert) || config.SSLKey != strings.TrimSpace(testKey) {
+		t.Errorf("expected the https config to use the ingress-tls secret, got %+v", config)
+	}
+
+	config, err = lb.buildNodeBalancerConfig(context.TODO(), service, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Protocol != linodego.ProtocolTCP || config.SSLCert != "" {
+		t.Errorf("expected the tcp config not to use a certificate, got %+v", config)
+	}
+
+	portConfig, err := getPortConfig(service, 8443)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if portConfig.TLSSecretName != "other-tls" {
+		t.Errorf("expected the port's own tls-secret-name to take precedence, got %q", portConfig.TLSSecretName)
+	}
+
+	service.Annotations[annLinodeIngressTLS] = "app.example.com:app-tls,www.example.com:www-tls"
+	if _, err := lb.buildNodeBalancerConfig(context.TODO(), service, 443); err == nil || !strings.Contains(err.Error(), annLinodeIngressTLS) {
+		t.Errorf("expected an error naming the %s annotation, got %v", annLinodeIngressTLS, err)
+	}
+}
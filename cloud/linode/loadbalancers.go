@@ -2,18 +2,30 @@ package linode
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
+	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/klog/v2"
 
@@ -22,30 +34,89 @@ import (
 )
 
 const (
+	// annLinodeAnnotationPrefix prefixes every service annotation which configures the
+	// service's NodeBalancer.
+	annLinodeAnnotationPrefix = "service.beta.kubernetes.io/linode-loadbalancer-"
+
 	// annLinodeDefaultProtocol is the annotation used to specify the default protocol
 	// for Linode load balancers. Options are tcp, http and https. Defaults to tcp.
 	annLinodeDefaultProtocol      = "service.beta.kubernetes.io/linode-loadbalancer-default-protocol"
 	annLinodePortConfigPrefix     = "service.beta.kubernetes.io/linode-loadbalancer-port-"
 	annLinodeDefaultProxyProtocol = "service.beta.kubernetes.io/linode-loadbalancer-default-proxy-protocol"
+	annLinodeDefaultAlgorithm     = "service.beta.kubernetes.io/linode-loadbalancer-default-algorithm"
+	annLinodeDefaultStickiness    = "service.beta.kubernetes.io/linode-loadbalancer-default-stickiness"
 
-	annLinodeCheckPath       = "service.beta.kubernetes.io/linode-loadbalancer-check-path"
-	annLinodeCheckBody       = "service.beta.kubernetes.io/linode-loadbalancer-check-body"
-	annLinodeHealthCheckType = "service.beta.kubernetes.io/linode-loadbalancer-check-type"
-
-	annLinodeHealthCheckInterval = "service.beta.kubernetes.io/linode-loadbalancer-check-interval"
-	annLinodeHealthCheckTimeout  = "service.beta.kubernetes.io/linode-loadbalancer-check-timeout"
-	annLinodeHealthCheckAttempts = "service.beta.kubernetes.io/linode-loadbalancer-check-attempts"
-	annLinodeHealthCheckPassive  = "service.beta.kubernetes.io/linode-loadbalancer-check-passive"
+	// annLinodePreserveSourceIP passes client addresses on to backends by whichever
+	// means suits each port's protocol: PROXY protocol for tcp, and the X-Forwarded-For
+	// header NodeBalancers add for http and https.
+	annLinodePreserveSourceIP = "service.beta.kubernetes.io/linode-loadbalancer-preserve-source-ip"
 
 	// annLinodeThrottle is the annotation specifying the value of the Client Connection
 	// Throttle, which limits the number of subsequent new connections per second from the
 	// same client IP. Options are a number between 1-20, or 0 to disable. Defaults to 20.
+	// NodeBalancers have no limit on a client's concurrent connections, so this is the
+	// only per-client limit there is to configure.
 	annLinodeThrottle = "service.beta.kubernetes.io/linode-loadbalancer-throttle"
 
 	annLinodeLoadBalancerPreserve = "service.beta.kubernetes.io/linode-loadbalancer-preserve"
 	annLinodeNodeBalancerID       = "service.beta.kubernetes.io/linode-loadbalancer-nodebalancer-id"
+
+	// annLinodeSkipStatusUpdate stops the CCM from writing the service's ingress status,
+	// for when another tool manages it. The NodeBalancer is then found by its label.
+	annLinodeSkipStatusUpdate = "service.beta.kubernetes.io/linode-loadbalancer-skip-status-update"
+
+	// annLinodeFirewallID names a Cloud Firewall, managed outside the CCM, which protects
+	// the service's NodeBalancer. The CCM then neither manages a firewall for the
+	// service's source ranges nor deletes this one.
+	annLinodeFirewallID = "service.beta.kubernetes.io/linode-loadbalancer-firewall-id"
+
+	// annLinodeBackendNodeSelector is a label selector, such as "lke.linode.com/pool-id=1234",
+	// restricting the service's backends to the nodes it matches, for dedicating a node
+	// pool to the service.
+	annLinodeBackendNodeSelector = "service.beta.kubernetes.io/linode-loadbalancer-backend-node-selector"
+
+	// annLinodeNodeBalancerTags is a comma-separated list of tags to apply to the
+	// service's NodeBalancer, each prefixed with serviceTagPrefix.
+	annLinodeNodeBalancerTags = "service.beta.kubernetes.io/linode-loadbalancer-tags"
+
+	// annLinodeNodeBackendIP is set on a Node to override the address NodeBalancers use to
+	// reach it, which otherwise is the Node's internal IP.
+	annLinodeNodeBackendIP = "node.linode.com/nodebalancer-backend-ip"
+)
+
+const (
+	eventReasonNodeBalancerNotFound   = "NodeBalancerNotFound"
+	eventReasonEnsuredLoadBalancer    = "EnsuredLoadBalancer"
+	eventReasonNodePortOutOfRange     = "NodePortOutOfRange"
+	eventReasonRegionFallback         = "NodeBalancerRegionFallback"
+	eventReasonNoBackendNodes         = "NoBackendNodes"
+	eventReasonDuplicateNodeBalancers = "DuplicateNodeBalancers"
+	eventReasonRegionAtCapacity       = "NodeBalancerRegionAtCapacity"
+	eventReasonNoServicePorts         = "NoServicePorts"
+
+	eventReasonDeprecatedAnnotationPrefix = "DeprecatedAnnotationPrefix"
+	eventReasonNodePortRouting            = "NodePortRouting"
+	eventReasonInvalidServiceConfig       = "InvalidServiceConfig"
+	eventReasonForbiddenSecretNamespace   = "ForbiddenSecretNamespace"
+)
+
+const (
+	nodeBalancerLabelMaxLength  = 32
+	nodeBalancerLabelHashLength = 8
 )
 
+// maxNodeBalancerConfigNodes is the maximum number of backend nodes the Linode API
+// accepts for a single NodeBalancer config.
+const maxNodeBalancerConfigNodes = 100
+
+// transientErrorRetryInterval is how long EnsureLoadBalancer waits before retrying after
+// a transient error. It is a variable so tests can shorten it.
+var transientErrorRetryInterval = time.Second
+
+// maxNodeBalancerDeleteAttempts caps how many times deleteNodeBalancer tries to delete a
+// NodeBalancer before giving up.
+const maxNodeBalancerDeleteAttempts = 3
+
 type lbNotFoundError struct {
 	serviceNn      string
 	nodeBalancerID int
@@ -58,64 +129,196 @@ func (e lbNotFoundError) Error() string {
 	return fmt.Sprintf("LoadBalancer not found for service (%s)", e.serviceNn)
 }
 
+// lbAdoptedNotFoundError is returned when the NodeBalancer referenced by the
+// nodebalancer-id annotation no longer exists. Unlike NodeBalancers created by
+// the CCM, an adopted NodeBalancer is owned by the user and is never recreated.
+type lbAdoptedNotFoundError struct {
+	lbNotFoundError
+}
+
+func (e lbAdoptedNotFoundError) Error() string {
+	return fmt.Sprintf("%s annotation points to a NodeBalancer that does not exist: %s", annLinodeNodeBalancerID, e.lbNotFoundError)
+}
+
+// regionCapacityError is returned when every region a NodeBalancer may be created in
+// lacks capacity for it. Capacity is freed over time, so the service controller's
+// retries will eventually succeed.
+type regionCapacityError struct {
+	regions []string
+	err     error
+}
+
+func (e regionCapacityError) Error() string {
+	return fmt.Sprintf("no capacity for a new NodeBalancer in region(s) %s, will retry: %s", strings.Join(e.regions, ", "), e.err)
+}
+
+func (e regionCapacityError) Unwrap() error {
+	return e.err
+}
+
+// reconcileAbortedError is returned when a reconcile stops part-way because the
+// service or its NodeBalancer is being deleted, so that it does not recreate backends on
+// a NodeBalancer which is being torn down.
+type reconcileAbortedError struct {
+	serviceNn string
+	reason    string
+}
+
+func (e reconcileAbortedError) Error() string {
+	return fmt.Sprintf("aborted reconciling service (%s): %s", e.serviceNn, e.reason)
+}
+
+// forbiddenSecretNamespaceError is returned when a port references a TLS secret in
+// another namespace than the service's while cross-namespace TLS secrets are not allowed.
+type forbiddenSecretNamespaceError struct {
+	port            int
+	namespace       string
+	secretNamespace string
+	secretName      string
+}
+
+func (e forbiddenSecretNamespaceError) Error() string {
+	return fmt.Sprintf("TLS secret %s/%s for port %v is in namespace %q, not the service's namespace %q, and cross-namespace TLS secrets are not allowed; run the CCM with --allow-cross-namespace-tls-secrets to allow them",
+		e.secretNamespace, e.secretName, e.port, e.secretNamespace, e.namespace)
+}
+
+// nodeBalancerLabelTakenError is returned when the NodeBalancers labeled as the
+// service's NodeBalancer would be were not created for it, so it can neither take them
+// over nor create another with their label.
+type nodeBalancerLabelTakenError struct {
+	serviceNn      string
+	label          string
+	nodeBalancerID int
+}
+
+func (e nodeBalancerLabelTakenError) Error() string {
+	return fmt.Sprintf("NodeBalancer (%d) is labeled %s but was not created for service (%s) by this cluster; rename it or change --nodebalancer-label-template",
+		e.nodeBalancerID, e.label, e.serviceNn)
+}
+
 type loadbalancers struct {
 	client *linodego.Client
 	zone   string
 
 	kubeClient kubernetes.Interface
+	recorder   record.EventRecorder
+
+	// objectStorage fetches TLS certificates from Object Storage, and is nil when no
+	// Object Storage credentials are configured.
+	objectStorage *objectStorageClient
+
+	// lookups caches region lookups, and is nil when regions are not validated.
+	lookups *lookupCache
+
+	// labelTemplate renders NodeBalancer labels, and is nil when they are derived from
+	// the service's namespace and name.
+	labelTemplate *template.Template
+
+	states serviceStates
+
+	// claims records which services have adopted which NodeBalancers.
+	claims nodeBalancerClaims
+
+	// refresh tracks the secrets services are built from, to reconcile the services when
+	// the secrets change.
+	refresh *refreshRegistry
 }
 
 type portConfigAnnotation struct {
-	TLSSecretName string `json:"tls-secret-name"`
-	Protocol      string `json:"protocol"`
-	ProxyProtocol string `json:"proxy-protocol"`
+	TLSSecretName    string                 `json:"tls-secret-name"`
+	TLSObjectStorage string                 `json:"tls-object-storage"`
+	Protocol         string                 `json:"protocol"`
+	ProxyProtocol    string                 `json:"proxy-protocol"`
+	Algorithm        string                 `json:"algorithm"`
+	Stickiness       string                 `json:"stickiness"`
+	HealthCheck      *healthCheckAnnotation `json:"healthcheck"`
+
+	// Check is shorthand for the type of the port's healthcheck, such as none to keep a
+	// metrics port from affecting backend health.
+	Check string `json:"check"`
 }
 
 type portConfig struct {
-	TLSSecretName string
-	Protocol      linodego.ConfigProtocol
-	ProxyProtocol linodego.ConfigProxyProtocol
-	Port          int
+	TLSSecretName    string
+	TLSObjectStorage string
+	Protocol         linodego.ConfigProtocol
+	ProxyProtocol    linodego.ConfigProxyProtocol
+	Algorithm        linodego.ConfigAlgorithm
+	Stickiness       linodego.ConfigStickiness
+	HealthCheck      *healthCheckAnnotation
+	Port             int
+
+	// WebSocketTCP is set when the port serves WebSockets, and so is proxied as tcp
+	// instead of http.
+	WebSocketTCP bool
 }
 
 // newLoadbalancers returns a cloudprovider.LoadBalancer whose concrete type is a *loadbalancer.
 func newLoadbalancers(client *linodego.Client, zone string) cloudprovider.LoadBalancer {
-	return &loadbalancers{client: client, zone: zone}
+	lb := &loadbalancers{client: client, zone: zone, refresh: newRefreshRegistry()}
+	lb.refresh.register(refreshKindSecret, lb.refreshServices)
+	return lb
 }
 
-func (l *loadbalancers) getNodeBalancerForService(ctx context.Context, service *v1.Service) (*linodego.NodeBalancer, error) {
+// getNodeBalancerIDAnnotation returns the ID of the NodeBalancer adopted through the
+// nodebalancer-id annotation, if any.
+func getNodeBalancerIDAnnotation(service *v1.Service) (int, bool) {
 	rawID, _ := getServiceAnnotation(service, annLinodeNodeBalancerID)
-	id, idErr := strconv.Atoi(rawID)
-	hasIDAnn := idErr == nil && id != 0
+	id, err := strconv.Atoi(rawID)
+	return id, err == nil && id != 0
+}
 
-	if hasIDAnn {
-		sentry.SetTag(ctx, "load_balancer_id", rawID)
+func (l *loadbalancers) getNodeBalancerForService(ctx context.Context, clusterName string, service *v1.Service) (*linodego.NodeBalancer, error) {
+	if id, hasIDAnn := getNodeBalancerIDAnnotation(service); hasIDAnn {
+		sentry.SetTag(ctx, "load_balancer_id", strconv.Itoa(id))
+		if err := l.claimAdoptedNodeBalancer(service, id); err != nil {
+			return nil, err
+		}
 		nb, err := l.getNodeBalancerByID(ctx, service, id)
 		switch err.(type) {
 		case nil:
 			return nb, nil
 
 		case lbNotFoundError:
-			return nil, fmt.Errorf("%s annotation points to a NodeBalancer that does not exist: %s", annLinodeNodeBalancerID, err)
+			return nil, lbAdoptedNotFoundError{err.(lbNotFoundError)}
 
 		default:
 			return nil, err
 		}
 	}
-	return l.getNodeBalancerByStatus(ctx, service)
+	serviceNn := getServiceNn(service)
+	l.claims.release(serviceNn)
+
+	nb, err := l.getNodeBalancerByStatus(ctx, service)
+	if err == nil && l.hasLeftSharedNodeBalancer(service, nb) {
+		klog.Infof("service (%s) has left the services sharing NodeBalancer (%d); it will be moved to a NodeBalancer of its own", serviceNn, nb.ID)
+		return nil, lbNotFoundError{serviceNn: serviceNn, nodeBalancerID: nb.ID}
+	}
+	if err == nil {
+		l.checkDuplicateNodeBalancers(ctx, clusterName, service, nb)
+	}
+	if _, ok := err.(lbNotFoundError); ok && shouldSkipStatusUpdate(service) {
+		return l.getNodeBalancerByLabel(ctx, clusterName, service, l.GetLoadBalancerName(ctx, clusterName, service))
+	}
+	return nb, err
 }
 
-func (l *loadbalancers) getLatestServiceLoadBalancerStatus(ctx context.Context, service *v1.Service) (v1.LoadBalancerStatus, error) {
+// getLatestService returns the latest version of service from the Kubernetes API.
+func (l *loadbalancers) getLatestService(ctx context.Context, service *v1.Service) (*v1.Service, error) {
 	err := l.retrieveKubeClient()
 	if err != nil {
-		return v1.LoadBalancerStatus{}, err
+		return nil, err
 	}
+	return l.kubeClient.CoreV1().Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+}
 
-	service, err = l.kubeClient.CoreV1().Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
-	if err != nil {
-		return v1.LoadBalancerStatus{}, err
+// checkNotDeleting returns a reconcileAbortedError if the service is being deleted.
+func (l *loadbalancers) checkNotDeleting(service *v1.Service) error {
+	serviceNn := getServiceNn(service)
+	if l.states.get(serviceNn).deleting {
+		return reconcileAbortedError{serviceNn: serviceNn, reason: "its NodeBalancer is being deleted"}
 	}
-	return service.Status.LoadBalancer, nil
+	return nil
 }
 
 // getNodeBalancerByStatus attempts to get the NodeBalancer from the IPv4 specified in the
@@ -130,13 +333,33 @@ func (l *loadbalancers) getNodeBalancerByStatus(ctx context.Context, service *v1
 	return nil, lbNotFoundError{serviceNn: getServiceNn(service)}
 }
 
+// getNodeBalancerIDByStatus returns the ID of the NodeBalancer in the service's most
+// recent LoadBalancer status, or 0 if there is none.
+func (l *loadbalancers) getNodeBalancerIDByStatus(ctx context.Context, service *v1.Service) (int, error) {
+	nb, err := l.getNodeBalancerByStatus(ctx, service)
+	switch err.(type) {
+	case nil:
+		return nb.ID, nil
+	case lbNotFoundError:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
+
 // cleanupOldNodeBalancer removes the service's disowned NodeBalancer if there is one.
 //
-// The current NodeBalancer from getNodeBalancerForService is compared to the most recent
-// LoadBalancer status; if they are different (because of an updated NodeBalancerID
-// annotation), the old one is deleted.
-func (l *loadbalancers) cleanupOldNodeBalancer(ctx context.Context, service *v1.Service) error {
-	previousNB, err := l.getNodeBalancerByStatus(ctx, service)
+// The current NodeBalancer nb is compared to previousID, the NodeBalancer in the
+// service's status before the reconcile; if they are different (because of an updated
+// NodeBalancerID annotation), the old one is deleted, or, if its tags or claims show
+// other services still share it, the service's configs are removed from it. The previous
+// NodeBalancer is passed in rather than found through the status, which
+// UpdateLoadBalancer is not given.
+func (l *loadbalancers) cleanupOldNodeBalancer(ctx context.Context, clusterName string, service *v1.Service, previousID int, nb *linodego.NodeBalancer) error {
+	if previousID == 0 || previousID == nb.ID {
+		return nil
+	}
+	previousNB, err := l.getNodeBalancerByID(ctx, service, previousID)
 	switch err.(type) {
 	case nil:
 		// continue execution
@@ -146,30 +369,86 @@ func (l *loadbalancers) cleanupOldNodeBalancer(ctx context.Context, service *v1.
 	default:
 		return err
 	}
+	previousSharing := l.nodeBalancerSharing(service, previousNB)
 
-	nb, err := l.getNodeBalancerForService(ctx, service)
-	if err != nil {
-		return err
-	}
-
-	if previousNB.ID == nb.ID {
-		return nil
-	}
-
-	if err := l.client.DeleteNodeBalancer(ctx, previousNB.ID); err != nil {
-		return err
+	if previousSharing == nodeBalancerShared {
+		if err := l.leaveSharedNodeBalancer(ctx, service, previousNB); err != nil {
+			return err
+		}
+	} else {
+		if err := l.deleteManagedFirewall(ctx, clusterName, service, previousNB.ID); err != nil {
+			return err
+		}
+		if err := l.deleteNodeBalancer(ctx, previousNB.ID); err != nil {
+			return err
+		}
+		klog.Infof("successfully deleted old NodeBalancer (%d) for service (%s)", previousNB.ID, getServiceNn(service))
 	}
 
-	klog.Infof("successfully deleted old NodeBalancer (%d) for service (%s)", previousNB.ID, getServiceNn(service))
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonNodeBalancerMigrated,
+		"moved from %s NodeBalancer (%d) to %s NodeBalancer (%d); its IP changes from %s to %s",
+		previousSharing, previousNB.ID, l.nodeBalancerSharing(service, nb), nb.ID, stringValue(previousNB.IPv4), stringValue(nb.IPv4))
 	return nil
 }
 
 // GetLoadBalancerName returns the name of the load balancer.
 //
+// The name is rendered from --nodebalancer-label-template when one is set. Otherwise,
+// or if the template cannot be rendered, it is derived from the service's namespace
+// and name, which keeps it recognizable, followed by a hash of the service's UID,
+// which keeps it unique between services whose names would otherwise collide once
+// truncated.
+//
 // GetLoadBalancer will not modify service.
 func (l *loadbalancers) GetLoadBalancerName(ctx context.Context, clusterName string, service *v1.Service) string {
-	unixNano := strconv.FormatInt(time.Now().UnixNano(), 16)
-	return fmt.Sprintf("ccm-%s", unixNano[len(unixNano)-12:])
+	uid := string(service.UID)
+	if uid == "" {
+		uid = getServiceNn(service)
+	}
+	hash := sha256.Sum256([]byte(uid))
+	suffix := hex.EncodeToString(hash[:])[:nodeBalancerLabelHashLength]
+
+	if l.labelTemplate != nil {
+		label, err := renderNodeBalancerLabel(l.labelTemplate, nodeBalancerLabelData{
+			Cluster:   clusterName,
+			Namespace: service.Namespace,
+			Name:      service.Name,
+			UID:       string(service.UID),
+			Hash:      suffix,
+		})
+		if err == nil {
+			return label
+		}
+		klog.Warningf("falling back to the default NodeBalancer label for service (%s): %s", getServiceNn(service), err)
+	}
+
+	maxPrefixLength := nodeBalancerLabelMaxLength - len("ccm--") - nodeBalancerLabelHashLength
+	prefix := sanitizeNodeBalancerLabel(fmt.Sprintf("%s-%s", service.Namespace, service.Name))
+	if len(prefix) > maxPrefixLength {
+		prefix = strings.TrimRight(prefix[:maxPrefixLength], "-_.")
+	}
+	if prefix == "" {
+		return fmt.Sprintf("ccm-%s", suffix)
+	}
+	return fmt.Sprintf("ccm-%s-%s", prefix, suffix)
+}
+
+// sanitizeNodeBalancerLabel replaces the characters which are not allowed in a
+// NodeBalancer label and collapses repeated separators.
+func sanitizeNodeBalancerLabel(label string) string {
+	var b strings.Builder
+	lastSeparator := true
+	for _, r := range strings.ToLower(label) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			b.WriteRune(r)
+			lastSeparator = false
+		case !lastSeparator:
+			b.WriteRune('-')
+			lastSeparator = true
+		}
+	}
+	return strings.TrimRight(b.String(), "-")
 }
 
 // GetLoadBalancer returns the *v1.LoadBalancerStatus of service.
@@ -180,7 +459,7 @@ func (l *loadbalancers) GetLoadBalancer(ctx context.Context, clusterName string,
 	sentry.SetTag(ctx, "cluster_name", clusterName)
 	sentry.SetTag(ctx, "service", service.Name)
 
-	nb, err := l.getNodeBalancerForService(ctx, service)
+	nb, err := l.getNodeBalancerForService(ctx, clusterName, service)
 	switch err.(type) {
 	case nil:
 		break
@@ -199,8 +478,109 @@ func (l *loadbalancers) GetLoadBalancer(ctx context.Context, clusterName string,
 // EnsureLoadBalancer ensures that the cluster is running a load balancer for
 // service.
 //
-// EnsureLoadBalancer will not modify service or nodes.
-func (l *loadbalancers) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (lbStatus *v1.LoadBalancerStatus, err error) {
+// EnsureLoadBalancer will not modify service or nodes. Transient Linode API errors are
+// retried for up to Options.TransientErrorGracePeriod before they are returned.
+func (l *loadbalancers) EnsureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (_ *v1.LoadBalancerStatus, err error) {
+	if err := l.checkReconcileBackoff(service); err != nil {
+		return nil, err
+	}
+	if err := l.checkReconcileInterval(service); err != nil {
+		return nil, err
+	}
+	defer func() { l.recordReconcileResult(service, err) }()
+	defer func() { l.setReconciledCondition(ctx, service, err) }()
+	// The NodeBalancer is described as it is after the reconcile, even a failed one
+	defer l.describeOnRequest(ctx, clusterName, service)
+	defer func(start time.Time) { observeReconcile(reconcileOperationEnsure, service, start, err) }(time.Now())
+	l.warnDeprecatedAnnotationPrefix(service)
+	l.explainNodePortRouting(service)
+	l.warnWebSocketPorts(service)
+
+	if err = validateServiceConfig(service); err != nil {
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonInvalidServiceConfig, "%s", err)
+		return nil, err
+	}
+
+	deadline := time.Now().Add(Options.TransientErrorGracePeriod)
+	for {
+		lbStatus, err := l.ensureLoadBalancer(ctx, clusterName, service, nodes)
+		if err == nil && shouldSkipStatusUpdate(service) {
+			klog.Infof("leaving status of service (%s) unchanged instead of setting ingress %v as annotated with %s",
+				getServiceNn(service), lbStatus.Ingress, annLinodeSkipStatusUpdate)
+			return service.Status.LoadBalancer.DeepCopy(), nil
+		}
+		if err == nil && shouldApplyStatus(service) {
+			// The status has been written, so the service controller is handed back the
+			// service's own status, leaving it nothing to update.
+			if err = l.applyServiceStatus(ctx, service, lbStatus); err != nil {
+				sentry.CaptureError(ctx, err)
+				return nil, err
+			}
+			return service.Status.LoadBalancer.DeepCopy(), nil
+		}
+		if err == nil || !isTransientError(err) || time.Now().Add(transientErrorRetryInterval).After(deadline) {
+			return lbStatus, err
+		}
+
+		klog.Warningf("retrying NodeBalancer for service (%s) after transient error: %s", getServiceNn(service), err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(transientErrorRetryInterval):
+		}
+	}
+}
+
+// isTransientError reports whether err is a Linode API server or connection error, or a
+// TLS secret which could not be fetched for the time being, which may succeed if retried.
+func isTransientError(err error) bool {
+	var fetchErr tlsSecretFetchError
+	if errors.As(err, &fetchErr) {
+		return true
+	}
+	var apiErr *linodego.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= http.StatusInternalServerError || apiErr.Code == linodego.ErrorFromError
+	}
+	var gatewayErr linodego.Error
+	if errors.As(err, &gatewayErr) {
+		return gatewayErr.Code >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// deleteNodeBalancer deletes the NodeBalancer with the given ID and confirms it is gone,
+// as an undeleted NodeBalancer keeps being billed without anything referring to it.
+// Transient errors, and NodeBalancers which are still found after being deleted, are
+// retried up to maxNodeBalancerDeleteAttempts times.
+func (l *loadbalancers) deleteNodeBalancer(ctx context.Context, id int) error {
+	for attempt := 1; ; attempt++ {
+		err := l.client.DeleteNodeBalancer(ctx, id)
+		if apiErr, ok := err.(*linodego.Error); err == nil || (ok && apiErr.Code == http.StatusNotFound) {
+			_, err = l.client.GetNodeBalancer(ctx, id)
+			if apiErr, ok := err.(*linodego.Error); ok && apiErr.Code == http.StatusNotFound {
+				return nil
+			}
+			if err == nil {
+				err = fmt.Errorf("NodeBalancer (%d) still exists after being deleted", id)
+			}
+		} else if !isTransientError(err) {
+			return err
+		}
+
+		if attempt >= maxNodeBalancerDeleteAttempts {
+			return fmt.Errorf("could not confirm NodeBalancer (%d) was deleted after %d attempts: %s", id, attempt, err)
+		}
+		klog.Warningf("retrying deletion of NodeBalancer (%d): %s", id, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(transientErrorRetryInterval):
+		}
+	}
+}
+
+func (l *loadbalancers) ensureLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (lbStatus *v1.LoadBalancerStatus, err error) {
 	ctx = sentry.SetHubOnContext(ctx)
 	sentry.SetTag(ctx, "cluster_name", clusterName)
 	sentry.SetTag(ctx, "service", service.Name)
@@ -208,16 +588,70 @@ func (l *loadbalancers) EnsureLoadBalancer(ctx context.Context, clusterName stri
 	var nb *linodego.NodeBalancer
 	serviceNn := getServiceNn(service)
 
-	nb, err = l.getNodeBalancerForService(ctx, service)
+	previousID, err := l.getNodeBalancerIDByStatus(ctx, service)
+	if err != nil {
+		sentry.CaptureError(ctx, err)
+		return nil, err
+	}
+
+	nb, err = l.getNodeBalancerForService(ctx, clusterName, service)
 	switch err.(type) {
 	case lbNotFoundError:
+		if service.Spec.LoadBalancerIP != "" {
+			if nb, err = l.getNodeBalancerForLoadBalancerIP(ctx, service); err != nil {
+				sentry.CaptureError(ctx, err)
+				return nil, err
+			}
+			if err = l.updateNodeBalancer(ctx, service, nodes, nb); err != nil {
+				sentry.CaptureError(ctx, err)
+				return nil, err
+			}
+			break
+		}
+
+		// A NodeBalancer created for the service whose status was never written, such as
+		// after a crash or a lost race, is reconciled onto rather than duplicated
+		nb, err = l.getNodeBalancerByLabel(ctx, clusterName, service, l.GetLoadBalancerName(ctx, clusterName, service))
+		if err == nil {
+			klog.Infof("found NodeBalancer (%d) for service (%s) by label; reconciling it instead of creating another", nb.ID, serviceNn)
+			if err = l.updateNodeBalancer(ctx, service, nodes, nb); err != nil {
+				sentry.CaptureError(ctx, err)
+				return nil, err
+			}
+			break
+		}
+		if _, ok := err.(lbNotFoundError); !ok {
+			sentry.CaptureError(ctx, err)
+			return nil, err
+		}
+
+		if len(service.Status.LoadBalancer.Ingress) > 0 {
+			klog.Infof("NodeBalancer for service (%s) no longer exists; creating a new one", serviceNn)
+		}
 		if nb, err = l.buildLoadBalancerRequest(ctx, clusterName, service, nodes); err != nil {
 			sentry.CaptureError(ctx, err)
 			return nil, err
 		}
 		klog.Infof("created new NodeBalancer (%d) for service (%s)", nb.ID, serviceNn)
 
+	case lbAdoptedNotFoundError:
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonNodeBalancerNotFound,
+			"NodeBalancer (%d) referenced by %s does not exist and will not be recreated", err.(lbAdoptedNotFoundError).nodeBalancerID, annLinodeNodeBalancerID)
+		return nil, err
+
 	case nil:
+		if nb, err = l.migrateLegacyNodeBalancer(ctx, clusterName, service, nb); err != nil {
+			sentry.CaptureError(ctx, err)
+			return nil, err
+		}
+		if l.shouldRecreateInRegion(service, nb) {
+			if nb, err = l.recreateNodeBalancerInRegion(ctx, clusterName, service, nodes, nb); err != nil {
+				sentry.CaptureError(ctx, err)
+				return nil, err
+			}
+			break
+		}
+
 		if err = l.updateNodeBalancer(ctx, service, nodes, nb); err != nil {
 			sentry.CaptureError(ctx, err)
 			return nil, err
@@ -228,21 +662,220 @@ func (l *loadbalancers) EnsureLoadBalancer(ctx context.Context, clusterName stri
 		return nil, err
 	}
 
+	if err = l.reconcileFirewall(ctx, clusterName, service, nodes, nb); err != nil {
+		sentry.CaptureError(ctx, err)
+		return nil, err
+	}
+
 	klog.Infof("NodeBalancer (%d) has been ensured for service (%s)", nb.ID, serviceNn)
 	lbStatus = makeLoadBalancerStatus(nb)
 
 	if !l.shouldPreserveNodeBalancer(service) {
-		if err := l.cleanupOldNodeBalancer(ctx, service); err != nil {
+		if err := l.cleanupOldNodeBalancer(ctx, clusterName, service, previousID, nb); err != nil {
 			sentry.CaptureError(ctx, err)
 			return nil, err
 		}
 	}
-
+	l.annotateNodeBalancer(ctx, service, nb)
+
+	announce := false
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.nodeSnapshot = makeNodeSnapshot(service, nodes)
+		if ip := stringValue(nb.IPv4); ip != state.announcedIP {
+			announce = !hasIngressIP(service, ip)
+			state.announcedIP = ip
+		}
+	})
+	if announce {
+		l.recordEvent(service, v1.EventTypeNormal, eventReasonEnsuredLoadBalancer,
+			"NodeBalancer (%d) is ready with IP %s and hostname %s", nb.ID, stringValue(nb.IPv4), stringValue(nb.Hostname))
+	}
+	l.reportBackendHealth(ctx, service, nb)
 	return lbStatus, nil
 }
 
-//nolint:funlen
-func (l *loadbalancers) updateNodeBalancer(ctx context.Context, service *v1.Service, nodes []*v1.Node, nb *linodego.NodeBalancer) (err error) {
+// hasIngressIP reports whether the service's status already lists ip, so it has been
+// announced by an earlier reconcile.
+func hasIngressIP(service *v1.Service, ip string) bool {
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldRecreateInRegion reports whether nb is a CCM-managed NodeBalancer in a region
+// other than the current one which should be recreated in the current region.
+func (l *loadbalancers) shouldRecreateInRegion(service *v1.Service, nb *linodego.NodeBalancer) bool {
+	if nb.Region == l.zone {
+		return false
+	}
+	// NodeBalancers in a fallback region were put there for lack of capacity, and
+	// recreating them would only fall back again.
+	for _, region := range Options.FallbackRegions {
+		if nb.Region == region {
+			return false
+		}
+	}
+	if _, ok := getNodeBalancerIDAnnotation(service); ok {
+		return false
+	}
+	if !Options.RecreateNodeBalancersOnRegionChange {
+		klog.Warningf("NodeBalancer (%d) for service (%s) is in region %s instead of %s; not recreating as --recreate-nodebalancers-on-region-change is not set",
+			nb.ID, getServiceNn(service), nb.Region, l.zone)
+		return false
+	}
+	return true
+}
+
+// recreateNodeBalancerInRegion creates a replacement for oldNB in the current region and
+// deletes oldNB unless the service is annotated to preserve it.
+func (l *loadbalancers) recreateNodeBalancerInRegion(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node, oldNB *linodego.NodeBalancer) (*linodego.NodeBalancer, error) {
+	serviceNn := getServiceNn(service)
+
+	// NodeBalancer labels are unique per account, so free up the label for the replacement.
+	if label := l.GetLoadBalancerName(ctx, clusterName, service); oldNB.Label != nil && *oldNB.Label == label {
+		if len(label) > nodeBalancerLabelMaxLength-len("-old") {
+			label = strings.TrimRight(label[:nodeBalancerLabelMaxLength-len("-old")], "-")
+		}
+		oldLabel := label + "-old"
+		if _, err := l.client.UpdateNodeBalancer(ctx, oldNB.ID, linodego.NodeBalancerUpdateOptions{Label: &oldLabel}); err != nil {
+			return nil, err
+		}
+	}
+
+	nb, err := l.buildLoadBalancerRequest(ctx, clusterName, service, nodes)
+	if err != nil {
+		return nil, err
+	}
+	klog.Infof("created NodeBalancer (%d) in region %s to replace NodeBalancer (%d) in region %s for service (%s)",
+		nb.ID, nb.Region, oldNB.ID, oldNB.Region, serviceNn)
+
+	if l.shouldPreserveNodeBalancer(service) {
+		return nb, nil
+	}
+
+	if err := l.deleteManagedFirewall(ctx, clusterName, service, oldNB.ID); err != nil {
+		return nil, err
+	}
+	if err := l.deleteNodeBalancer(ctx, oldNB.ID); err != nil {
+		return nil, err
+	}
+	klog.Infof("successfully deleted NodeBalancer (%d) in region %s for service (%s)", oldNB.ID, oldNB.Region, serviceNn)
+	return nb, nil
+}
+
+// plannedConfig is the desired state of the NodeBalancer config for one of a service's
+// ports.
+type plannedConfig struct {
+	port     int
+	config   linodego.NodeBalancerConfig
+	nodes    []linodego.NodeBalancerNodeCreateOptions
+	metadata configMetadata
+}
+
+// planNodeBalancerConfigs builds the desired config for each of the service's ports, in
+// the order set by the config-order annotation, without changing anything, so that a
+// misconfigured port fails the update before any part of the NodeBalancer is touched.
+func (l *loadbalancers) planNodeBalancerConfigs(ctx context.Context, service *v1.Service, nodes []*v1.Node) ([]plannedConfig, error) {
+	resolver, err := getBackendAddressResolver(service)
+	if err != nil {
+		return nil, err
+	}
+
+	if nodes, err = l.filterBackendNodes(service, nodes); err != nil {
+		return nil, err
+	}
+	nodes = selectBackendNodes(service, l.filterAddressableNodes(service, resolver, nodes))
+
+	ports, err := configOrderedServicePorts(service)
+	if err != nil {
+		return nil, err
+	}
+	planned := make([]plannedConfig, 0, len(ports))
+	for _, port := range ports {
+		config, err := l.buildNodeBalancerConfig(ctx, service, int(port.Port))
+		if err != nil {
+			return nil, err
+		}
+
+		nbNodes, err := l.buildNodeBalancerNodesCreateOptions(resolver, nodes, port.NodePort)
+		if err != nil {
+			return nil, err
+		}
+
+		appliedOpts := config.GetCreateOptions()
+		appliedOpts.Nodes = nbNodes
+		metadata, err := newConfigMetadata(appliedOpts)
+		if err != nil {
+			return nil, err
+		}
+		metadata.Owner = serviceOwner(service)
+
+		planned = append(planned, plannedConfig{port: int(port.Port), config: config, nodes: nbNodes, metadata: metadata})
+	}
+	return planned, nil
+}
+
+// updateNodeBalancer brings nb in line with service, then verifies that the live
+// NodeBalancer has exactly one config per port. A NodeBalancer which diverges is
+// reported in a NodeBalancerConfigMismatch event and reconciled once more; if it still
+// diverges, an error is returned so the service is retried.
+func (l *loadbalancers) updateNodeBalancer(ctx context.Context, service *v1.Service, nodes []*v1.Node, nb *linodego.NodeBalancer) error {
+	if err := l.applyNodeBalancer(ctx, service, nodes, nb); err != nil {
+		return err
+	}
+	mismatch, err := l.verifyNodeBalancerConfigs(ctx, service, nb)
+	if err != nil || mismatch == nil {
+		return err
+	}
+
+	klog.Warningf("%s; reconciling it again", mismatch)
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonNodeBalancerConfigMismatch, "%s; reconciling it again", mismatch)
+	if err := l.applyNodeBalancer(ctx, service, nodes, nb); err != nil {
+		return err
+	}
+	if mismatch, err = l.verifyNodeBalancerConfigs(ctx, service, nb); err != nil {
+		return err
+	}
+	if mismatch != nil {
+		sentry.CaptureError(ctx, mismatch)
+		return mismatch
+	}
+	return nil
+}
+
+// applyNodeBalancer brings nb in line with service. Every config is planned before
+// anything is changed; the NodeBalancer-wide throttle is then applied first, followed
+// by the configs. If applying a config fails, the metadata of the configs which were
+// applied is still recorded, so the error names the failed port and the next reconcile
+// only redoes what was not applied.
+func (l *loadbalancers) applyNodeBalancer(ctx context.Context, service *v1.Service, nodes []*v1.Node, nb *linodego.NodeBalancer) (err error) {
+	// NodeBalancers cannot serve UDP, so reject such services before anything is changed
+	for _, port := range service.Spec.Ports {
+		if port.Protocol == v1.ProtocolUDP {
+			err := fmt.Errorf("error updating NodeBalancer Config: ports with the UDP protocol are not supported")
+			sentry.CaptureError(ctx, err)
+			return err
+		}
+	}
+	if err := l.checkNodePorts(service); err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+
+	planned, err := l.planNodeBalancerConfigs(ctx, service, nodes)
+	if err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+	serviceTags, err := getServiceTags(service)
+	if err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+
 	connThrottle := getConnectionThrottle(service)
 	if connThrottle != nb.ClientConnThrottle {
 		update := nb.GetUpdateOptions()
@@ -251,7 +884,7 @@ func (l *loadbalancers) updateNodeBalancer(ctx context.Context, service *v1.Serv
 		nb, err = l.client.UpdateNodeBalancer(ctx, nb.ID, update)
 		if err != nil {
 			sentry.CaptureError(ctx, err)
-			return err
+			return fmt.Errorf("error updating NodeBalancer client connection throttle: %w", err)
 		}
 	}
 
@@ -262,113 +895,384 @@ func (l *loadbalancers) updateNodeBalancer(ctx context.Context, service *v1.Serv
 		return err
 	}
 
-	// Delete any configs for ports that have been removed from the Service
-	if err = l.deleteUnusedConfigs(ctx, nbCfgs, service.Spec.Ports); err != nil {
+	sort.Slice(nbCfgs, func(i, j int) bool { return nbCfgs[i].Port < nbCfgs[j].Port })
+
+	// Delete any configs for ports that have been removed from the Service, other than
+	// those of services sharing the NodeBalancer
+	sharedPorts := l.sharedPorts(service, nb)
+	usedPorts := append([]v1.ServicePort(nil), service.Spec.Ports...)
+	for _, port := range sharedPorts {
+		usedPorts = append(usedPorts, v1.ServicePort{Port: int32(port)})
+	}
+	if err = l.deleteUnusedConfigs(ctx, nbCfgs, usedPorts); err != nil {
 		sentry.CaptureError(ctx, err)
 		return err
 	}
 
-	// Add or overwrite configs for each of the Service's ports
-	for _, port := range service.Spec.Ports {
-		if port.Protocol == v1.ProtocolUDP {
-			err := fmt.Errorf("error updating NodeBalancer Config: ports with the UDP protocol are not supported")
-			sentry.CaptureError(ctx, err)
-			return err
+	// recorded starts as the metadata of the configs as they were last applied, and
+	// takes on each config's new metadata once it has been applied.
+	// Configs recorded before owners were are taken to be the service's own.
+	appliedMetadata, _ := parseConfigMetadataTags(nb.Tags)
+	recorded := make(map[int]configMetadata, len(planned))
+	for _, plan := range planned {
+		if metadata, ok := appliedMetadata[plan.port]; ok {
+			if metadata.Owner == "" {
+				metadata.Owner = plan.metadata.Owner
+				appliedMetadata[plan.port] = metadata
+			}
+			recorded[plan.port] = metadata
+		}
+	}
+	for _, port := range sharedPorts {
+		if metadata, ok := appliedMetadata[port]; ok {
+			recorded[port] = metadata
 		}
+	}
 
-		// Construct a new config for this port
-		newNBCfg, err := l.buildNodeBalancerConfig(ctx, service, int(port.Port))
+	// Add or overwrite configs for each of the Service's ports. A config whose backend
+	// removal was capped keeps its old metadata, so the next reconcile carries on.
+	anyCapped := false
+	for _, plan := range planned {
+		// The service may have started being deleted since the last config was applied
+		if err = l.checkNotDeleting(service); err != nil {
+			return err
+		}
+		capped, err := l.applyNodeBalancerConfig(ctx, service, nb, nbCfgs, plan, appliedMetadata[plan.port])
 		if err != nil {
+			_, getErr := l.client.GetNodeBalancer(ctx, nb.ID)
+			if apiErr, ok := getErr.(*linodego.Error); ok && apiErr.Code == http.StatusNotFound {
+				return reconcileAbortedError{serviceNn: getServiceNn(service), reason: fmt.Sprintf("NodeBalancer (%d) no longer exists", nb.ID)}
+			}
 			sentry.CaptureError(ctx, err)
+			if tagErr := l.updateManagedTags(ctx, nb, serviceTags, recorded); tagErr != nil {
+				klog.Errorf("failed to record the configs applied to NodeBalancer (%d) before port %d failed: %s", nb.ID, plan.port, tagErr)
+			}
 			return err
 		}
-
-		// Add all of the Nodes to the config
-		var newNBNodes []linodego.NodeBalancerNodeCreateOptions
-		for _, node := range nodes {
-			newNBNodes = append(newNBNodes, l.buildNodeBalancerNodeCreateOptions(node, port.NodePort))
+		if !capped {
+			recorded[plan.port] = plan.metadata
+		}
+		anyCapped = anyCapped || capped
+	}
+	l.states.update(getServiceNn(service), func(state *serviceState) {
+		state.backendRemovalCapped = anyCapped
+		state.nodeBalancerID = nb.ID
+		state.backends = 0
+		if len(planned) > 0 {
+			state.backends = len(planned[0].nodes)
 		}
+	})
 
-		// Look for an existing config for this port
-		var currentNBCfg *linodego.NodeBalancerConfig
-		for i := range nbCfgs {
-			nbc := nbCfgs[i]
-			if nbc.Port == int(port.Port) {
-				currentNBCfg = &nbc
-				break
-			}
+	return l.updateManagedTags(ctx, nb, serviceTags, recorded)
+}
+
+// applyNodeBalancerConfig creates or rebuilds the config for plan's port unless the
+// existing config was last applied with the same metadata. Existing configs which differ
+// only in health check timing, or whose backends are already as planned, are updated in
+// place instead. It reports whether the removal of backends was capped or held back for
+// NotReady nodes, leaving the config short of plan.
+func (l *loadbalancers) applyNodeBalancerConfig(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer, nbCfgs []linodego.NodeBalancerConfig, plan plannedConfig, applied configMetadata) (capped bool, err error) {
+	// Look for an existing config for this port
+	var currentNBCfg *linodego.NodeBalancerConfig
+	for i := range nbCfgs {
+		nbc := nbCfgs[i]
+		if nbc.Port == plan.port {
+			currentNBCfg = &nbc
+			break
 		}
+	}
 
-		// If there's no existing config, create it
-		var rebuildOpts linodego.NodeBalancerConfigRebuildOptions
-		if currentNBCfg == nil {
-			createOpts := newNBCfg.GetCreateOptions()
+	// Skip configs which are unchanged since they were last applied
+	if currentNBCfg != nil && applied == plan.metadata {
+		klog.V(4).Infof("NodeBalancer (%d) config for port %d is up to date", nb.ID, plan.port)
+		return false, nil
+	}
 
-			currentNBCfg, err = l.client.CreateNodeBalancerConfig(ctx, nb.ID, createOpts)
-			if err != nil {
-				sentry.CaptureError(ctx, err)
-				return fmt.Errorf("[port %d] error creating NodeBalancer config: %v", int(port.Port), err)
+	var held bool
+	if plan, held, err = l.holdNotReadyBackends(ctx, service, nb, currentNBCfg, plan); err != nil {
+		return false, fmt.Errorf("[port %d] error checking NotReady backends: %w", plan.port, err)
+	}
+	if plan, capped, err = l.guardBackendRemoval(ctx, service, nb, currentNBCfg, plan); err != nil {
+		return false, fmt.Errorf("[port %d] error listing NodeBalancer config backends: %w", plan.port, err)
+	}
+	capped = capped || held
+
+	// Retune health checks in place, as a rebuild would replace the config's backends
+	if !capped && currentNBCfg != nil && onlyHealthCheckTimingChanged(plan, currentNBCfg, applied) {
+		if _, err = l.client.UpdateNodeBalancerConfig(ctx, nb.ID, currentNBCfg.ID, plan.config.GetUpdateOptions()); err != nil {
+			return false, fmt.Errorf("[port %d] error updating NodeBalancer config: %w", plan.port, err)
+		}
+		return false, nil
+	}
+
+	// Reconfigure configs whose backends are already as planned in place, such as when a
+	// port switches from tcp to https, as a rebuild replaces every backend
+	if !capped && currentNBCfg != nil {
+		current, err := l.client.ListNodeBalancerNodes(ctx, nb.ID, currentNBCfg.ID, nil)
+		if err != nil {
+			return false, fmt.Errorf("[port %d] error listing NodeBalancer config backends: %w", plan.port, err)
+		}
+		if backendsMatch(current, plan.nodes) {
+			if _, err = l.client.UpdateNodeBalancerConfig(ctx, nb.ID, currentNBCfg.ID, plan.config.GetUpdateOptions()); err != nil {
+				return false, fmt.Errorf("[port %d] error updating NodeBalancer config: %w", plan.port, err)
 			}
-			rebuildOpts = currentNBCfg.GetRebuildOptions()
+			return false, nil
+		}
+	}
+
+	// If there's no existing config, create it
+	var rebuildOpts linodego.NodeBalancerConfigRebuildOptions
+	if currentNBCfg == nil {
+		createOpts := plan.config.GetCreateOptions()
 
-			// SSLCert and SSLKey return <REDACTED> from the API, so copy the
-			// value that we sent in create for the rebuild
-			rebuildOpts.SSLCert = newNBCfg.SSLCert
-			rebuildOpts.SSLKey = newNBCfg.SSLKey
-		} else {
-			rebuildOpts = newNBCfg.GetRebuildOptions()
+		currentNBCfg, err = l.client.CreateNodeBalancerConfig(ctx, nb.ID, createOpts)
+		if err != nil {
+			return false, fmt.Errorf("[port %d] error creating NodeBalancer config: %w", plan.port, err)
 		}
+		rebuildOpts = currentNBCfg.GetRebuildOptions()
+
+		// SSLCert and SSLKey return <REDACTED> from the API, so copy the
+		// value that we sent in create for the rebuild
+		rebuildOpts.SSLCert = plan.config.SSLCert
+		rebuildOpts.SSLKey = plan.config.SSLKey
+	} else {
+		rebuildOpts = plan.config.GetRebuildOptions()
+	}
 
-		rebuildOpts.Nodes = newNBNodes
+	rebuildOpts.Nodes = plan.nodes
 
-		if _, err = l.client.RebuildNodeBalancerConfig(ctx, nb.ID, currentNBCfg.ID, rebuildOpts); err != nil {
-			sentry.CaptureError(ctx, err)
-			return fmt.Errorf("[port %d] error rebuilding NodeBalancer config: %v", int(port.Port), err)
+	if _, err = l.client.RebuildNodeBalancerConfig(ctx, nb.ID, currentNBCfg.ID, rebuildOpts); err != nil {
+		return false, fmt.Errorf("[port %d] error rebuilding NodeBalancer config: %w", plan.port, err)
+	}
+	return capped, nil
+}
+
+// backendsMatch reports whether the backends current are those planned, in any order.
+func backendsMatch(current []linodego.NodeBalancerNode, planned []linodego.NodeBalancerNodeCreateOptions) bool {
+	if len(current) != len(planned) {
+		return false
+	}
+	remaining := make(map[linodego.NodeBalancerNodeCreateOptions]int, len(planned))
+	for _, node := range planned {
+		remaining[node]++
+	}
+	for _, node := range current {
+		key := linodego.NodeBalancerNodeCreateOptions{Address: node.Address, Label: node.Label, Weight: node.Weight, Mode: node.Mode}
+		if remaining[key] == 0 {
+			return false
 		}
+		remaining[key]--
+	}
+	return true
+}
+
+// onlyHealthCheckTimingChanged reports whether plan differs from the config last
+// applied, whose metadata is applied, in nothing but its health check interval, timeout
+// and attempts. The settings current has are taken to be those last applied.
+func onlyHealthCheckTimingChanged(plan plannedConfig, current *linodego.NodeBalancerConfig, applied configMetadata) bool {
+	if !applied.isManaged() {
+		return false
+	}
+	opts := plan.config.GetCreateOptions()
+	opts.CheckInterval = current.CheckInterval
+	opts.CheckTimeout = current.CheckTimeout
+	opts.CheckAttempts = current.CheckAttempts
+	opts.Nodes = plan.nodes
+	metadata, err := newConfigMetadata(opts)
+	metadata.Owner = plan.metadata.Owner
+	return err == nil && metadata == applied
+}
+
+// updateManagedTags records metadata and the service's tags, serviceTags, in the
+// NodeBalancer's tags if they differ from what is already stored there. Tags the CCM
+// does not manage are kept.
+func (l *loadbalancers) updateManagedTags(ctx context.Context, nb *linodego.NodeBalancer, serviceTags []string, metadata map[int]configMetadata) error {
+	tags, err := mergeConfigMetadataTags(nb.Tags, metadata)
+	if err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+	tags = mergeServiceTags(tags, serviceTags)
+
+	current := append([]string(nil), nb.Tags...)
+	desired := append([]string(nil), tags...)
+	sort.Strings(current)
+	sort.Strings(desired)
+	if strings.Join(current, ",") == strings.Join(desired, ",") {
+		return nil
+	}
+
+	update := nb.GetUpdateOptions()
+	if tags == nil {
+		tags = []string{}
+	}
+	update.Tags = &tags
+	if _, err = l.client.UpdateNodeBalancer(ctx, nb.ID, update); err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
 	}
 	return nil
 }
 
 // UpdateLoadBalancer updates the NodeBalancer to have configs that match the Service's ports
 func (l *loadbalancers) UpdateLoadBalancer(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (err error) {
+	if err := l.checkReconcileBackoff(service); err != nil {
+		return err
+	}
+	defer func() { l.recordReconcileResult(service, err) }()
+	defer func() { l.setReconciledCondition(ctx, service, err) }()
+	defer func(start time.Time) { observeReconcile(reconcileOperationUpdate, service, start, err) }(time.Now())
+	l.warnDeprecatedAnnotationPrefix(service)
+	l.explainNodePortRouting(service)
+	l.warnWebSocketPorts(service)
+
 	ctx = sentry.SetHubOnContext(ctx)
 	sentry.SetTag(ctx, "cluster_name", clusterName)
 	sentry.SetTag(ctx, "service", service.Name)
 
+	serviceNn := getServiceNn(service)
+	nodeSnapshot := makeNodeSnapshot(service, nodes)
+	state := l.states.get(serviceNn)
+	nodesUnchanged := Options.ReconcileNodesOnChangeOnly && state.nodeSnapshot == nodeSnapshot && !state.backendRemovalCapped
+	if nodesUnchanged && l.firewallUpToDate(service, nodes) {
+		klog.V(3).Infof("skipping update of NodeBalancer for service (%s) as its nodes have not changed", serviceNn)
+		return nil
+	}
+	if err = l.checkReconcileInterval(service); err != nil {
+		return err
+	}
+
+	// A service deleted while this update was queued is left to EnsureLoadBalancerDeleted
+	if err = l.checkNotDeleting(service); err != nil {
+		klog.Infof("%s", err)
+		return nil
+	}
+
 	// UpdateLoadBalancer is invoked with a nil LoadBalancerStatus; we must fetch the latest
 	// status for NodeBalancer discovery.
-	serviceWithStatus := service.DeepCopy()
-	serviceWithStatus.Status.LoadBalancer, err = l.getLatestServiceLoadBalancerStatus(ctx, service)
+	latest, err := l.getLatestService(ctx, service)
+	if apierrors.IsNotFound(err) || (err == nil && latest.DeletionTimestamp != nil) {
+		klog.Infof("%s", reconcileAbortedError{serviceNn: serviceNn, reason: "the service is being deleted"})
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get latest LoadBalancer status for service (%s): %s", getServiceNn(service), err)
 	}
+	serviceWithStatus := service.DeepCopy()
+	serviceWithStatus.Status.LoadBalancer = latest.Status.LoadBalancer
 
-	nb, err := l.getNodeBalancerForService(ctx, serviceWithStatus)
+	previousID, err := l.getNodeBalancerIDByStatus(ctx, serviceWithStatus)
+	if err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+	nb, err := l.getNodeBalancerForService(ctx, clusterName, serviceWithStatus)
 	if err != nil {
+		if adoptedErr, ok := err.(lbAdoptedNotFoundError); ok {
+			l.recordEvent(service, v1.EventTypeWarning, eventReasonNodeBalancerNotFound,
+				"NodeBalancer (%d) referenced by %s does not exist and will not be recreated", adoptedErr.nodeBalancerID, annLinodeNodeBalancerID)
+		}
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+	if nb, err = l.migrateLegacyNodeBalancer(ctx, clusterName, serviceWithStatus, nb); err != nil {
+		sentry.CaptureError(ctx, err)
+		return err
+	}
+
+	// Source ranges are reconciled on their own so that edits to them take effect even
+	// when the NodeBalancer has nothing to change.
+	if err = l.reconcileFirewall(ctx, clusterName, serviceWithStatus, nodes, nb); err != nil {
 		sentry.CaptureError(ctx, err)
 		return err
 	}
+	if nodesUnchanged {
+		klog.V(3).Infof("skipping update of NodeBalancer for service (%s) as its nodes have not changed", serviceNn)
+		return nil
+	}
 
 	if !l.shouldPreserveNodeBalancer(service) {
-		if err := l.cleanupOldNodeBalancer(ctx, service); err != nil {
+		if err := l.cleanupOldNodeBalancer(ctx, clusterName, service, previousID, nb); err != nil {
 			sentry.CaptureError(ctx, err)
 			return err
 		}
 	}
 
-	return l.updateNodeBalancer(ctx, serviceWithStatus, nodes, nb)
+	if err = l.updateNodeBalancer(ctx, serviceWithStatus, nodes, nb); err != nil {
+		if _, ok := err.(reconcileAbortedError); ok {
+			klog.Infof("%s", err)
+			return nil
+		}
+		return err
+	}
+	l.annotateNodeBalancer(ctx, service, nb)
+
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.nodeSnapshot = nodeSnapshot
+	})
+	l.reportBackendHealth(ctx, service, nb)
+	return nil
+}
+
+// makeNodeSnapshot returns a description of the parts of nodes and service which
+// affect the NodeBalancer: each node's name, addresses, backend IP override and
+// readiness, the service's ports, session affinity, external traffic policy and
+// NodeBalancer annotations, and the default annotations it inherits. Only nodes matching
+// the service's backend node selector are included. Node changes which are not
+// reflected in the snapshot, such as other label updates, do not require the
+// NodeBalancer to be reconciled.
+func makeNodeSnapshot(service *v1.Service, nodes []*v1.Node) string {
+	selector, err := getBackendNodeSelector(service)
+	if err != nil {
+		selector = labels.Everything()
+	}
+	entries := make([]string, 0, len(nodes)+len(service.Spec.Ports))
+	for _, node := range nodes {
+		if !selector.Matches(labels.Set(node.Labels)) {
+			continue
+		}
+		addresses := make([]string, 0, len(node.Status.Addresses))
+		for _, addr := range node.Status.Addresses {
+			addresses = append(addresses, fmt.Sprintf("%s=%s", addr.Type, addr.Address))
+		}
+		sort.Strings(addresses)
+		entries = append(entries, fmt.Sprintf("node:%s:%t:%s:%s", node.Name, isNodeReady(node), node.Annotations[annLinodeNodeBackendIP], strings.Join(addresses, ",")))
+	}
+	for _, port := range service.Spec.Ports {
+		entries = append(entries, fmt.Sprintf("port:%s:%d:%d", port.Protocol, port.Port, port.NodePort))
+	}
+	entries = append(entries,
+		fmt.Sprintf("sessionAffinity:%s", service.Spec.SessionAffinity),
+		fmt.Sprintf("externalTrafficPolicy:%s", service.Spec.ExternalTrafficPolicy))
+	for name, value := range service.Annotations {
+		if strings.HasPrefix(name, annLinodeAnnotationPrefix) || strings.HasPrefix(name, annLinodeGAAnnotationPrefix) {
+			entries = append(entries, fmt.Sprintf("annotation:%s=%s", name, value))
+		}
+	}
+	for name, value := range defaultAnnotations.forNamespace(service.Namespace) {
+		entries = append(entries, fmt.Sprintf("default:%s=%s", name, value))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ";")
 }
 
-// Delete any NodeBalancer configs for ports that no longer exist on the Service
+// Delete any NodeBalancer configs for ports that no longer exist on the Service, and any
+// duplicate configs for a port after the first
 // Note: Don't build a map or other lookup structure here, it is not worth the overhead
 func (l *loadbalancers) deleteUnusedConfigs(ctx context.Context, nbConfigs []linodego.NodeBalancerConfig, servicePorts []v1.ServicePort) error {
-	for _, nbc := range nbConfigs {
+	for i, nbc := range nbConfigs {
 		found := false
 		for _, sp := range servicePorts {
 			if nbc.Port == int(sp.Port) {
 				found = true
 			}
 		}
+		// Only the first config for a port is reconciled, so any others are removed
+		for _, earlier := range nbConfigs[:i] {
+			if nbc.Port == earlier.Port {
+				found = false
+			}
+		}
 		if !found {
 			if err := l.client.DeleteNodeBalancerConfig(ctx, nbc.NodeBalancerID, nbc.ID); err != nil {
 				return err
@@ -378,6 +1282,17 @@ func (l *loadbalancers) deleteUnusedConfigs(ctx context.Context, nbConfigs []lin
 	return nil
 }
 
+// shouldSkipStatusUpdate reports whether the service is annotated with
+// skip-status-update, so the CCM leaves its ingress status to another tool.
+func shouldSkipStatusUpdate(service *v1.Service) bool {
+	skipRaw, ok := getServiceAnnotation(service, annLinodeSkipStatusUpdate)
+	if !ok {
+		return false
+	}
+	skip, err := strconv.ParseBool(skipRaw)
+	return err == nil && skip
+}
+
 // shouldPreserveNodeBalancer determines whether a NodeBalancer should be deleted based on the
 // service's preserve annotation.
 func (l *loadbalancers) shouldPreserveNodeBalancer(service *v1.Service) bool {
@@ -394,19 +1309,38 @@ func (l *loadbalancers) shouldPreserveNodeBalancer(service *v1.Service) bool {
 // successfully deleted.
 //
 // EnsureLoadBalancerDeleted will not modify service.
-func (l *loadbalancers) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) error {
+func (l *loadbalancers) EnsureLoadBalancerDeleted(ctx context.Context, clusterName string, service *v1.Service) (err error) {
+	defer func(start time.Time) { observeReconcile(reconcileOperationDelete, service, start, err) }(time.Now())
+
 	ctx = sentry.SetHubOnContext(ctx)
 	sentry.SetTag(ctx, "cluster_name", clusterName)
 	sentry.SetTag(ctx, "service", service.Name)
 
 	serviceNn := getServiceNn(service)
 
-	if len(service.Status.LoadBalancer.Ingress) == 0 {
+	// Updates in flight check this to stop before recreating backends on the NodeBalancer
+	// being deleted. The state is forgotten once there is nothing left to delete.
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.deleting = true
+	})
+	defer func() {
+		if err == nil {
+			l.states.delete(serviceNn)
+			l.refresh.forget(serviceNn)
+			l.claims.release(serviceNn)
+			return
+		}
+		l.states.update(serviceNn, func(state *serviceState) {
+			state.deleting = false
+		})
+	}()
+
+	if len(service.Status.LoadBalancer.Ingress) == 0 && !shouldSkipStatusUpdate(service) {
 		klog.Infof("short-circuting deletion of NodeBalancer for service(%s) as LoadBalancer ingress is not present", serviceNn)
 		return nil
 	}
 
-	nb, err := l.getNodeBalancerForService(ctx, service)
+	nb, err := l.getNodeBalancerForService(ctx, clusterName, service)
 	switch getErr := err.(type) {
 	case nil:
 		break
@@ -415,6 +1349,10 @@ func (l *loadbalancers) EnsureLoadBalancerDeleted(ctx context.Context, clusterNa
 		klog.Infof("short-circuting deletion for NodeBalancer for service (%s) as one does not exist: %s", serviceNn, err)
 		return nil
 
+	case nodeBalancerClaimedError:
+		klog.Infof("short-circuting deletion of NodeBalancer (%d) for service (%s) as it belongs to service (%s)", getErr.nodeBalancerID, serviceNn, getErr.claimedBy)
+		return nil
+
 	default:
 		klog.Errorf("failed to get NodeBalancer for service (%s): %s", serviceNn, err)
 		sentry.CaptureError(ctx, getErr)
@@ -425,53 +1363,323 @@ func (l *loadbalancers) EnsureLoadBalancerDeleted(ctx context.Context, clusterNa
 		klog.Infof("short-circuting deletion of NodeBalancer (%d) for service (%s) as annotated with %s", nb.ID, serviceNn, annLinodeLoadBalancerPreserve)
 		return nil
 	}
+	if l.sharesNodeBalancer(service, nb) {
+		if err = l.leaveSharedNodeBalancer(ctx, service, nb); err != nil {
+			klog.Errorf("failed to remove the configs of service (%s) from shared NodeBalancer (%d): %s", serviceNn, nb.ID, err)
+			sentry.CaptureError(ctx, err)
+			return err
+		}
+		klog.Infof("short-circuting deletion of NodeBalancer (%d) for service (%s) as it is shared with other services", nb.ID, serviceNn)
+		return nil
+	}
+
+	// Draining is best effort: a NodeBalancer which cannot be drained is still deleted.
+	if err = l.drainNodeBalancer(ctx, nb); err != nil {
+		klog.Warningf("failed to drain NodeBalancer (%d) for service (%s) before deleting it: %s", nb.ID, serviceNn, err)
+	}
+
+	if err = l.deleteManagedFirewall(ctx, clusterName, service, nb.ID); err != nil {
+		klog.Errorf("failed to delete Firewall of NodeBalancer (%d) for service (%s): %s", nb.ID, serviceNn, err)
+		sentry.CaptureError(ctx, err)
+		return err
+	}
 
-	if err = l.client.DeleteNodeBalancer(ctx, nb.ID); err != nil {
+	if err = l.deleteNodeBalancer(ctx, nb.ID); err != nil {
 		klog.Errorf("failed to delete NodeBalancer (%d) for service (%s): %s", nb.ID, serviceNn, err)
 		sentry.CaptureError(ctx, err)
 		return err
 	}
 
-	klog.Infof("successfully deleted NodeBalancer (%d) for service (%s)", nb.ID, serviceNn)
+	klog.Infof("successfully deleted NodeBalancer (%d) for service (%s)", nb.ID, serviceNn)
+	l.clearNodeBalancerAnnotations(ctx, service)
+	return nil
+}
+
+func (l *loadbalancers) getNodeBalancerByIPv4(ctx context.Context, service *v1.Service, ipv4 string) (*linodego.NodeBalancer, error) {
+	lbs, err := l.client.ListNodeBalancers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, lb := range lbs {
+		if *lb.IPv4 == ipv4 {
+			klog.V(2).Infof("found NodeBalancer (%d) for service (%s) via IPv4 (%s)", lb.ID, getServiceNn(service), ipv4)
+			return &lb, nil
+		}
+	}
+	return nil, lbNotFoundError{serviceNn: getServiceNn(service)}
+}
+
+// getNodeBalancerByLabel returns the NodeBalancer with the given label which was
+// created for the service by the cluster clusterName. Labels derive from the service's
+// UID, so more than one match means a duplicate was created for the service, by a race
+// or a bug. The oldest, with the lowest ID, is then returned so that every reconcile
+// settles on the same NodeBalancer, and a warning event names the others for an
+// operator to clean up. NodeBalancers with the label which belong to anything else are
+// never returned; if there are only those, a nodeBalancerLabelTakenError is.
+func (l *loadbalancers) getNodeBalancerByLabel(ctx context.Context, clusterName string, service *v1.Service, label string) (*linodego.NodeBalancer, error) {
+	labeled, err := l.listNodeBalancersByLabel(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+	if len(labeled) == 0 {
+		return nil, lbNotFoundError{serviceNn: getServiceNn(service)}
+	}
+	matches := ownedNodeBalancers(clusterName, service, labeled)
+	if len(matches) == 0 {
+		return nil, nodeBalancerLabelTakenError{serviceNn: getServiceNn(service), label: label, nodeBalancerID: labeled[0].ID}
+	}
+
+	nb := &matches[0]
+	l.warnDuplicateNodeBalancers(service, label, "the oldest", nb, matches)
+	klog.V(2).Infof("found NodeBalancer (%d) for service (%s) via label (%s)", nb.ID, getServiceNn(service), label)
+	return nb, nil
+}
+
+// listNodeBalancersByLabel returns the NodeBalancers with the given label, oldest first.
+func (l *loadbalancers) listNodeBalancersByLabel(ctx context.Context, label string) ([]linodego.NodeBalancer, error) {
+	lbs, err := l.client.ListNodeBalancers(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []linodego.NodeBalancer
+	for _, lb := range lbs {
+		if lb.Label != nil && *lb.Label == label {
+			matches = append(matches, lb)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+	return matches, nil
+}
+
+// ownedNodeBalancers returns those of nbs which were created for the service by the
+// cluster clusterName: they carry the cluster's tag, and config metadata recorded for
+// the service. NodeBalancers whose configs were recorded before owners were are
+// recognized by the hash of the service's UID in their label instead.
+func ownedNodeBalancers(clusterName string, service *v1.Service, nbs []linodego.NodeBalancer) []linodego.NodeBalancer {
+	owner := serviceOwner(service)
+	var owned []linodego.NodeBalancer
+	for _, nb := range nbs {
+		if !hasTag(nb.Tags, makeClusterTag(clusterName)) {
+			continue
+		}
+		metadata, _ := parseConfigMetadataTags(nb.Tags)
+		ownedBy, hasOwners := false, false
+		for _, m := range metadata {
+			hasOwners = hasOwners || m.Owner != ""
+			ownedBy = ownedBy || m.Owner == owner
+		}
+		if ownedBy || (!hasOwners && nb.Label != nil && strings.Contains(*nb.Label, owner[:nodeBalancerLabelHashLength])) {
+			owned = append(owned, nb)
+		}
+	}
+	return owned
+}
+
+// warnDuplicateNodeBalancers records a warning event naming the NodeBalancers labeled
+// label other than nb, which is used for the service as chosen, if there are any.
+func (l *loadbalancers) warnDuplicateNodeBalancers(service *v1.Service, label, chosen string, nb *linodego.NodeBalancer, matches []linodego.NodeBalancer) {
+	duplicates := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if match.ID != nb.ID {
+			duplicates = append(duplicates, strconv.Itoa(match.ID))
+		}
+	}
+	if len(duplicates) == 0 {
+		return
+	}
+	msg := fmt.Sprintf("found %d NodeBalancers labeled %s; using %s (%d), the duplicates (%s) should be deleted",
+		len(matches), label, chosen, nb.ID, strings.Join(duplicates, ", "))
+	klog.Warningf("service (%s) %s", getServiceNn(service), msg)
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonDuplicateNodeBalancers, "%s", msg)
+}
+
+// checkDuplicateNodeBalancers warns about duplicates of nb, the NodeBalancer found
+// through the service's status, as getNodeBalancerByLabel does. nb is kept, as
+// switching would change the service's IP. Each NodeBalancer is checked once, so that
+// ordinary reconciles do not list every NodeBalancer; one whose label is not the
+// service's, such as one adopted by IP, is not checked.
+func (l *loadbalancers) checkDuplicateNodeBalancers(ctx context.Context, clusterName string, service *v1.Service, nb *linodego.NodeBalancer) {
+	serviceNn := getServiceNn(service)
+	label := l.GetLoadBalancerName(ctx, clusterName, service)
+	if l.states.get(serviceNn).duplicatesCheckedID == nb.ID || nb.Label == nil || *nb.Label != label {
+		return
+	}
+	labeled, err := l.listNodeBalancersByLabel(ctx, label)
+	if err != nil {
+		klog.Warningf("failed to check for duplicates of NodeBalancer (%d) of service (%s): %s", nb.ID, serviceNn, err)
+		return
+	}
+	l.warnDuplicateNodeBalancers(service, label, "the one in the service's status", nb, ownedNodeBalancers(clusterName, service, labeled))
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.duplicatesCheckedID = nb.ID
+	})
+}
+
+// getNodeBalancerForLoadBalancerIP returns the existing NodeBalancer whose IPv4 address
+// is the service's spec.loadBalancerIP. NodeBalancers are always assigned an address by
+// Linode, so a new NodeBalancer cannot be created with the requested address.
+func (l *loadbalancers) getNodeBalancerForLoadBalancerIP(ctx context.Context, service *v1.Service) (*linodego.NodeBalancer, error) {
+	ip := service.Spec.LoadBalancerIP
+	nb, err := l.getNodeBalancerByIPv4(ctx, service, ip)
+	switch err.(type) {
+	case nil:
+		klog.Infof("reusing NodeBalancer (%d) with loadBalancerIP (%s) for service (%s)", nb.ID, ip, getServiceNn(service))
+		return nb, nil
+
+	case lbNotFoundError:
+		return nil, fmt.Errorf("no NodeBalancer has the loadBalancerIP (%s) requested by service (%s); Linode cannot create a NodeBalancer with a specific IP", ip, getServiceNn(service))
+
+	default:
+		return nil, err
+	}
+}
+
+func (l *loadbalancers) getNodeBalancerByID(ctx context.Context, service *v1.Service, id int) (*linodego.NodeBalancer, error) {
+	nb, err := l.client.GetNodeBalancer(ctx, id)
+	if err != nil {
+		if apiErr, ok := err.(*linodego.Error); ok && apiErr.Code == http.StatusNotFound {
+			return nil, lbNotFoundError{serviceNn: getServiceNn(service), nodeBalancerID: id}
+		}
+		return nil, err
+	}
+	return nb, nil
+}
+
+func (l *loadbalancers) createNodeBalancer(ctx context.Context, clusterName string, service *v1.Service, configs []*linodego.NodeBalancerConfigCreateOptions) (lb *linodego.NodeBalancer, err error) {
+	connThrottle := getConnectionThrottle(service)
+
+	metadata := make(map[int]configMetadata, len(configs))
+	for _, config := range configs {
+		m, err := newConfigMetadata(*config)
+		if err != nil {
+			return nil, err
+		}
+		m.Owner = serviceOwner(service)
+		metadata[config.Port] = m
+	}
+	metadataTags, err := makeConfigMetadataTags(metadata)
+	if err != nil {
+		return nil, err
+	}
+	serviceTags, err := getServiceTags(service)
+	if err != nil {
+		return nil, err
+	}
+	tags := append([]string{makeClusterTag(clusterName)}, metadataTags...)
+	tags = append(tags, serviceTags...)
+
+	label := l.GetLoadBalancerName(ctx, clusterName, service)
+	createOpts := linodego.NodeBalancerCreateOptions{
+		Label:              &label,
+		Region:             l.zone,
+		ClientConnThrottle: &connThrottle,
+		Configs:            configs,
+		Tags:               tags,
+	}
+	nb, err := l.createNodeBalancerInRegions(ctx, service, createOpts)
+	if err != nil {
+		return nil, err
+	}
+	return l.waitForNodeBalancerIP(ctx, service, nb)
+}
+
+// validateIPPoll returns an error unless the --nodebalancer-ip-poll-timeout and
+// --nodebalancer-ip-poll-interval are both positive.
+func validateIPPoll(timeout, interval time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("invalid --nodebalancer-ip-poll-timeout %s, must be positive", timeout)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("invalid --nodebalancer-ip-poll-interval %s, must be positive", interval)
+	}
 	return nil
 }
 
-func (l *loadbalancers) getNodeBalancerByIPv4(ctx context.Context, service *v1.Service, ipv4 string) (*linodego.NodeBalancer, error) {
-	lbs, err := l.client.ListNodeBalancers(ctx, nil)
-	if err != nil {
-		return nil, err
+// waitForNodeBalancerIP returns nb once it has been assigned an IPv4 address, which the
+// service's ingress status is made from. The address is polled every
+// Options.IPPollInterval for up to Options.IPPollTimeout; if none is assigned in time,
+// the NodeBalancer is deleted so that the next reconcile does not leave it orphaned.
+func (l *loadbalancers) waitForNodeBalancerIP(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer) (*linodego.NodeBalancer, error) {
+	if nb.IPv4 != nil && *nb.IPv4 != "" {
+		return nb, nil
 	}
-	for _, lb := range lbs {
-		if *lb.IPv4 == ipv4 {
-			klog.V(2).Infof("found NodeBalancer (%d) for service (%s) via IPv4 (%s)", lb.ID, getServiceNn(service), ipv4)
-			return &lb, nil
+
+	interval := Options.IPPollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	pollCtx, cancel := context.WithTimeout(ctx, Options.IPPollTimeout)
+	defer cancel()
+
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		current, err := l.client.GetNodeBalancer(pollCtx, nb.ID)
+		if err != nil {
+			klog.V(2).Infof("failed to get NodeBalancer (%d) while waiting for its IPv4 address: %s", nb.ID, err)
+			return false, nil
 		}
+		nb = current
+		return nb.IPv4 != nil && *nb.IPv4 != "", nil
+	}, pollCtx.Done())
+	if err == nil {
+		return nb, nil
 	}
-	return nil, lbNotFoundError{serviceNn: getServiceNn(service)}
+
+	err = fmt.Errorf("NodeBalancer (%d) was not assigned an IPv4 address within %s", nb.ID, Options.IPPollTimeout)
+	klog.Errorf("failed waiting for the IPv4 address of NodeBalancer (%d) for service (%s): %s", nb.ID, getServiceNn(service), err)
+	if deleteErr := l.client.DeleteNodeBalancer(ctx, nb.ID); deleteErr != nil {
+		klog.Errorf("failed to delete NodeBalancer (%d) without an IPv4 address: %s", nb.ID, deleteErr)
+	}
+	return nil, err
 }
 
-func (l *loadbalancers) getNodeBalancerByID(ctx context.Context, service *v1.Service, id int) (*linodego.NodeBalancer, error) {
-	nb, err := l.client.GetNodeBalancer(ctx, id)
-	if err != nil {
-		if apiErr, ok := err.(*linodego.Error); ok && apiErr.Code == http.StatusNotFound {
-			return nil, lbNotFoundError{serviceNn: getServiceNn(service), nodeBalancerID: id}
+// createNodeBalancerInRegions creates a NodeBalancer from createOpts in the configured
+// region or, while regions lack capacity, in each of Options.FallbackRegions in turn.
+func (l *loadbalancers) createNodeBalancerInRegions(ctx context.Context, service *v1.Service, createOpts linodego.NodeBalancerCreateOptions) (*linodego.NodeBalancer, error) {
+	regions := append([]string{l.zone}, Options.FallbackRegions...)
+
+	var err error
+	for i, region := range regions {
+		createOpts.Region = region
+		if i > 0 && l.lookups != nil {
+			if _, ok := l.lookups.validateRegion(ctx, region, false).(unknownRegionError); ok {
+				klog.Warningf("not creating the NodeBalancer for service (%s) in unknown fallback region %s", getServiceNn(service), region)
+				continue
+			}
 		}
-		return nil, err
+
+		var nb *linodego.NodeBalancer
+		if nb, err = l.client.CreateNodeBalancer(ctx, createOpts); err == nil {
+			if i > 0 {
+				klog.Warningf("created NodeBalancer (%d) for service (%s) in fallback region %s", nb.ID, getServiceNn(service), region)
+				l.recordEvent(service, v1.EventTypeWarning, eventReasonRegionFallback,
+					"NodeBalancer (%d) was created in fallback region %s as region %s lacks capacity", nb.ID, region, l.zone)
+			}
+			return nb, nil
+		}
+		if !isCapacityError(err) {
+			return nil, err
+		}
+		klog.Warningf("region %s lacks capacity for the NodeBalancer of service (%s): %s", region, getServiceNn(service), err)
 	}
-	return nb, nil
-}
 
-func (l *loadbalancers) createNodeBalancer(ctx context.Context, clusterName string, service *v1.Service, configs []*linodego.NodeBalancerConfigCreateOptions) (lb *linodego.NodeBalancer, err error) {
-	connThrottle := getConnectionThrottle(service)
+	capacityErr := regionCapacityError{regions: regions, err: err}
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonRegionAtCapacity, "%s", capacityErr)
+	return nil, capacityErr
+}
 
-	label := l.GetLoadBalancerName(ctx, clusterName, service)
-	createOpts := linodego.NodeBalancerCreateOptions{
-		Label:              &label,
-		Region:             l.zone,
-		ClientConnThrottle: &connThrottle,
-		Configs:            configs,
+// isCapacityError reports whether err is a Linode API error reporting that a region
+// cannot currently hold more NodeBalancers.
+func isCapacityError(err error) bool {
+	var apiErr *linodego.Error
+	if !errors.As(err, &apiErr) {
+		var valueErr linodego.Error
+		if !errors.As(err, &valueErr) {
+			return false
+		}
+		apiErr = &valueErr
 	}
-	return l.client.CreateNodeBalancer(ctx, createOpts)
+	return apiErr.Code == http.StatusServiceUnavailable || strings.Contains(strings.ToLower(apiErr.Message), "capacity")
 }
 
 //nolint:funlen
@@ -481,64 +1689,31 @@ func (l *loadbalancers) buildNodeBalancerConfig(ctx context.Context, service *v1
 		return linodego.NodeBalancerConfig{}, err
 	}
 
-	health, err := getHealthCheckType(service)
+	health, err := getHealthCheck(service, portConfig)
 	if err != nil {
-		return linodego.NodeBalancerConfig{}, nil
+		return linodego.NodeBalancerConfig{}, err
 	}
 
 	config := linodego.NodeBalancerConfig{
 		Port:          port,
 		Protocol:      portConfig.Protocol,
 		ProxyProtocol: portConfig.ProxyProtocol,
-		Check:         health,
-	}
-
-	if health == linodego.CheckHTTP || health == linodego.CheckHTTPBody {
-		path := service.Annotations[annLinodeCheckPath]
-		if path == "" {
-			path = "/"
-		}
-		config.CheckPath = path
-	}
-
-	if health == linodego.CheckHTTPBody {
-		body := service.Annotations[annLinodeCheckBody]
-		if body == "" {
-			return config, fmt.Errorf("for health check type http_body need body regex annotation %v", annLinodeCheckBody)
-		}
-		config.CheckBody = body
-	}
-	checkInterval := 5
-	if ci, ok := service.Annotations[annLinodeHealthCheckInterval]; ok {
-		if checkInterval, err = strconv.Atoi(ci); err != nil {
-			return config, err
-		}
-	}
-	config.CheckInterval = checkInterval
-
-	checkTimeout := 3
-	if ct, ok := service.Annotations[annLinodeHealthCheckTimeout]; ok {
-		if checkTimeout, err = strconv.Atoi(ct); err != nil {
-			return config, err
-		}
+		Algorithm:     portConfig.Algorithm,
+		Stickiness:    portConfig.Stickiness,
+		Check:         health.Type,
+		CheckInterval: jitterCheckInterval(service, port, health.Interval, Options.HealthCheckIntervalJitter),
+		CheckTimeout:  health.Timeout,
+		CheckAttempts: health.Attempts,
+		CheckPassive:  health.Passive,
 	}
-	config.CheckTimeout = checkTimeout
 
-	checkAttempts := 2
-	if ca, ok := service.Annotations[annLinodeHealthCheckAttempts]; ok {
-		if checkAttempts, err = strconv.Atoi(ca); err != nil {
-			return config, err
-		}
+	if health.Type == linodego.CheckHTTP || health.Type == linodego.CheckHTTPBody {
+		config.CheckPath = health.Path
 	}
-	config.CheckAttempts = checkAttempts
 
-	checkPassive := true
-	if cp, ok := service.Annotations[annLinodeHealthCheckPassive]; ok {
-		if checkPassive, err = strconv.ParseBool(cp); err != nil {
-			return config, err
-		}
+	if health.Type == linodego.CheckHTTPBody {
+		config.CheckBody = health.bodyPattern()
 	}
-	config.CheckPassive = checkPassive
 
 	if portConfig.Protocol == linodego.ProtocolHTTPS {
 		if err = l.addTLSCert(ctx, service, &config, portConfig); err != nil {
@@ -550,12 +1725,29 @@ func (l *loadbalancers) buildNodeBalancerConfig(ctx context.Context, service *v1
 }
 
 func (l *loadbalancers) addTLSCert(ctx context.Context, service *v1.Service, nbConfig *linodego.NodeBalancerConfig, config portConfig) error {
+	if config.TLSObjectStorage != "" {
+		var err error
+		nbConfig.SSLCert, nbConfig.SSLKey, err = l.getObjectStorageTLSCertInfo(ctx, config)
+		return err
+	}
+
 	err := l.retrieveKubeClient()
 	if err != nil {
 		return err
 	}
 
+	// The secret is tracked even if it cannot be read yet, so the service is reconciled
+	// once it is created or fixed.
+	if namespace, name, err := parseTLSSecretRef(config.TLSSecretName, service.Namespace); err == nil && config.TLSSecretName != "" {
+		l.refresh.track(getServiceNn(service), refreshSource{kind: refreshKindSecret, namespace: namespace, name: name})
+	}
+
 	nbConfig.SSLCert, nbConfig.SSLKey, err = getTLSCertInfo(ctx, l.kubeClient, service.Namespace, config)
+	var forbiddenErr forbiddenSecretNamespaceError
+	if errors.As(err, &forbiddenErr) {
+		klog.Warningf("service (%s) references TLS secret %s/%s in forbidden namespace %q", getServiceNn(service), forbiddenErr.secretNamespace, forbiddenErr.secretName, forbiddenErr.secretNamespace)
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonForbiddenSecretNamespace, "%s", err)
+	}
 	if err != nil {
 		return err
 	}
@@ -565,38 +1757,250 @@ func (l *loadbalancers) addTLSCert(ctx context.Context, service *v1.Service, nbC
 // buildLoadBalancerRequest returns a linodego.NodeBalancer
 // requests for service across nodes.
 func (l *loadbalancers) buildLoadBalancerRequest(ctx context.Context, clusterName string, service *v1.Service, nodes []*v1.Node) (*linodego.NodeBalancer, error) {
-	ports := service.Spec.Ports
+	ports, err := configOrderedServicePorts(service)
+	if err != nil {
+		return nil, err
+	}
+	if len(ports) == 0 {
+		// A NodeBalancer without configs would only cost money without serving anything.
+		err := fmt.Errorf("service %s has no ports", getServiceNn(service))
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonNoServicePorts, "not creating a NodeBalancer: %s", err)
+		return nil, err
+	}
 	configs := make([]*linodego.NodeBalancerConfigCreateOptions, 0, len(ports))
 
+	resolver, err := getBackendAddressResolver(service)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := len(nodes)
+	nodes, err = l.filterBackendNodes(service, nodes)
+	if err != nil {
+		return nil, err
+	}
+	nodes = selectBackendNodes(service, l.filterAddressableNodes(service, resolver, nodes))
+	if len(nodes) == 0 && Options.NoBackendNodesPolicy == noBackendNodesDefer {
+		// A NodeBalancer without backends could not serve anything either.
+		err := noBackendNodesError{serviceNn: getServiceNn(service), nodes: candidates}
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonNoBackendNodes, "%s", err)
+		return nil, err
+	}
+
 	for _, port := range ports {
 		if port.Protocol == v1.ProtocolUDP {
 			return nil, fmt.Errorf("error creating NodeBalancer Config: ports with the UDP protocol are not supported")
 		}
+	}
+	if err = l.checkNodePorts(service); err != nil {
+		return nil, err
+	}
 
+	for _, port := range ports {
 		config, err := l.buildNodeBalancerConfig(ctx, service, int(port.Port))
 		if err != nil {
 			return nil, err
 		}
 		createOpt := config.GetCreateOptions()
-
-		for _, n := range nodes {
-			createOpt.Nodes = append(createOpt.Nodes, l.buildNodeBalancerNodeCreateOptions(n, port.NodePort))
+		createOpt.Nodes, err = l.buildNodeBalancerNodesCreateOptions(resolver, nodes, port.NodePort)
+		if err != nil {
+			return nil, err
 		}
 
 		configs = append(configs, &createOpt)
 	}
-	return l.createNodeBalancer(ctx, clusterName, service, configs)
+
+	nb, err := l.createNodeBalancer(ctx, clusterName, service, configs)
+	if err != nil {
+		return nil, err
+	}
+	l.states.update(getServiceNn(service), func(state *serviceState) {
+		state.nodeBalancerID = nb.ID
+		state.backends = len(nodes)
+	})
+	return nb, nil
+}
+
+// checkNodePorts checks the service's NodePorts fall within Options.NodePortRange, as
+// NodeBalancers pointed at other ports cannot reach the service. Ports out of range are
+// an error when Options.RejectOutOfRangeNodePorts is set, and otherwise are reported in
+// a warning event. Nothing is checked when no range is configured.
+func (l *loadbalancers) checkNodePorts(service *v1.Service) error {
+	if Options.NodePortRange.Size == 0 {
+		return nil
+	}
+
+	var outOfRange []string
+	for _, port := range service.Spec.Ports {
+		if port.NodePort != 0 && !Options.NodePortRange.Contains(int(port.NodePort)) {
+			outOfRange = append(outOfRange, strconv.Itoa(int(port.NodePort)))
+		}
+	}
+	if len(outOfRange) == 0 {
+		return nil
+	}
+
+	msg := fmt.Sprintf("NodePorts %s are outside the node port range %s and may be unreachable",
+		strings.Join(outOfRange, ", "), Options.NodePortRange.String())
+	if Options.RejectOutOfRangeNodePorts {
+		return fmt.Errorf("service (%s) %s", getServiceNn(service), msg)
+	}
+	klog.Warningf("service (%s) %s", getServiceNn(service), msg)
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonNodePortOutOfRange, "%s", msg)
+	return nil
+}
+
+// explainNodePortRouting emits an event when any of the service's ports has a named
+// targetPort, which users may expect the NodeBalancer to reach pods on. NodeBalancers
+// only ever reach backends on the port's NodePort, leaving kube-proxy to resolve the
+// targetPort. The event is emitted again only if the named targetPorts change.
+func (l *loadbalancers) explainNodePortRouting(service *v1.Service) {
+	var named []string
+	for _, port := range sortedServicePorts(service) {
+		if port.TargetPort.Type == intstr.String {
+			named = append(named, fmt.Sprintf("%d (targetPort %q)", port.Port, port.TargetPort.StrVal))
+		}
+	}
+	explained := strings.Join(named, ", ")
+
+	explain := false
+	l.states.update(getServiceNn(service), func(state *serviceState) {
+		explain = explained != "" && explained != state.nodePortRoutingExplained
+		state.nodePortRoutingExplained = explained
+	})
+	if explain {
+		l.recordEvent(service, v1.EventTypeNormal, eventReasonNodePortRouting,
+			"ports %s have named targetPorts; NodeBalancer backends are always reached on each port's NodePort, which kube-proxy forwards to the pods' named port", explained)
+	}
+}
+
+// getBackendNodeSelector returns the selector the service's backend nodes must match,
+// which is everything when the service is not annotated with one.
+func getBackendNodeSelector(service *v1.Service) (labels.Selector, error) {
+	raw, ok := getServiceAnnotation(service, annLinodeBackendNodeSelector)
+	if !ok {
+		return labels.Everything(), nil
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("invalid %s annotation: selector must not be empty", annLinodeBackendNodeSelector)
+	}
+	selector, err := labels.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %s", annLinodeBackendNodeSelector, err)
+	}
+	return selector, nil
+}
+
+// filterBackendNodes returns the nodes matching the service's backend node selector. A
+// warning event is recorded when a selector matches none of the nodes, as the service
+// is then unreachable.
+func (l *loadbalancers) filterBackendNodes(service *v1.Service, nodes []*v1.Node) ([]*v1.Node, error) {
+	selector, err := getBackendNodeSelector(service)
+	if err != nil || selector.Empty() {
+		return nodes, err
+	}
+
+	matched := make([]*v1.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if selector.Matches(labels.Set(node.Labels)) {
+			matched = append(matched, node)
+		}
+	}
+	if len(matched) == 0 && len(nodes) != 0 {
+		msg := fmt.Sprintf("none of the %d nodes match the backend node selector %q", len(nodes), selector.String())
+		klog.Warningf("service (%s) %s", getServiceNn(service), msg)
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonNoBackendNodes, "%s", msg)
+	}
+	return matched, nil
 }
 
-func (l *loadbalancers) buildNodeBalancerNodeCreateOptions(node *v1.Node, nodePort int32) linodego.NodeBalancerNodeCreateOptions {
+// selectBackendNodes returns the nodes to register as backends for service, capped at
+// maxNodeBalancerConfigNodes. When there are too many nodes, Ready and schedulable nodes
+// are preferred, and ties are broken by node name so the selection is stable across
+// reconciles.
+func selectBackendNodes(service *v1.Service, nodes []*v1.Node) []*v1.Node {
+	if len(nodes) <= maxNodeBalancerConfigNodes {
+		return nodes
+	}
+
+	sorted := make([]*v1.Node, len(nodes))
+	copy(sorted, nodes)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		iEligible, jEligible := isNodeEligibleBackend(sorted[i]), isNodeEligibleBackend(sorted[j])
+		if iEligible != jEligible {
+			return iEligible
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	klog.Warningf("service (%s) has %d nodes but a NodeBalancer config accepts at most %d; registering a subset",
+		getServiceNn(service), len(nodes), maxNodeBalancerConfigNodes)
+	return sorted[:maxNodeBalancerConfigNodes]
+}
+
+// isNodeEligibleBackend reports whether node is Ready and schedulable.
+func isNodeEligibleBackend(node *v1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	return isNodeReady(node)
+}
+
+func isNodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// sortedServicePorts returns the service's ports ordered by port number, so that configs
+// are built and compared in the same order however the ports are listed.
+func sortedServicePorts(service *v1.Service) []v1.ServicePort {
+	ports := append([]v1.ServicePort(nil), service.Spec.Ports...)
+	sort.Slice(ports, func(i, j int) bool { return ports[i].Port < ports[j].Port })
+	return ports
+}
+
+// buildNodeBalancerNodesCreateOptions returns the backends for nodes, addressed by
+// resolver and ordered by label and address so that equivalent node lists produce
+// identical configs.
+func (l *loadbalancers) buildNodeBalancerNodesCreateOptions(resolver BackendAddressResolver, nodes []*v1.Node, nodePort int32) ([]linodego.NodeBalancerNodeCreateOptions, error) {
+	nbNodes := make([]linodego.NodeBalancerNodeCreateOptions, 0, len(nodes))
+	for _, node := range nodes {
+		address, err := resolver.BackendAddress(node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get backend address of node (%s): %w", node.Name, err)
+		}
+		nbNodes = append(nbNodes, l.buildNodeBalancerNodeCreateOptions(node, address, nodePort))
+	}
+	sort.Slice(nbNodes, func(i, j int) bool {
+		if nbNodes[i].Label != nbNodes[j].Label {
+			return nbNodes[i].Label < nbNodes[j].Label
+		}
+		return nbNodes[i].Address < nbNodes[j].Address
+	})
+	return nbNodes, nil
+}
+
+func (l *loadbalancers) buildNodeBalancerNodeCreateOptions(node *v1.Node, address string, nodePort int32) linodego.NodeBalancerNodeCreateOptions {
 	return linodego.NodeBalancerNodeCreateOptions{
-		Address: fmt.Sprintf("%v:%v", getNodeInternalIP(node), nodePort),
+		Address: fmt.Sprintf("%v:%v", address, nodePort),
 		Label:   node.Name,
 		Mode:    "accept",
 		Weight:  100,
 	}
 }
 
+// recordEvent emits an event for service if the loadbalancers have an event recorder.
+func (l *loadbalancers) recordEvent(service *v1.Service, eventType, reason, messageFmt string, args ...interface{}) {
+	if l.recorder == nil {
+		return
+	}
+	l.recorder.Eventf(service, eventType, reason, messageFmt, args...)
+}
+
 func (l *loadbalancers) retrieveKubeClient() error {
 	if l.kubeClient != nil {
 		return nil
@@ -635,9 +2039,12 @@ func getPortConfig(service *v1.Service, port int) (portConfig, error) {
 		return portConfig, err
 	}
 	protocol := portConfigAnnotation.Protocol
-	if protocol == "" {
+	if protocol == "" && (portConfigAnnotation.TLSSecretName != "" || portConfigAnnotation.TLSObjectStorage != "") {
+		// A port given a certificate is meant to terminate TLS, whatever the default
+		protocol = string(linodego.ProtocolHTTPS)
+	} else if protocol == "" {
 		var ok bool
-		protocol, ok = service.Annotations[annLinodeDefaultProtocol]
+		protocol, ok = getServiceAnnotation(service, annLinodeDefaultProtocol)
 		if !ok {
 			protocol = "tcp"
 		}
@@ -645,11 +2052,11 @@ func getPortConfig(service *v1.Service, port int) (portConfig, error) {
 	protocol = strings.ToLower(protocol)
 
 	proxyProtocol := portConfigAnnotation.ProxyProtocol
+	proxyProtocolSet := proxyProtocol != ""
 	if proxyProtocol == "" {
-		var ok bool
 		for _, ann := range []string{annLinodeDefaultProxyProtocol, annLinodeProxyProtocolDeprecated} {
-			proxyProtocol, ok = service.Annotations[ann]
-			if ok {
+			proxyProtocol, proxyProtocolSet = getServiceAnnotation(service, ann)
+			if proxyProtocolSet {
 				break
 			} else {
 				proxyProtocol = string(linodego.ProxyProtocolNone)
@@ -657,10 +2064,27 @@ func getPortConfig(service *v1.Service, port int) (portConfig, error) {
 		}
 	}
 
+	preserveSourceIP, err := getPreserveSourceIP(service)
+	if err != nil {
+		return portConfig, err
+	}
+
 	if protocol != "tcp" && protocol != "http" && protocol != "https" {
 		return portConfig, fmt.Errorf("invalid protocol: %q specified", protocol)
 	}
 
+	websocket, err := isWebSocketPort(service, port)
+	if err != nil {
+		return portConfig, err
+	}
+	if websocket {
+		configured := protocol
+		if protocol, err = getWebSocketProtocol(port, protocol); err != nil {
+			return portConfig, err
+		}
+		portConfig.WebSocketTCP = protocol != configured
+	}
+
 	switch proxyProtocol {
 	case string(linodego.ProxyProtocolNone), string(linodego.ProxyProtocolV1), string(linodego.ProxyProtocolV2):
 		break
@@ -668,45 +2092,155 @@ func getPortConfig(service *v1.Service, port int) (portConfig, error) {
 		return portConfig, fmt.Errorf("invalid NodeBalancer proxy protocol value '%s'", proxyProtocol)
 	}
 
+	// NodeBalancers terminate http and https connections themselves, so PROXY protocol
+	// headers can only be sent to backends of tcp configs.
+	if proxyProtocol != string(linodego.ProxyProtocolNone) && protocol != string(linodego.ProtocolTCP) {
+		return portConfig, fmt.Errorf("NodeBalancer proxy protocol '%s' cannot be used with protocol %q, only with %q", proxyProtocol, protocol, linodego.ProtocolTCP)
+	}
+
+	// http and https configs already pass the client address in X-Forwarded-For, so
+	// preserving it only takes PROXY protocol on tcp configs. An explicitly chosen
+	// version is kept.
+	if preserveSourceIP && protocol == string(linodego.ProtocolTCP) {
+		if !proxyProtocolSet {
+			proxyProtocol = string(linodego.ProxyProtocolV2)
+		} else if proxyProtocol == string(linodego.ProxyProtocolNone) {
+			return portConfig, fmt.Errorf("NodeBalancer proxy protocol '%s' cannot be used with %s, which needs PROXY protocol for protocol %q", proxyProtocol, annLinodePreserveSourceIP, protocol)
+		}
+	}
+
+	if portConfigAnnotation.TLSSecretName != "" && portConfigAnnotation.TLSObjectStorage != "" {
+		return portConfig, fmt.Errorf("only one of tls-secret-name and tls-object-storage can be specified for port %d", port)
+	}
+
+	// https ports without a certificate of their own use the ingress-tls one
+	tlsSecretName := portConfigAnnotation.TLSSecretName
+	ingressTLSSecretName, err := getIngressTLSSecretName(service)
+	if err != nil {
+		return portConfig, err
+	}
+	if protocol == string(linodego.ProtocolHTTPS) && tlsSecretName == "" && portConfigAnnotation.TLSObjectStorage == "" {
+		tlsSecretName = ingressTLSSecretName
+	}
+
+	algorithm := portConfigAnnotation.Algorithm
+	if algorithm == "" {
+		var ok bool
+		algorithm, ok = getServiceAnnotation(service, annLinodeDefaultAlgorithm)
+		if !ok {
+			algorithm = string(linodego.AlgorithmRoundRobin)
+		}
+	}
+
+	switch linodego.ConfigAlgorithm(algorithm) {
+	case linodego.AlgorithmRoundRobin, linodego.AlgorithmLeastConn, linodego.AlgorithmSource:
+		break
+	default:
+		return portConfig, fmt.Errorf("invalid NodeBalancer algorithm value '%s'", algorithm)
+	}
+
+	stickiness := portConfigAnnotation.Stickiness
+	if stickiness == "" {
+		var ok bool
+		stickiness, ok = getServiceAnnotation(service, annLinodeDefaultStickiness)
+		if !ok {
+			stickiness = getSessionAffinityStickiness(service)
+		}
+	}
+
+	switch linodego.ConfigStickiness(stickiness) {
+	case linodego.StickinessNone, linodego.StickinessTable:
+		break
+	case linodego.StickinessHTTPCookie:
+		if protocol == string(linodego.ProtocolTCP) {
+			return portConfig, fmt.Errorf("NodeBalancer stickiness '%s' cannot be used with protocol %q", stickiness, protocol)
+		}
+	default:
+		return portConfig, fmt.Errorf("invalid NodeBalancer stickiness value '%s'", stickiness)
+	}
+
 	portConfig.Port = port
 	portConfig.Protocol = linodego.ConfigProtocol(protocol)
 	portConfig.ProxyProtocol = linodego.ConfigProxyProtocol(proxyProtocol)
-	portConfig.TLSSecretName = portConfigAnnotation.TLSSecretName
+	portConfig.Algorithm = linodego.ConfigAlgorithm(algorithm)
+	portConfig.Stickiness = linodego.ConfigStickiness(stickiness)
+	portConfig.TLSSecretName = tlsSecretName
+	portConfig.TLSObjectStorage = portConfigAnnotation.TLSObjectStorage
+	portConfig.HealthCheck, err = getPortHealthCheck(portConfigAnnotation, port)
+	if err != nil {
+		return portConfig, err
+	}
 
 	return portConfig, nil
 }
 
-func getHealthCheckType(service *v1.Service) (linodego.ConfigCheck, error) {
-	hType, ok := service.Annotations[annLinodeHealthCheckType]
+// getPortHealthCheck returns the health check overrides of a port config annotation,
+// with its check shorthand applied as the type of its healthcheck.
+func getPortHealthCheck(annotation portConfigAnnotation, port int) (*healthCheckAnnotation, error) {
+	if annotation.Check == "" {
+		return annotation.HealthCheck, nil
+	}
+	if !isValidHealthCheckType(annotation.Check) {
+		return nil, fmt.Errorf("invalid check %q for port %d, must be one of none, connection, http or http_body", annotation.Check, port)
+	}
+
+	var health healthCheckAnnotation
+	if annotation.HealthCheck != nil {
+		health = *annotation.HealthCheck
+	}
+	if health.Type != "" && health.Type != annotation.Check {
+		return nil, fmt.Errorf("port %d sets check %q and healthcheck type %q; set only one of them", port, annotation.Check, health.Type)
+	}
+	health.Type = annotation.Check
+	return &health, nil
+}
+
+// getSessionAffinityStickiness returns the stickiness matching the service's
+// sessionAffinity, for ports without a stickiness annotation. ClientIP affinity keeps
+// each client on the same backend Node with a table of client addresses.
+func getSessionAffinityStickiness(service *v1.Service) string {
+	if service.Spec.SessionAffinity == v1.ServiceAffinityClientIP {
+		return string(linodego.StickinessTable)
+	}
+	return string(linodego.StickinessNone)
+}
+
+// getPreserveSourceIP reports whether service is annotated with preserve-source-ip.
+func getPreserveSourceIP(service *v1.Service) (bool, error) {
+	preserveRaw, ok := getServiceAnnotation(service, annLinodePreserveSourceIP)
 	if !ok {
-		return linodego.CheckConnection, nil
+		return false, nil
 	}
-	if hType != "none" && hType != "connection" && hType != "http" && hType != "http_body" {
-		return "", fmt.Errorf("invalid health check type: %q specified in annotation: %q", hType, annLinodeHealthCheckType)
+	preserve, err := strconv.ParseBool(preserveRaw)
+	if err != nil {
+		return false, fmt.Errorf("invalid value %q for annotation %s, must be true or false", preserveRaw, annLinodePreserveSourceIP)
 	}
-	return linodego.ConfigCheck(hType), nil
+	return preserve, nil
 }
 
 func getPortConfigAnnotation(service *v1.Service, port int) (portConfigAnnotation, error) {
 	annotation := portConfigAnnotation{}
 	annotationKey := annLinodePortConfigPrefix + strconv.Itoa(port)
-	annotationJSON, ok := service.Annotations[annotationKey]
+	annotationJSON, ok := getServiceAnnotation(service, annotationKey)
 
 	if !ok {
 		return annotation, nil
 	}
 
-	err := json.Unmarshal([]byte(annotationJSON), &annotation)
-	if err != nil {
-		return annotation, err
+	if err := unmarshalAnnotationJSON(annotationKey, annotationJSON, &annotation); err != nil {
+		return portConfigAnnotation{}, err
 	}
 
 	return annotation, nil
 }
 
 func getNodeInternalIP(node *v1.Node) string {
+	return getNodeAddress(node, v1.NodeInternalIP)
+}
+
+func getNodeAddress(node *v1.Node, addressType v1.NodeAddressType) string {
 	for _, addr := range node.Status.Addresses {
-		if addr.Type == v1.NodeInternalIP {
+		if addr.Type == addressType {
 			return addr.Address
 		}
 	}
@@ -718,33 +2252,127 @@ func getTLSCertInfo(ctx context.Context, kubeClient kubernetes.Interface, namesp
 		return "", "", fmt.Errorf("TLS secret name for port %v is not specified", config.Port)
 	}
 
-	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, config.TLSSecretName, metav1.GetOptions{})
+	secretNamespace, secretName, err := parseTLSSecretRef(config.TLSSecretName, namespace)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid TLS secret for port %v: %s", config.Port, err)
+	}
+	if secretNamespace != namespace && !Options.AllowCrossNamespaceTLSSecrets {
+		return "", "", forbiddenSecretNamespaceError{
+			port:            config.Port,
+			namespace:       namespace,
+			secretNamespace: secretNamespace,
+			secretName:      secretName,
+		}
+	}
+
+	secret, err := getTLSSecret(ctx, kubeClient, secretNamespace, secretName)
 	if err != nil {
 		return "", "", err
 	}
 
-	cert := string(secret.Data[v1.TLSCertKey])
-	cert = strings.TrimSpace(cert)
+	if secret.Type != v1.SecretTypeTLS {
+		return "", "", fmt.Errorf("TLS secret %s/%s for port %v has type %q, must be %q", secretNamespace, secretName, config.Port, secret.Type, v1.SecretTypeTLS)
+	}
 
-	key := string(secret.Data[v1.TLSPrivateKeyKey])
+	cert := strings.TrimSpace(string(secret.Data[v1.TLSCertKey]))
+	if cert == "" {
+		return "", "", fmt.Errorf("TLS secret %s/%s for port %v is missing key %q", secretNamespace, secretName, config.Port, v1.TLSCertKey)
+	}
 
-	key = strings.TrimSpace(key)
+	key := strings.TrimSpace(string(secret.Data[v1.TLSPrivateKeyKey]))
+	if key == "" {
+		return "", "", fmt.Errorf("TLS secret %s/%s for port %v is missing key %q", secretNamespace, secretName, config.Port, v1.TLSPrivateKeyKey)
+	}
 
+	if err := validateTLSMaterial(fmt.Sprintf("TLS secret %s/%s for port %v", secretNamespace, secretName, config.Port), cert, key); err != nil {
+		return "", "", err
+	}
 	return cert, key, nil
 }
 
+// getObjectStorageTLSCertInfo returns the certificate and key stored as tls.crt and
+// tls.key under the port's tls-object-storage reference, after checking they are a
+// valid PEM key pair and validating them against Options.TLSValidation.
+func (l *loadbalancers) getObjectStorageTLSCertInfo(ctx context.Context, config portConfig) (string, string, error) {
+	if l.objectStorage == nil {
+		return "", "", fmt.Errorf("TLS certificate for port %v is in Object Storage, but no Object Storage credentials are configured", config.Port)
+	}
+
+	bucket, prefix, err := parseObjectStorageRef(config.TLSObjectStorage)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid TLS Object Storage reference for port %v: %s", config.Port, err)
+	}
+
+	cert, err := l.objectStorage.getObject(ctx, bucket, path.Join(prefix, v1.TLSCertKey))
+	if err != nil {
+		return "", "", err
+	}
+	key, err := l.objectStorage.getObject(ctx, bucket, path.Join(prefix, v1.TLSPrivateKeyKey))
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return "", "", fmt.Errorf("invalid TLS certificate or key in Object Storage %s for port %v: %s", config.TLSObjectStorage, config.Port, err)
+	}
+	if err := validateTLSMaterial(fmt.Sprintf("TLS certificate in Object Storage %s for port %v", config.TLSObjectStorage, config.Port), string(cert), string(key)); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(string(cert)), strings.TrimSpace(string(key)), nil
+}
+
+// parseObjectStorageRef parses a tls-object-storage reference of the form "bucket" or
+// "bucket/prefix".
+func parseObjectStorageRef(ref string) (bucket, prefix string, err error) {
+	parts := strings.SplitN(strings.Trim(strings.TrimSpace(ref), "/"), "/", 2)
+	bucket = parts[0]
+	if len(parts) == 2 {
+		prefix = parts[1]
+	}
+	if msgs := validation.IsDNS1123Subdomain(bucket); len(msgs) > 0 {
+		return "", "", fmt.Errorf("invalid bucket name %q: %s", bucket, strings.Join(msgs, ", "))
+	}
+	return bucket, prefix, nil
+}
+
+// parseTLSSecretRef parses a tls-secret-name of the form "name" or "namespace/name",
+// defaulting the namespace to defaultNamespace.
+func parseTLSSecretRef(ref, defaultNamespace string) (namespace, name string, err error) {
+	ref = strings.TrimSpace(ref)
+	namespace, name = defaultNamespace, ref
+	if parts := strings.Split(ref, "/"); len(parts) == 2 {
+		namespace, name = parts[0], parts[1]
+		if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+			return "", "", fmt.Errorf("invalid namespace %q: %s", namespace, strings.Join(msgs, ", "))
+		}
+	} else if len(parts) > 2 {
+		return "", "", fmt.Errorf("%q must be a secret name or namespace/name", ref)
+	}
+
+	if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+		return "", "", fmt.Errorf("invalid secret name %q: %s", name, strings.Join(msgs, ", "))
+	}
+	return namespace, name, nil
+}
+
+// getConnectionThrottle returns the service's Client Connection Throttle, clamped to
+// between 0 and maxConnectionThrottle.
 func getConnectionThrottle(service *v1.Service) int {
+	max := maxConnectionThrottle()
 	connThrottle := 20
+	if connThrottle > max {
+		connThrottle = max
+	}
 
-	if connThrottleString := service.Annotations[annLinodeThrottle]; connThrottleString != "" {
+	if connThrottleString, _ := getServiceAnnotation(service, annLinodeThrottle); connThrottleString != "" {
 		parsed, err := strconv.Atoi(connThrottleString)
 		if err == nil {
 			if parsed < 0 {
 				parsed = 0
 			}
 
-			if parsed > 20 {
-				parsed = 20
+			if parsed > max {
+				parsed = max
 			}
 			connThrottle = parsed
 		}
@@ -767,10 +2395,27 @@ func getServiceNn(service *v1.Service) string {
 	return fmt.Sprintf("%s/%s", service.Namespace, service.Name)
 }
 
+// getServiceAnnotation returns the service's annotation with the given name, preferring
+// its GA form when the name has the beta prefix. Annotations the service does not set
+// fall back to the defaults for its namespace, and then to those for the cluster.
 func getServiceAnnotation(service *v1.Service, name string) (string, bool) {
-	if service.Annotations == nil {
+	if val, ok := lookupAnnotation(service.Annotations, name); ok {
+		return val, ok
+	}
+	return defaultAnnotations.get(service.Namespace, name)
+}
+
+// lookupAnnotation returns the annotation with the given name from annotations,
+// preferring its GA form when the name has the beta prefix.
+func lookupAnnotation(annotations map[string]string, name string) (string, bool) {
+	if annotations == nil {
 		return "", false
 	}
-	val, ok := service.Annotations[name]
+	if gaName, ok := gaAnnotationName(name); ok {
+		if val, ok := annotations[gaName]; ok {
+			return val, ok
+		}
+	}
+	val, ok := annotations[name]
 	return val, ok
 }
@@ -1,5 +1,52 @@
 package linode
 
+import (
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
 const (
 	annLinodeProxyProtocolDeprecated = "service.beta.kubernetes.io/linode-loadbalancer-proxy-protocol"
 )
+
+// annLinodeGAAnnotationPrefix is the GA form of annLinodeAnnotationPrefix. Every
+// annotation may be given with either prefix; the GA one wins when both are set.
+const annLinodeGAAnnotationPrefix = "service.kubernetes.io/linode-loadbalancer-"
+
+// gaAnnotationName returns the GA form of the beta-prefixed annotation name, if it has
+// one.
+func gaAnnotationName(name string) (string, bool) {
+	if !strings.HasPrefix(name, annLinodeAnnotationPrefix) {
+		return "", false
+	}
+	return annLinodeGAAnnotationPrefix + strings.TrimPrefix(name, annLinodeAnnotationPrefix), true
+}
+
+// warnDeprecatedAnnotationPrefix emits a warning event recommending the GA prefix when
+// the service has annotations with the beta prefix. Each service is warned once.
+func (l *loadbalancers) warnDeprecatedAnnotationPrefix(service *v1.Service) {
+	var names []string
+	for name := range service.Annotations {
+		if strings.HasPrefix(name, annLinodeAnnotationPrefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+
+	warn := false
+	l.states.update(getServiceNn(service), func(state *serviceState) {
+		warn = !state.warnedDeprecatedAnnotationPrefix
+		state.warnedDeprecatedAnnotationPrefix = true
+	})
+	if !warn {
+		return
+	}
+	sort.Strings(names)
+	l.recordEvent(service, v1.EventTypeWarning, eventReasonDeprecatedAnnotationPrefix,
+		"annotations %s use the deprecated prefix %s; migrate them to %s",
+		strings.Join(names, ", "), annLinodeAnnotationPrefix, annLinodeGAAnnotationPrefix)
+}
@@ -1,22 +1,33 @@
 package linode
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/linode/linodego"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 const testCert string = `-----BEGIN CERTIFICATE-----
@@ -162,6 +173,114 @@ func TestCCMLoadBalancers(t *testing.T) {
 			name: "getNodeBalancerForService - NodeBalancerID does not exist",
 			f:    testGetNodeBalancerForServiceIDDoesNotExist,
 		},
+		{
+			name: "Ensure Load Balancer - NodeBalancer deleted out-of-band",
+			f:    testEnsureLoadBalancerDeletedOutOfBand,
+		},
+		{
+			name: "Ensure Load Balancer - adopted NodeBalancer deleted out-of-band",
+			f:    testEnsureLoadBalancerAdoptedDeletedOutOfBand,
+		},
+		{
+			name: "Ensure Load Balancer - region changed",
+			f:    testEnsureLoadBalancerRegionChanged,
+		},
+		{
+			name: "Update Load Balancer - unrelated node change",
+			f:    testUpdateLoadBalancerUnrelatedNodeChange,
+		},
+		{
+			name: "Ensure Load Balancer - loadBalancerIP",
+			f:    testEnsureLoadBalancerWithLoadBalancerIP,
+		},
+		{
+			name: "Update Load Balancer - config metadata tags",
+			f:    testUpdateLoadBalancerConfigMetadataTags,
+		},
+		{
+			name: "Create Load Balancer - node backend IP override",
+			f:    testCreateNodeBalancerWithNodeBackendIP,
+		},
+		{
+			name: "Update Load Balancer - algorithm and stickiness annotations",
+			f:    testUpdateLoadBalancerAlgorithmStickinessAnnotations,
+		},
+		{
+			name: "Update Load Balancer - reordered ports and nodes",
+			f:    testUpdateLoadBalancerReorderedPorts,
+		},
+		{
+			name: "Ensure Load Balancer - ready event",
+			f:    testEnsureLoadBalancerReadyEvent,
+		},
+		{
+			name: "Update Load Balancer - UDP port",
+			f:    testUpdateLoadBalancerUDPPort,
+		},
+		{
+			name: "Ensure Load Balancer - skip status update",
+			f:    testEnsureLoadBalancerSkipStatusUpdate,
+		},
+		{
+			name: "Ensure Load Balancer - skip status update with a cluster label template",
+			f:    testEnsureLoadBalancerSkipStatusUpdateClusterLabel,
+		},
+		{
+			name: "Update Load Balancer - source ranges",
+			f:    testUpdateLoadBalancerSourceRanges,
+		},
+		{
+			name: "Update Load Balancer - no Firewall requests without source ranges",
+			f:    testUpdateLoadBalancerWithoutSourceRanges,
+		},
+		{
+			name: "Ensure Load Balancer Deleted - firewalls",
+			f:    testEnsureLoadBalancerDeletedFirewalls,
+		},
+		{
+			name: "Update Load Balancer - firewall node addresses",
+			f:    testUpdateLoadBalancerFirewallNodes,
+		},
+		{
+			name: "Update Load Balancer - backend node selector",
+			f:    testUpdateLoadBalancerBackendNodeSelector,
+		},
+		{
+			name: "Update Load Balancer - throttle and port config",
+			f:    testUpdateLoadBalancerThrottleAndPortConfig,
+		},
+		{
+			name: "Ensure Load Balancer - no ports",
+			f:    testEnsureLoadBalancerNoPorts,
+		},
+		{
+			name: "Update Load Balancer - NodePort changed",
+			f:    testUpdateLoadBalancerNodePortChanged,
+		},
+		{
+			name: "Update Load Balancer - health check interval changed",
+			f:    testUpdateLoadBalancerHealthCheckInterval,
+		},
+		{
+			name: "Update Load Balancer - minimum backend ratio",
+			f:    testUpdateLoadBalancerMinBackendRatio,
+		},
+		{
+			name: "Update Load Balancer - service tags changed",
+			f:    testUpdateLoadBalancerServiceTags,
+		},
+		{
+			name: "Ensure Load Balancer - NodeBalancer annotations",
+			f:    testEnsureLoadBalancerNodeBalancerAnnotations,
+		},
+		{
+			name: "Ensure Load Balancer - config ID annotation",
+			f:    testEnsureLoadBalancerConfigIDAnnotation,
+		},
+		{
+			name: "Update Load Balancer - NotReady node grace period",
+			f:    testUpdateLoadBalancerNotReadyNodeGracePeriod,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -209,7 +328,7 @@ func testCreateNodeBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
+	lb := &loadbalancers{client: client, zone: "us-west"}
 	var nodes []*v1.Node
 	nb, err := lb.buildLoadBalancerRequest(context.TODO(), "linodelb", svc, nodes)
 	if err != nil {
@@ -254,6 +373,74 @@ func testCreateNodeBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
 }
 
+func testCreateNodeBalancerWithNodeBackendIP(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     randString(10),
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+				Annotations: map[string]string{
+					annLinodeNodeBackendIP: "192.168.1.10",
+				},
+			},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.2"}},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	nb, err := lb.buildLoadBalancerRequest(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = client.DeleteNodeBalancer(context.TODO(), nb.ID) }()
+
+	configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nbNodes, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"node-1": "192.168.1.10:30000",
+		"node-2": "127.0.0.2:30000",
+	}
+	actual := make(map[string]string)
+	for _, n := range nbNodes {
+		actual[n.Label] = n.Address
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Error("unexpected backend addresses")
+		t.Logf("expected: %v", expected)
+		t.Logf("actual: %v", actual)
+	}
+}
+
 func testUpdateLoadBalancerAddAnnotation(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -288,7 +475,7 @@ func testUpdateLoadBalancerAddAnnotation(t *testing.T, client *linodego.Client,
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
+	lb := &loadbalancers{client: client, zone: "us-west"}
 	fakeClientset := fake.NewSimpleClientset()
 	lb.kubeClient = fakeClientset
 
@@ -356,7 +543,7 @@ func testUpdateLoadBalancerAddPortAnnotation(t *testing.T, client *linodego.Clie
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
+	lb := &loadbalancers{client: client, zone: "us-west"}
 	fakeClientset := fake.NewSimpleClientset()
 	lb.kubeClient = fakeClientset
 
@@ -402,6 +589,107 @@ func testUpdateLoadBalancerAddPortAnnotation(t *testing.T, client *linodego.Clie
 	}
 }
 
+func testUpdateLoadBalancerAlgorithmStickinessAnnotations(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	defer func(reconcileNodesOnChangeOnly bool) {
+		Options.ReconcileNodesOnChangeOnly = reconcileNodesOnChangeOnly
+	}(Options.ReconcileNodesOnChangeOnly)
+	Options.ReconcileNodesOnChangeOnly = true
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        randString(10),
+			UID:         "foobar123",
+			Annotations: map[string]string{},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     randString(10),
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	nodes := []*v1.Node{
+		{
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{
+						Type:    v1.NodeInternalIP,
+						Address: "127.0.0.1",
+					},
+				},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	defer lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+
+	for _, test := range []struct {
+		annotations map[string]string
+		algorithm   linodego.ConfigAlgorithm
+		stickiness  linodego.ConfigStickiness
+	}{
+		{
+			map[string]string{annLinodeDefaultAlgorithm: "leastconn"},
+			linodego.AlgorithmLeastConn,
+			linodego.StickinessNone,
+		},
+		{
+			map[string]string{annLinodeDefaultAlgorithm: "leastconn", annLinodeDefaultStickiness: "table"},
+			linodego.AlgorithmLeastConn,
+			linodego.StickinessTable,
+		},
+		{
+			map[string]string{annLinodePortConfigPrefix + "80": `{"algorithm": "source"}`},
+			linodego.AlgorithmSource,
+			linodego.StickinessNone,
+		},
+		{
+			map[string]string{},
+			linodego.AlgorithmRoundRobin,
+			linodego.StickinessNone,
+		},
+	} {
+		svc.ObjectMeta.SetAnnotations(test.annotations)
+
+		if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+			t.Fatalf("UpdateLoadBalancer returned an error with annotations %v: %s", test.annotations, err)
+		}
+
+		cfgs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+		if err != nil {
+			t.Fatalf("error getting NodeBalancer configs: %v", err)
+		}
+		if len(cfgs) != 1 {
+			t.Fatalf("expected 1 NodeBalancer config, got %d", len(cfgs))
+		}
+		if cfgs[0].Algorithm != test.algorithm || cfgs[0].Stickiness != test.stickiness {
+			t.Errorf("with annotations %v expected algorithm %q and stickiness %q, got %q and %q",
+				test.annotations, test.algorithm, test.stickiness, cfgs[0].Algorithm, cfgs[0].Stickiness)
+		}
+	}
+}
+
 func testUpdateLoadBalancerAddTLSPort(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -443,7 +731,7 @@ func testUpdateLoadBalancerAddTLSPort(t *testing.T, client *linodego.Client, _ *
 		NodePort: int32(30001),
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
+	lb := &loadbalancers{client: client, zone: "us-west"}
 
 	defer lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
 
@@ -502,82 +790,257 @@ func testUpdateLoadBalancerAddTLSPort(t *testing.T, client *linodego.Client, _ *
 	}
 }
 
-func testUpdateLoadBalancerAddProxyProtocol(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+func testUpdateLoadBalancerReorderedPorts(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "alt", Protocol: "TCP", Port: int32(8080), NodePort: int32(30001)},
+				{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)},
+				{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30002)},
+			},
+		},
+	}
 	nodes := []*v1.Node{
 		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
 			Status: v1.NodeStatus{
-				Addresses: []v1.NodeAddress{
-					{
-						Type:    v1.NodeInternalIP,
-						Address: "127.0.0.1",
-					},
-				},
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.2"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
 			},
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
+	lb := &loadbalancers{client: client, zone: "us-west"}
 	fakeClientset := fake.NewSimpleClientset()
 	lb.kubeClient = fakeClientset
 
-	for _, tc := range []struct {
-		name                string
-		proxyProtocolConfig linodego.ConfigProxyProtocol
-		invalidErr          bool
-	}{
-		{
-			name:                "with invalid Proxy Protocol",
-			proxyProtocolConfig: "bogus",
-			invalidErr:          true,
-		},
-		{
-			name:                "with none",
-			proxyProtocolConfig: linodego.ProxyProtocolNone,
-		},
-		{
-			name:                "with v1",
-			proxyProtocolConfig: linodego.ProxyProtocolV1,
-		},
-		{
-			name:                "with v2",
-			proxyProtocolConfig: linodego.ProxyProtocolV2,
-		},
-	} {
-		t.Run(tc.name, func(t *testing.T) {
-			svc := &v1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:        randString(10),
-					UID:         "foobar123",
-					Annotations: map[string]string{},
-				},
-				Spec: v1.ServiceSpec{
-					Ports: []v1.ServicePort{
-						{
-							Name:     randString(10),
-							Protocol: "tcp",
-							Port:     int32(80),
-							NodePort: int32(8080),
-						},
-					},
-				},
-			}
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
 
-			defer lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
-			nodeBalancer, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
-				Region: lb.zone,
-			})
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 
-			if err != nil {
-				t.Fatalf("failed to create NodeBalancer: %s", err)
-			}
+	reordered := svc.DeepCopy()
+	reordered.Spec.Ports = []v1.ServicePort{svc.Spec.Ports[2], svc.Spec.Ports[1], svc.Spec.Ports[0]}
+	reorderedNodes := []*v1.Node{nodes[1], nodes[0]}
 
-			svc.Status.LoadBalancer = *makeLoadBalancerStatus(nodeBalancer)
-			svc.ObjectMeta.SetAnnotations(map[string]string{
-				annLinodeDefaultProxyProtocol: string(tc.proxyProtocolConfig),
-			})
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", reordered, reorderedNodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	for request := range fakeAPI.requests {
+		if request.Method != http.MethodGet {
+			t.Errorf("expected no changes for reordered ports and nodes, got %s %s", request.Method, request.Path)
+		}
+	}
+}
 
-			stubService(fakeClientset, svc)
-			if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+func testUpdateLoadBalancerUDPPort(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "dns-tcp", Protocol: "TCP", Port: int32(53), NodePort: int32(30000)},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	udp := svc.DeepCopy()
+	udp.Spec.Ports = []v1.ServicePort{
+		{Name: "dns-udp", Protocol: "UDP", Port: int32(53), NodePort: int32(30001)},
+		{Name: "dns-tcp", Protocol: "TCP", Port: int32(5353), NodePort: int32(30002)},
+	}
+	udp.Annotations = map[string]string{annLinodeThrottle: "5"}
+
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", udp, nil); err == nil {
+		t.Fatal("expected UpdateLoadBalancer to reject a UDP port")
+	}
+	for request := range fakeAPI.requests {
+		if request.Method != http.MethodGet {
+			t.Errorf("expected the NodeBalancer to be left unchanged, got %s %s", request.Method, request.Path)
+		}
+	}
+
+	if _, err = lb.buildLoadBalancerRequest(context.TODO(), "linodelb", udp, nil); err == nil {
+		t.Error("expected buildLoadBalancerRequest to reject a UDP port")
+	}
+}
+
+func testUpdateLoadBalancerUnrelatedNodeChange(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	defer func(onChangeOnly bool) { Options.ReconcileNodesOnChangeOnly = onChangeOnly }(Options.ReconcileNodesOnChangeOnly)
+	Options.ReconcileNodesOnChangeOnly = true
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     randString(10),
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+				Addresses: []v1.NodeAddress{
+					{
+						Type:    v1.NodeInternalIP,
+						Address: "127.0.0.1",
+					},
+				},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	labeledNode := nodes[0].DeepCopy()
+	labeledNode.Labels = map[string]string{"unrelated": "label"}
+
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, []*v1.Node{labeledNode}); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if len(fakeAPI.requests) != 0 {
+		t.Errorf("expected no Linode API requests for an unrelated node change, got %v", fakeAPI.requests)
+	}
+
+	notReadyNode := labeledNode.DeepCopy()
+	notReadyNode.Status.Conditions[0].Status = v1.ConditionFalse
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, []*v1.Node{notReadyNode}); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if len(fakeAPI.requests) == 0 {
+		t.Error("expected the NodeBalancer to be updated when a node's readiness changes")
+	}
+}
+
+func testUpdateLoadBalancerAddProxyProtocol(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	nodes := []*v1.Node{
+		{
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{
+						Type:    v1.NodeInternalIP,
+						Address: "127.0.0.1",
+					},
+				},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	for _, tc := range []struct {
+		name                string
+		proxyProtocolConfig linodego.ConfigProxyProtocol
+		invalidErr          bool
+	}{
+		{
+			name:                "with invalid Proxy Protocol",
+			proxyProtocolConfig: "bogus",
+			invalidErr:          true,
+		},
+		{
+			name:                "with none",
+			proxyProtocolConfig: linodego.ProxyProtocolNone,
+		},
+		{
+			name:                "with v1",
+			proxyProtocolConfig: linodego.ProxyProtocolV1,
+		},
+		{
+			name:                "with v2",
+			proxyProtocolConfig: linodego.ProxyProtocolV2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        randString(10),
+					UID:         "foobar123",
+					Annotations: map[string]string{},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     randString(10),
+							Protocol: "tcp",
+							Port:     int32(80),
+							NodePort: int32(8080),
+						},
+					},
+				},
+			}
+
+			defer lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
+			nodeBalancer, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+				Region: lb.zone,
+			})
+
+			if err != nil {
+				t.Fatalf("failed to create NodeBalancer: %s", err)
+			}
+
+			svc.Status.LoadBalancer = *makeLoadBalancerStatus(nodeBalancer)
+			svc.ObjectMeta.SetAnnotations(map[string]string{
+				annLinodeDefaultProxyProtocol: string(tc.proxyProtocolConfig),
+			})
+
+			stubService(fakeClientset, svc)
+			if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
 				expectedErrMessage := fmt.Sprintf("invalid NodeBalancer proxy protocol value '%s'", tc.proxyProtocolConfig)
 				if tc.invalidErr && err.Error() == expectedErrMessage {
 					return
@@ -636,7 +1099,7 @@ func testUpdateLoadBalancerAddNodeBalancerID(t *testing.T, client *linodego.Clie
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
+	lb := &loadbalancers{client: client, zone: "us-west"}
 	defer lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
 
 	fakeClientset := fake.NewSimpleClientset()
@@ -779,7 +1242,7 @@ func Test_getPortConfig(t *testing.T) {
 					UID:  "abc123",
 				},
 			},
-			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
 			nil,
 		},
 		{
@@ -793,7 +1256,7 @@ func Test_getPortConfig(t *testing.T) {
 					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolV2},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolV2, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
 			nil,
 		},
 		{
@@ -808,7 +1271,7 @@ func Test_getPortConfig(t *testing.T) {
 					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolV1},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolV1, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
 			nil,
 		},
 		{
@@ -826,253 +1289,3030 @@ func Test_getPortConfig(t *testing.T) {
 			fmt.Errorf("invalid NodeBalancer proxy protocol value '%s'", "invalid"),
 		},
 		{
-			"default no protocol specified",
+			"default algorithm and stickiness specified",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultAlgorithm:  string(linodego.AlgorithmLeastConn),
+						annLinodeDefaultStickiness: string(linodego.StickinessTable),
+					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone},
-
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmLeastConn, Stickiness: linodego.StickinessTable},
 			nil,
 		},
 		{
-			"default tcp protocol specified",
+			"port specific algorithm and stickiness specified",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodeDefaultProtocol: "tcp",
+						annLinodeDefaultAlgorithm:         string(linodego.AlgorithmLeastConn),
+						annLinodePortConfigPrefix + "443": `{"protocol": "http", "algorithm": "source", "stickiness": "http_cookie"}`,
 					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone},
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmSource, Stickiness: linodego.StickinessHTTPCookie},
 			nil,
 		},
 		{
-			"default capitalized protocol specified",
+			"https inferred from tls-secret-name",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodeDefaultProtocol: "HTTP",
+						annLinodeDefaultProtocol:          "tcp",
+						annLinodePortConfigPrefix + "443": `{"tls-secret-name": "prod-app-tls"}`,
 					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone},
+			portConfig{Port: 443, Protocol: "https", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone, TLSSecretName: "prod-app-tls"},
 			nil,
 		},
 		{
-			"default invalid protocol",
+			"explicit protocol kept with tls-secret-name",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodeDefaultProtocol: "invalid",
+						annLinodePortConfigPrefix + "443": `{"tls-secret-name": "prod-app-tls", "protocol": "http"}`,
 					},
 				},
 			},
-			portConfig{},
-			fmt.Errorf("invalid protocol: %q specified", "invalid"),
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone, TLSSecretName: "prod-app-tls"},
+			nil,
 		},
 		{
-			"port config falls back to default",
+			"invalid algorithm",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodeDefaultProtocol:          "http",
-						annLinodePortConfigPrefix + "443": `{}`,
+						annLinodeDefaultAlgorithm: "random",
 					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone},
-			nil,
+			portConfig{},
+			fmt.Errorf("invalid NodeBalancer algorithm value '%s'", "random"),
 		},
 		{
-			"port config capitalized protocol",
+			"invalid stickiness",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodePortConfigPrefix + "443": `{ "protocol": "HTTp" }`,
+						annLinodeDefaultStickiness: "session",
 					},
 				},
 			},
-			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone},
-			nil,
+			portConfig{},
+			fmt.Errorf("invalid NodeBalancer stickiness value '%s'", "session"),
 		},
 		{
-			"port config invalid protocol",
+			"http_cookie stickiness with tcp port",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
 					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodePortConfigPrefix + "443": `{ "protocol": "invalid" }`,
+						annLinodeDefaultStickiness: string(linodego.StickinessHTTPCookie),
 					},
 				},
 			},
 			portConfig{},
-			fmt.Errorf("invalid protocol: %q specified", "invalid"),
+			fmt.Errorf("NodeBalancer stickiness '%s' cannot be used with protocol %q", linodego.StickinessHTTPCookie, "tcp"),
 		},
-	}
-
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			testPort := 443
-			portConfig, err := getPortConfig(test.service, testPort)
-
-			if !reflect.DeepEqual(portConfig, test.expectedPortConfig) {
-				t.Error("unexpected port config")
-				t.Logf("expected: %q", test.expectedPortConfig)
-				t.Logf("actual: %q", portConfig)
-			}
-
-			if !reflect.DeepEqual(err, test.err) {
-				t.Error("unexpected error")
-				t.Logf("expected: %q", test.err)
-				t.Logf("actual: %q", err)
-			}
-		})
-	}
-}
-
-func Test_getHealthCheckType(t *testing.T) {
-	testcases := []struct {
-		name       string
-		service    *v1.Service
-		healthType linodego.ConfigCheck
-		err        error
-	}{
 		{
-			"no type specified",
+			"preserve source ip with tcp port",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        randString(10),
-					UID:         "abc123",
-					Annotations: map[string]string{},
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePreserveSourceIP: "true",
+					},
 				},
 			},
-			linodego.CheckConnection,
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolV2, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
 			nil,
 		},
 		{
-			"http specified",
+			"preserve source ip with http port",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
+					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodeHealthCheckType: "http",
+						annLinodePreserveSourceIP: "true",
+						annLinodeDefaultProtocol:  "http",
 					},
 				},
 			},
-			linodego.CheckHTTP,
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
 			nil,
 		},
 		{
-			"invalid specified",
+			"preserve source ip with https port",
 			&v1.Service{
 				ObjectMeta: metav1.ObjectMeta{
-					Name: "test",
+					Name: randString(10),
 					UID:  "abc123",
 					Annotations: map[string]string{
-						annLinodeHealthCheckType: "invalid",
+						annLinodePreserveSourceIP:         "true",
+						annLinodePortConfigPrefix + "443": `{"protocol": "https"}`,
 					},
 				},
 			},
-			"",
-			fmt.Errorf("invalid health check type: %q specified in annotation: %q", "invalid", annLinodeHealthCheckType),
+			portConfig{Port: 443, Protocol: "https", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
 		},
-	}
-
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			hType, err := getHealthCheckType(test.service)
-			if !reflect.DeepEqual(hType, test.healthType) {
-				t.Error("unexpected health check type")
-				t.Logf("expected: %v", test.healthType)
-				t.Logf("actual: %v", hType)
-			}
-
-			if !reflect.DeepEqual(err, test.err) {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.err)
-				t.Logf("actual: %v", err)
-			}
-		})
-	}
-}
-
-func Test_getNodeInternalIP(t *testing.T) {
-	testcases := []struct {
-		name    string
-		node    *v1.Node
-		address string
-	}{
 		{
-			"node internal ip specified",
-			&v1.Node{
-				Status: v1.NodeStatus{
-					Addresses: []v1.NodeAddress{
-						{
-							Type:    v1.NodeInternalIP,
-							Address: "127.0.0.1",
-						},
+			"preserve source ip keeps explicit proxy protocol",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePreserveSourceIP:     "true",
+						annLinodeDefaultProxyProtocol: string(linodego.ProxyProtocolV1),
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolV1, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"preserve source ip disabled",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePreserveSourceIP: "false",
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"preserve source ip with proxy protocol none",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePreserveSourceIP:         "true",
+						annLinodePortConfigPrefix + "443": `{"proxy-protocol": "none"}`,
+					},
+				},
+			},
+			portConfig{},
+			fmt.Errorf("NodeBalancer proxy protocol '%s' cannot be used with %s, which needs PROXY protocol for protocol %q", linodego.ProxyProtocolNone, annLinodePreserveSourceIP, "tcp"),
+		},
+		{
+			"invalid preserve source ip",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePreserveSourceIP: "yes please",
+					},
+				},
+			},
+			portConfig{},
+			fmt.Errorf("invalid value %q for annotation %s, must be true or false", "yes please", annLinodePreserveSourceIP),
+		},
+		{
+			"default no protocol specified",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+				},
+			},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+
+			nil,
+		},
+		{
+			"default tcp protocol specified",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol: "tcp",
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"default capitalized protocol specified",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol: "HTTP",
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"default invalid protocol",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol: "invalid",
+					},
+				},
+			},
+			portConfig{},
+			fmt.Errorf("invalid protocol: %q specified", "invalid"),
+		},
+		{
+			"port config falls back to default",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol:          "http",
+						annLinodePortConfigPrefix + "443": `{}`,
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"port config capitalized protocol",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePortConfigPrefix + "443": `{ "protocol": "HTTp" }`,
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"port config invalid protocol",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePortConfigPrefix + "443": `{ "protocol": "invalid" }`,
+					},
+				},
+			},
+			portConfig{},
+			fmt.Errorf("invalid protocol: %q specified", "invalid"),
+		},
+		{
+			"default proxy protocol with http port",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultProxyProtocol:     string(linodego.ProxyProtocolV2),
+						annLinodePortConfigPrefix + "443": `{ "protocol": "http" }`,
+					},
+				},
+			},
+			portConfig{},
+			fmt.Errorf("NodeBalancer proxy protocol '%s' cannot be used with protocol %q, only with %q", linodego.ProxyProtocolV2, "http", "tcp"),
+		},
+		{
+			"port proxy protocol with https port",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodePortConfigPrefix + "443": `{ "protocol": "https", "proxy-protocol": "v1" }`,
+					},
+				},
+			},
+			portConfig{},
+			fmt.Errorf("NodeBalancer proxy protocol '%s' cannot be used with protocol %q, only with %q", linodego.ProxyProtocolV1, "https", "tcp"),
+		},
+		{
+			"proxy protocol none with http port",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol:      "http",
+						annLinodeDefaultProxyProtocol: string(linodego.ProxyProtocolNone),
+					},
+				},
+			},
+			portConfig{Port: 443, Protocol: "http", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+		{
+			"ClientIP session affinity",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+				},
+				Spec: v1.ServiceSpec{
+					SessionAffinity: v1.ServiceAffinityClientIP,
+				},
+			},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessTable},
+			nil,
+		},
+		{
+			"ClientIP session affinity with stickiness annotation",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeDefaultStickiness: string(linodego.StickinessNone),
 					},
 				},
+				Spec: v1.ServiceSpec{
+					SessionAffinity: v1.ServiceAffinityClientIP,
+				},
+			},
+			portConfig{Port: 443, Protocol: "tcp", ProxyProtocol: linodego.ProxyProtocolNone, Algorithm: linodego.AlgorithmRoundRobin, Stickiness: linodego.StickinessNone},
+			nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			testPort := 443
+			portConfig, err := getPortConfig(test.service, testPort)
+
+			if !reflect.DeepEqual(portConfig, test.expectedPortConfig) {
+				t.Error("unexpected port config")
+				t.Logf("expected: %v", test.expectedPortConfig)
+				t.Logf("actual: %v", portConfig)
+			}
+
+			if !reflect.DeepEqual(err, test.err) {
+				t.Error("unexpected error")
+				t.Logf("expected: %q", test.err)
+				t.Logf("actual: %q", err)
+			}
+		})
+	}
+}
+
+func Test_getHealthCheckType(t *testing.T) {
+	testcases := []struct {
+		name       string
+		service    *v1.Service
+		healthType linodego.ConfigCheck
+		err        error
+	}{
+		{
+			"no type specified",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        randString(10),
+					UID:         "abc123",
+					Annotations: map[string]string{},
+				},
+			},
+			linodego.CheckConnection,
+			nil,
+		},
+		{
+			"http specified",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeHealthCheckType: "http",
+					},
+				},
+			},
+			linodego.CheckHTTP,
+			nil,
+		},
+		{
+			"invalid specified",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "test",
+					UID:  "abc123",
+					Annotations: map[string]string{
+						annLinodeHealthCheckType: "invalid",
+					},
+				},
+			},
+			"",
+			fmt.Errorf("invalid health check type: %q specified in annotation: %q", "invalid", annLinodeHealthCheckType),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			hType, err := getHealthCheckType(test.service)
+			if !reflect.DeepEqual(hType, test.healthType) {
+				t.Error("unexpected health check type")
+				t.Logf("expected: %v", test.healthType)
+				t.Logf("actual: %v", hType)
+			}
+
+			if !reflect.DeepEqual(err, test.err) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.err)
+				t.Logf("actual: %v", err)
+			}
+		})
+	}
+}
+
+func TestEnsureLoadBalancerTransientError(t *testing.T) {
+	defer func(gracePeriod, interval time.Duration) {
+		Options.TransientErrorGracePeriod = gracePeriod
+		transientErrorRetryInterval = interval
+	}(Options.TransientErrorGracePeriod, transientErrorRetryInterval)
+	transientErrorRetryInterval = 10 * time.Millisecond
+
+	newService := func() *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randString(10),
+				UID:  "foobar123",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{
+					{
+						Name:     "http",
+						Protocol: "TCP",
+						Port:     int32(80),
+						NodePort: int32(30000),
+					},
+				},
+			},
+		}
+	}
+
+	for _, test := range []struct {
+		name        string
+		gracePeriod time.Duration
+		failures    int
+		expectErr   bool
+	}{
+		{"retried within grace period", time.Second, 1, false},
+		{"grace period disabled", 0, 1, true},
+		{"grace period exceeded", 50 * time.Millisecond, 100, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			Options.TransientErrorGracePeriod = test.gracePeriod
+
+			fake := newFake(t)
+			failures := test.failures
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/nodebalancers") && failures > 0 {
+					failures--
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					_, _ = w.Write([]byte(`{"errors": [{"reason": "Internal Server Error"}]}`))
+					return
+				}
+				fake.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			client := linodego.NewClient(http.DefaultClient)
+			client.SetBaseURL(ts.URL)
+
+			lb := &loadbalancers{client: &client, zone: "us-west"}
+			lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", newService(), nil)
+			if test.expectErr {
+				if err == nil {
+					t.Fatal("expected EnsureLoadBalancer to return an error")
+				}
+				if len(fake.nb) != 0 {
+					t.Errorf("expected no NodeBalancer to be created, found %d", len(fake.nb))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+			}
+			if len(lbStatus.Ingress) != 1 || len(fake.nb) != 1 {
+				t.Errorf("expected a single NodeBalancer to be created, found %d", len(fake.nb))
+			}
+		})
+	}
+}
+
+func TestEnsureLoadBalancerDeletedRetry(t *testing.T) {
+	defer func(interval time.Duration) { transientErrorRetryInterval = interval }(transientErrorRetryInterval)
+	transientErrorRetryInterval = 10 * time.Millisecond
+
+	for _, test := range []struct {
+		name      string
+		failures  int
+		expectErr bool
+	}{
+		{"retried after a transient error", 1, false},
+		{"deletion cannot be confirmed", maxNodeBalancerDeleteAttempts, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFake(t)
+			failures := test.failures
+			deletes := 0
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/nodebalancers/") {
+					deletes++
+					if failures > 0 {
+						failures--
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(http.StatusInternalServerError)
+						_, _ = w.Write([]byte(`{"errors": [{"reason": "Internal Server Error"}]}`))
+						return
+					}
+				}
+				fake.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			client := linodego.NewClient(http.DefaultClient)
+			client.SetBaseURL(ts.URL)
+			lb := &loadbalancers{client: &client, zone: "us-west"}
+
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "foobar123",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     int32(80),
+							NodePort: int32(30000),
+						},
+					},
+				},
+			}
+			status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			if err != nil {
+				t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+			}
+			svc.Status.LoadBalancer = *status
+
+			err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
+			if test.expectErr {
+				if err == nil || !strings.Contains(err.Error(), "could not confirm") {
+					t.Fatalf("expected an error confirming deletion, got %v", err)
+				}
+				if deletes != maxNodeBalancerDeleteAttempts {
+					t.Errorf("expected %d delete attempts, got %d", maxNodeBalancerDeleteAttempts, deletes)
+				}
+				if len(fake.nb) != 1 {
+					t.Errorf("expected the NodeBalancer to remain, found %d", len(fake.nb))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+			}
+			if deletes != test.failures+1 {
+				t.Errorf("expected %d delete attempts, got %d", test.failures+1, deletes)
+			}
+			if len(fake.nb) != 0 {
+				t.Errorf("expected the NodeBalancer to be deleted, found %d", len(fake.nb))
+			}
+		})
+	}
+}
+
+func TestEnsureLoadBalancerDeprecatedAnnotationPrefix(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeThrottle: "10",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+	}
+
+	var deprecationEvents []string
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, eventReasonDeprecatedAnnotationPrefix) {
+			deprecationEvents = append(deprecationEvents, event)
+		}
+	}
+	if len(deprecationEvents) != 1 {
+		t.Fatalf("expected a single %s event, got %v", eventReasonDeprecatedAnnotationPrefix, deprecationEvents)
+	}
+	if !strings.Contains(deprecationEvents[0], annLinodeThrottle) {
+		t.Errorf("expected the %s event to name %s, got %q", eventReasonDeprecatedAnnotationPrefix, annLinodeThrottle, deprecationEvents[0])
+	}
+	if throttle := getConnectionThrottle(svc); throttle != 10 {
+		t.Errorf("expected the beta annotation to still be honored, got throttle %d", throttle)
+	}
+
+	gaThrottle, _ := gaAnnotationName(annLinodeThrottle)
+	svc.Annotations[gaThrottle] = "5"
+	if throttle := getConnectionThrottle(svc); throttle != 5 {
+		t.Errorf("expected the GA annotation to take precedence, got throttle %d", throttle)
+	}
+}
+
+func TestEnsureLoadBalancerNamedTargetPort(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       int32(80),
+					TargetPort: intstr.FromString("web"),
+					NodePort:   int32(30000),
+				},
+				{
+					Name:       "metrics",
+					Protocol:   "TCP",
+					Port:       int32(9090),
+					TargetPort: intstr.FromInt(9090),
+					NodePort:   int32(30001),
+				},
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+	}
+
+	var routingEvents []string
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, eventReasonNodePortRouting) {
+			routingEvents = append(routingEvents, event)
+		}
+	}
+	if len(routingEvents) != 1 {
+		t.Fatalf("expected a single %s event, got %v", eventReasonNodePortRouting, routingEvents)
+	}
+	if event := routingEvents[0]; !strings.HasPrefix(event, v1.EventTypeNormal) || !strings.Contains(event, `80 (targetPort "web")`) ||
+		!strings.Contains(event, "NodePort") || strings.Contains(event, "9090") {
+		t.Errorf("unexpected %s event %q", eventReasonNodePortRouting, event)
+	}
+}
+
+func TestUpdateLoadBalancerConcurrentDelete(t *testing.T) {
+	fakeAPI := newFake(t)
+	var lb *loadbalancers
+	var svc *v1.Service
+	deleteOnRebuild := false
+	rebuilds := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/rebuild") {
+			rebuilds++
+			if deleteOnRebuild {
+				// the service is deleted while its first config is being rebuilt
+				deleteOnRebuild = false
+				if err := lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+					t.Errorf("EnsureLoadBalancerDeleted returned an error: %s", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors": [{"reason": "Not found"}]}`))
+				return
+			}
+		}
+		fakeAPI.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	fakeClientset := fake.NewSimpleClientset()
+	lb = &loadbalancers{client: &client, zone: "us-west", kubeClient: fakeClientset}
+
+	svc = &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)},
+				{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30001)},
+			},
+		},
+	}
+	newNode := func(name, address string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: address}},
+			},
+		}
+	}
+	nodes := []*v1.Node{newNode("node-1", "127.0.0.1")}
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+	nodes = append(nodes, newNode("node-2", "127.0.0.2"))
+
+	t.Run("service deleting", func(t *testing.T) {
+		lb.states.update(getServiceNn(svc), func(state *serviceState) { state.deleting = true })
+		defer lb.states.update(getServiceNn(svc), func(state *serviceState) { state.deleting = false })
+
+		fakeAPI.requests = make(map[fakeRequest]struct{})
+		if err := lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+			t.Fatalf("expected UpdateLoadBalancer to abort without an error, got %s", err)
+		}
+		if len(fakeAPI.requests) != 0 {
+			t.Errorf("expected no API requests for a service being deleted, got %d", len(fakeAPI.requests))
+		}
+	})
+
+	t.Run("service has a deletion timestamp", func(t *testing.T) {
+		deleted := svc.DeepCopy()
+		now := metav1.Now()
+		deleted.DeletionTimestamp = &now
+		if _, err := fakeClientset.CoreV1().Services("").Update(context.TODO(), deleted, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if _, err := fakeClientset.CoreV1().Services("").Update(context.TODO(), svc, metav1.UpdateOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}()
+
+		fakeAPI.requests = make(map[fakeRequest]struct{})
+		if err := lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+			t.Fatalf("expected UpdateLoadBalancer to abort without an error, got %s", err)
+		}
+		if len(fakeAPI.requests) != 0 {
+			t.Errorf("expected no API requests for a deleted service, got %d", len(fakeAPI.requests))
+		}
+	})
+
+	t.Run("NodeBalancer deleted during the update", func(t *testing.T) {
+		deleteOnRebuild = true
+		if err := lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+			t.Fatalf("expected UpdateLoadBalancer to abort without an error, got %s", err)
+		}
+		if rebuilds != 1 {
+			t.Errorf("expected the update to stop after the first config, got %d rebuilds", rebuilds)
+		}
+		nbs, err := client.ListNodeBalancers(context.TODO(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(nbs) != 0 || len(fakeAPI.nbc) != 0 || len(fakeAPI.nbn) != 0 {
+			t.Errorf("expected nothing to be left of the deleted NodeBalancer, got %d NodeBalancers, %d configs and %d nodes", len(nbs), len(fakeAPI.nbc), len(fakeAPI.nbn))
+		}
+		if state := lb.states.get(getServiceNn(svc)); state.deleting || state.nodeSnapshot != "" {
+			t.Errorf("expected the deleted service's state to be forgotten, got %+v", state)
+		}
+	})
+}
+
+func TestEnsureLoadBalancerRegionFallback(t *testing.T) {
+	defer func(regions []string, gracePeriod time.Duration) {
+		Options.FallbackRegions = regions
+		Options.TransientErrorGracePeriod = gracePeriod
+	}(Options.FallbackRegions, Options.TransientErrorGracePeriod)
+	Options.TransientErrorGracePeriod = 0
+
+	for _, test := range []struct {
+		name            string
+		fallbackRegions []string
+		status          int
+		reason          string
+		region          string
+	}{
+		{"fallback on capacity error", []string{"us-central", "us-east"}, http.StatusBadRequest, "Insufficient capacity in region", "us-east"},
+		{"no fallback regions", nil, http.StatusBadRequest, "Insufficient capacity in region", ""},
+		{"all regions at capacity", []string{"us-central"}, http.StatusBadRequest, "Insufficient capacity in region", ""},
+		{"no fallback on other errors", []string{"us-east"}, http.StatusBadRequest, "Label is invalid", ""},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			Options.FallbackRegions = test.fallbackRegions
+
+			fake := newFake(t)
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/nodebalancers") {
+					body, _ := ioutil.ReadAll(r.Body)
+					r.Body = ioutil.NopCloser(bytes.NewReader(body))
+					if !strings.Contains(string(body), `"region":"us-east"`) {
+						w.Header().Set("Content-Type", "application/json")
+						w.WriteHeader(test.status)
+						_, _ = fmt.Fprintf(w, `{"errors": [{"reason": %q}]}`, test.reason)
+						return
+					}
+				}
+				fake.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			client := linodego.NewClient(http.DefaultClient)
+			client.SetBaseURL(ts.URL)
+
+			recorder := record.NewFakeRecorder(10)
+			lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "foobar123",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     int32(80),
+							NodePort: int32(30000),
+						},
+					},
+				},
+			}
+
+			_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			if test.region == "" {
+				if err == nil || !strings.Contains(err.Error(), test.reason) {
+					t.Fatalf("expected EnsureLoadBalancer to fail with %q, got %v", test.reason, err)
+				}
+				if len(fake.nb) != 0 {
+					t.Errorf("expected no NodeBalancer to be created, found %d", len(fake.nb))
+				}
+
+				capacityErr, atCapacity := err.(regionCapacityError)
+				if isCapacity := strings.Contains(test.reason, "capacity"); atCapacity != isCapacity {
+					t.Fatalf("expected a regionCapacityError to be %t, got %T: %s", isCapacity, err, err)
+				}
+				if !atCapacity {
+					return
+				}
+				regions := append([]string{"us-west"}, test.fallbackRegions...)
+				if !reflect.DeepEqual(capacityErr.regions, regions) {
+					t.Errorf("expected the error to name regions %v, got %v", regions, capacityErr.regions)
+				}
+				if linodeErr, ok := capacityErr.err.(*linodego.Error); !ok || linodeErr.Code != test.status {
+					t.Errorf("expected the Linode API error to be wrapped, got %v", err)
+				}
+				select {
+				case event := <-recorder.Events:
+					if !strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonRegionAtCapacity) || !strings.Contains(event, strings.Join(regions, ", ")) {
+						t.Errorf("unexpected event %q", event)
+					}
+				default:
+					t.Errorf("expected a %s event", eventReasonRegionAtCapacity)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+			}
+			if len(fake.nb) != 1 {
+				t.Fatalf("expected a single NodeBalancer to be created, found %d", len(fake.nb))
+			}
+			for _, nb := range fake.nb {
+				if nb.Region != test.region {
+					t.Errorf("expected the NodeBalancer to be created in %s, got %s", test.region, nb.Region)
+				}
+				if lb.shouldRecreateInRegion(svc, nb) {
+					t.Error("expected a NodeBalancer in a fallback region not to be recreated")
+				}
+			}
+
+			var fallbackEvent string
+			for len(recorder.Events) > 0 {
+				if event := <-recorder.Events; strings.Contains(event, eventReasonRegionFallback) {
+					fallbackEvent = event
+				}
+			}
+			if !strings.Contains(fallbackEvent, test.region) {
+				t.Errorf("expected a %s event naming region %s, got %q", eventReasonRegionFallback, test.region, fallbackEvent)
+			}
+		})
+	}
+}
+
+func TestEnsureLoadBalancerDuplicateNodeBalancers(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeSkipStatusUpdate: "true",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	label := lb.GetLoadBalancerName(context.TODO(), "linodelb", svc)
+	var oldest *linodego.NodeBalancer
+	for i := 0; i < 3; i++ {
+		nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+			Label:  &label,
+			Region: "us-west",
+			Tags:   []string{makeClusterTag("linodelb")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if oldest == nil || nb.ID < oldest.ID {
+			oldest = nb
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		nb, err := lb.getNodeBalancerForService(context.TODO(), "linodelb", svc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nb.ID != oldest.ID {
+			t.Errorf("expected the oldest NodeBalancer (%d) to be chosen, got %d", oldest.ID, nb.ID)
+		}
+	}
+
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	for _, config := range fakeAPI.nbc {
+		if config.NodeBalancerID != oldest.ID {
+			t.Errorf("expected only NodeBalancer (%d) to be reconciled, got a config on %d", oldest.ID, config.NodeBalancerID)
+		}
+	}
+	if len(fakeAPI.nbc) != 1 {
+		t.Errorf("expected a single config, found %d", len(fakeAPI.nbc))
+	}
+	if len(fakeAPI.nb) != 3 {
+		t.Errorf("expected the duplicates to be left for an operator, found %d NodeBalancers", len(fakeAPI.nb))
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonDuplicateNodeBalancers) || !strings.Contains(event, fmt.Sprintf("oldest (%d)", oldest.ID)) {
+			t.Errorf("unexpected event %q", event)
+		}
+	default:
+		t.Error("expected a DuplicateNodeBalancers event")
+	}
+}
+
+func TestEnsureLoadBalancerForeignNodeBalancerLabel(t *testing.T) {
+	for name, tags := range map[string][]string{
+		"not tagged for the cluster": nil,
+		"owned by another service":   {makeClusterTag("linodelb"), "ccm:80:managed-by=linode-ccm", "ccm:80:owner=0123456789abcdef"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fakeAPI := newFake(t)
+			ts := httptest.NewServer(fakeAPI)
+			defer ts.Close()
+
+			client := linodego.NewClient(http.DefaultClient)
+			client.SetBaseURL(ts.URL)
+
+			lb := &loadbalancers{client: &client, zone: "us-west"}
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "foobar123",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}},
+				},
+			}
+
+			label := lb.GetLoadBalancerName(context.TODO(), "linodelb", svc)
+			foreign, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Label: &label, Region: "us-west", Tags: tags})
+			if err != nil {
+				t.Fatal(err)
+			}
+			passive := false
+			config, err := client.CreateNodeBalancerConfig(context.TODO(), foreign.ID, linodego.NodeBalancerConfigCreateOptions{Port: 80, Protocol: linodego.ProtocolHTTP, CheckPassive: &passive})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			if _, ok := err.(nodeBalancerLabelTakenError); !ok {
+				t.Fatalf("expected a nodeBalancerLabelTakenError, got %v", err)
+			}
+
+			if len(fakeAPI.nb) != 1 {
+				t.Errorf("expected no NodeBalancer to be created, found %d", len(fakeAPI.nb))
+			}
+			current, err := client.GetNodeBalancer(context.TODO(), foreign.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(current.Tags, foreign.Tags) {
+				t.Errorf("expected the foreign NodeBalancer's tags to be left alone, got %v", current.Tags)
+			}
+			configs, err := client.ListNodeBalancerConfigs(context.TODO(), foreign.ID, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(configs) != 1 || configs[0].ID != config.ID || configs[0].Protocol != linodego.ProtocolHTTP {
+				t.Errorf("expected the foreign NodeBalancer's config to be left alone, got %+v", configs)
+			}
+		})
+	}
+}
+
+func TestEnsureLoadBalancerDuplicateNodeBalancersWithStatus(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	label := lb.GetLoadBalancerName(context.TODO(), "linodelb", svc)
+	var created []*linodego.NodeBalancer
+	for i := 0; i < 2; i++ {
+		nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+			Label:  &label,
+			Region: "us-west",
+			Tags:   []string{makeClusterTag("linodelb")},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		created = append(created, nb)
+	}
+	oldest, newest := created[0], created[1]
+	if newest.ID < oldest.ID {
+		oldest, newest = newest, oldest
+	}
+	duplicateEvents := func() []string {
+		var events []string
+		for {
+			select {
+			case event := <-recorder.Events:
+				if strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonDuplicateNodeBalancers) {
+					events = append(events, event)
+				}
+			default:
+				return events
+			}
+		}
+	}
+
+	// Without a status, the oldest NodeBalancer labeled for the service is reconciled
+	// onto rather than another being created
+	status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	if len(fakeAPI.nb) != 2 {
+		t.Errorf("expected no NodeBalancer to be created, found %d", len(fakeAPI.nb))
+	}
+	if len(status.Ingress) != 1 || status.Ingress[0].IP != *oldest.IPv4 {
+		t.Errorf("expected the oldest NodeBalancer (%d) to be used, got %v", oldest.ID, status.Ingress)
+	}
+	if events := duplicateEvents(); len(events) != 1 || !strings.Contains(events[0], fmt.Sprintf("the oldest (%d)", oldest.ID)) {
+		t.Errorf("expected a %s event naming the oldest NodeBalancer, got %v", eventReasonDuplicateNodeBalancers, events)
+	}
+
+	// With a status, the NodeBalancer in it is kept and its duplicates are reported once
+	svc.Status.LoadBalancer = *makeLoadBalancerStatus(newest)
+	for i := 0; i < 2; i++ {
+		if status, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+		if len(status.Ingress) != 1 || status.Ingress[0].IP != *newest.IPv4 {
+			t.Errorf("expected the NodeBalancer (%d) in the status to be kept, got %v", newest.ID, status.Ingress)
+		}
+	}
+	events := duplicateEvents()
+	if len(events) != 1 || !strings.Contains(events[0], fmt.Sprintf("the one in the service's status (%d)", newest.ID)) ||
+		!strings.Contains(events[0], fmt.Sprintf("duplicates (%d)", oldest.ID)) {
+		t.Errorf("expected a single %s event naming the duplicate, got %v", eventReasonDuplicateNodeBalancers, events)
+	}
+}
+
+func TestValidateIPPoll(t *testing.T) {
+	if err := validateIPPoll(30*time.Second, 2*time.Second); err != nil {
+		t.Errorf("expected the defaults to be accepted, got %s", err)
+	}
+	for _, poll := range [][2]time.Duration{{0, time.Second}, {-time.Second, time.Second}, {time.Second, 0}, {time.Second, -time.Second}} {
+		if err := validateIPPoll(poll[0], poll[1]); err == nil {
+			t.Errorf("expected a timeout of %s and an interval of %s to be rejected", poll[0], poll[1])
+		}
+	}
+}
+
+func TestEnsureLoadBalancerIPPoll(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		Options.IPPollTimeout = timeout
+		Options.IPPollInterval = interval
+	}(Options.IPPollTimeout, Options.IPPollInterval)
+	Options.IPPollTimeout = 200 * time.Millisecond
+	Options.IPPollInterval = 10 * time.Millisecond
+
+	for _, test := range []struct {
+		name          string
+		pendingPolls  int
+		expectTimeout bool
+	}{
+		{"assigned while polling", 3, false},
+		{"never assigned", -1, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			fakeAPI := newFake(t)
+			polls := 0
+			// withoutIP strips the IPv4 address from the fake's NodeBalancer responses until
+			// the NodeBalancer has been polled pendingPolls times.
+			withoutIP := func(w http.ResponseWriter, r *http.Request) {
+				rec := httptest.NewRecorder()
+				fakeAPI.ServeHTTP(rec, r)
+				var nb map[string]interface{}
+				if err := json.Unmarshal(rec.Body.Bytes(), &nb); err != nil {
+					t.Fatal(err)
+				}
+				if r.Method == http.MethodGet {
+					polls++
+				}
+				if test.pendingPolls < 0 || polls <= test.pendingPolls {
+					nb["ipv4"] = nil
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(nb)
+			}
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if (r.Method == http.MethodPost && r.URL.Path == "/nodebalancers") ||
+					(r.Method == http.MethodGet && regexp.MustCompile(`^/nodebalancers/[0-9]+$`).MatchString(r.URL.Path)) {
+					withoutIP(w, r)
+					return
+				}
+				fakeAPI.ServeHTTP(w, r)
+			}))
+			defer ts.Close()
+
+			client := linodego.NewClient(http.DefaultClient)
+			client.SetBaseURL(ts.URL)
+			lb := &loadbalancers{client: &client, zone: "us-west"}
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "foobar123",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     int32(80),
+							NodePort: int32(30000),
+						},
+					},
+				},
+			}
+
+			start := time.Now()
+			status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			elapsed := time.Since(start)
+
+			if test.expectTimeout {
+				if err == nil || !strings.Contains(err.Error(), "was not assigned an IPv4 address within 200ms") {
+					t.Fatalf("expected EnsureLoadBalancer to time out, got %v", err)
+				}
+				if elapsed < Options.IPPollTimeout || elapsed > 5*Options.IPPollTimeout {
+					t.Errorf("expected the poll to give up after %s, took %s", Options.IPPollTimeout, elapsed)
+				}
+				if len(fakeAPI.nb) != 0 {
+					t.Errorf("expected the NodeBalancer without an address to be deleted, found %d", len(fakeAPI.nb))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+			}
+			if len(status.Ingress) != 1 || status.Ingress[0].IP == "" {
+				t.Errorf("expected an ingress IP, got %v", status.Ingress)
+			}
+			if polls != test.pendingPolls+1 {
+				t.Errorf("expected %d polls, got %d", test.pendingPolls+1, polls)
+			}
+		})
+	}
+}
+
+func Test_getNodeInternalIP(t *testing.T) {
+	testcases := []struct {
+		name    string
+		node    *v1.Node
+		address string
+	}{
+		{
+			"node internal ip specified",
+			&v1.Node{
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{
+							Type:    v1.NodeInternalIP,
+							Address: "127.0.0.1",
+						},
+					},
+				},
+			},
+			"127.0.0.1",
+		},
+		{
+			"node internal ip not specified",
+			&v1.Node{
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{
+						{
+							Type:    v1.NodeExternalIP,
+							Address: "127.0.0.1",
+						},
+					},
+				},
+			},
+			"",
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			ip := getNodeInternalIP(test.node)
+			if ip != test.address {
+				t.Error("unexpected certificate")
+				t.Logf("expected: %q", test.address)
+				t.Logf("actual: %q", ip)
+			}
+		})
+	}
+
+}
+
+func Test_checkNodePorts(t *testing.T) {
+	defer func(portRange utilnet.PortRange, reject bool) {
+		Options.NodePortRange = portRange
+		Options.RejectOutOfRangeNodePorts = reject
+	}(Options.NodePortRange, Options.RejectOutOfRangeNodePorts)
+
+	newService := func(nodePorts ...int32) *v1.Service {
+		svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+		for i, nodePort := range nodePorts {
+			svc.Spec.Ports = append(svc.Spec.Ports, v1.ServicePort{Protocol: "TCP", Port: int32(80 + i), NodePort: nodePort})
+		}
+		return svc
+	}
+
+	testcases := []struct {
+		name      string
+		portRange string
+		reject    bool
+		service   *v1.Service
+		wantErr   bool
+		wantEvent bool
+	}{
+		{"no range configured", "", true, newService(80), false, false},
+		{"in range", "30000-32767", true, newService(30000, 32767), false, false},
+		{"unallocated", "30000-32767", true, newService(0), false, false},
+		{"out of range warns", "30000-32767", false, newService(30000, 8080), false, true},
+		{"out of range rejected", "30000-32767", true, newService(30000, 32768), true, false},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			Options.NodePortRange = utilnet.PortRange{}
+			if err := Options.NodePortRange.Set(test.portRange); err != nil {
+				t.Fatal(err)
+			}
+			Options.RejectOutOfRangeNodePorts = test.reject
+
+			recorder := record.NewFakeRecorder(10)
+			lb := &loadbalancers{recorder: recorder}
+			err := lb.checkNodePorts(test.service)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expected error %t, got %v", test.wantErr, err)
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if !test.wantEvent {
+					t.Errorf("unexpected event %q", event)
+				} else if !strings.Contains(event, eventReasonNodePortOutOfRange) || !strings.Contains(event, "8080") {
+					t.Errorf("expected a %s event naming the NodePort, got %q", eventReasonNodePortOutOfRange, event)
+				}
+			default:
+				if test.wantEvent {
+					t.Error("expected an event")
+				}
+			}
+		})
+	}
+
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	Options.NodePortRange = utilnet.PortRange{Base: 30000, Size: 2768}
+	Options.RejectOutOfRangeNodePorts = true
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", newService(8080), nil); err == nil {
+		t.Error("expected EnsureLoadBalancer to reject a NodePort outside the range")
+	}
+	if len(fake.nb) != 0 {
+		t.Errorf("expected no NodeBalancer to be created, found %d", len(fake.nb))
+	}
+}
+
+func Test_GetLoadBalancerName(t *testing.T) {
+	lb := &loadbalancers{}
+	labelRegexp := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+	newService := func(namespace, name string, uid types.UID) *v1.Service {
+		return &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: uid}}
+	}
+
+	first := lb.GetLoadBalancerName(context.TODO(), "linodelb", newService("default", "web", "uid-1"))
+	second := lb.GetLoadBalancerName(context.TODO(), "linodelb", newService("staging", "web", "uid-2"))
+	if first == second {
+		t.Errorf("expected distinct labels for services in different namespaces, both got %q", first)
+	}
+	if !strings.HasPrefix(first, "ccm-default-web-") {
+		t.Errorf("expected label to be recognizable from the service, got %q", first)
+	}
+	if again := lb.GetLoadBalancerName(context.TODO(), "linodelb", newService("default", "web", "uid-1")); again != first {
+		t.Errorf("expected label to be stable, got %q and %q", first, again)
+	}
+
+	longName := strings.Repeat("very.long_Service-Name", 5)
+	truncatedA := lb.GetLoadBalancerName(context.TODO(), "linodelb", newService("default", longName+"a", "uid-3"))
+	truncatedB := lb.GetLoadBalancerName(context.TODO(), "linodelb", newService("default", longName+"b", "uid-4"))
+	if truncatedA == truncatedB {
+		t.Errorf("expected distinct labels for services whose names collide once truncated, both got %q", truncatedA)
+	}
+
+	for _, label := range []string{first, second, truncatedA, truncatedB} {
+		if len(label) > nodeBalancerLabelMaxLength {
+			t.Errorf("label %q exceeds %d characters", label, nodeBalancerLabelMaxLength)
+		}
+		if !labelRegexp.MatchString(label) {
+			t.Errorf("label %q contains invalid characters", label)
+		}
+	}
+}
+
+func Test_makeNodeSnapshot(t *testing.T) {
+	defer func() { defaultAnnotations = annotationDefaults{} }()
+	defaultAnnotations = annotationDefaults{clusterNamespace: "kube-system"}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Protocol: "TCP", Port: 80, NodePort: 30080}},
+		},
+	}
+	nodes := []*v1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}}}
+	snapshot := makeNodeSnapshot(svc, nodes)
+
+	affinity := svc.DeepCopy()
+	affinity.Spec.SessionAffinity = v1.ServiceAffinityClientIP
+	if makeNodeSnapshot(affinity, nodes) == snapshot {
+		t.Error("expected a session affinity change to change the snapshot")
+	}
+
+	trafficPolicy := svc.DeepCopy()
+	trafficPolicy.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeLocal
+	if makeNodeSnapshot(trafficPolicy, nodes) == snapshot {
+		t.Error("expected an external traffic policy change to change the snapshot")
+	}
+
+	defaultAnnotations.set("kube-system", map[string]string{annLinodeThrottle: "10"})
+	if makeNodeSnapshot(svc, nodes) == snapshot {
+		t.Error("expected a default annotation change to change the snapshot")
+	}
+}
+
+func Test_selectBackendNodes(t *testing.T) {
+	svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+
+	makeNode := func(name string, ready, unschedulable bool) *v1.Node {
+		status := v1.ConditionTrue
+		if !ready {
+			status = v1.ConditionFalse
+		}
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       v1.NodeSpec{Unschedulable: unschedulable},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+			},
+		}
+	}
+
+	t.Run("below the cap", func(t *testing.T) {
+		nodes := []*v1.Node{makeNode("node-1", false, false), makeNode("node-2", true, false)}
+		selected := selectBackendNodes(svc, nodes)
+		if !reflect.DeepEqual(selected, nodes) {
+			t.Errorf("expected nodes to be returned unchanged, got %v", selected)
+		}
+	})
+
+	t.Run("exceeding the cap", func(t *testing.T) {
+		var nodes []*v1.Node
+		for i := maxNodeBalancerConfigNodes + 4; i >= 2; i-- {
+			nodes = append(nodes, makeNode(fmt.Sprintf("node-%03d", i), true, false))
+		}
+		nodes = append(nodes, makeNode("node-001", true, true), makeNode("node-000", false, false))
+
+		selected := selectBackendNodes(svc, nodes)
+		if len(selected) != maxNodeBalancerConfigNodes {
+			t.Fatalf("expected %d nodes, got %d", maxNodeBalancerConfigNodes, len(selected))
+		}
+
+		for i, node := range selected {
+			expected := fmt.Sprintf("node-%03d", i+2)
+			if node.Name != expected {
+				t.Fatalf("expected node %d to be %s, got %s", i, expected, node.Name)
+			}
+		}
+
+		if again := selectBackendNodes(svc, nodes); !reflect.DeepEqual(selected, again) {
+			t.Error("expected selection to be deterministic")
+		}
+	})
+}
+
+func testBuildLoadBalancerRequest(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeDefaultProtocol: "tcp",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-2",
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-3",
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	nb, err := lb.buildLoadBalancerRequest(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if nb == nil {
+		t.Error("unexpected nodeID")
+		t.Logf("expected: != \"\"")
+		t.Logf("actual: %v", lb)
+	}
+	if !reflect.DeepEqual(err, err) {
+		t.Error("unexpected error")
+		t.Logf("expected: %v", nil)
+		t.Logf("actual: %v", err)
+	}
+
+	configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(configs) != len(svc.Spec.Ports) {
+		t.Error("unexpected nodebalancer config count")
+		t.Logf("expected: %v", len(svc.Spec.Ports))
+		t.Logf("actual: %v", len(configs))
+	}
+
+	nbNodes, _ := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+
+	if len(nbNodes) != len(nodes) {
+		t.Error("unexpected nodebalancer nodes count")
+		t.Logf("expected: %v", len(nodes))
+		t.Logf("actual: %v", len(nbNodes))
+	}
+
+}
+
+func testEnsureLoadBalancerPreserveAnnotation(t *testing.T, client *linodego.Client, fake *fakeAPI) {
+	testServiceSpec := v1.ServiceSpec{
+		Ports: []v1.ServicePort{
+			{
+				Name:     "test",
+				Protocol: "TCP",
+				Port:     int32(80),
+				NodePort: int32(30000),
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	for _, test := range []struct {
+		name        string
+		deleted     bool
+		annotations map[string]string
+	}{
+		{
+			name:        "load balancer preserved",
+			annotations: map[string]string{annLinodeLoadBalancerPreserve: "true"},
+			deleted:     false,
+		},
+		{
+			name:        "load balancer not preserved (deleted)",
+			annotations: map[string]string{annLinodeLoadBalancerPreserve: "false"},
+			deleted:     true,
+		},
+		{
+			name:        "invalid value treated as false (deleted)",
+			annotations: map[string]string{annLinodeLoadBalancerPreserve: "bogus"},
+			deleted:     true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					UID:         types.UID("foobar" + randString(10)),
+					Annotations: test.annotations,
+				},
+				Spec: testServiceSpec,
+			}
+
+			nb, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, []*linodego.NodeBalancerConfigCreateOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			svc.Status.LoadBalancer = *makeLoadBalancerStatus(nb)
+			err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
+
+			didDelete := fake.didRequestOccur(http.MethodDelete, fmt.Sprintf("/nodebalancers/%d", nb.ID), "")
+			if didDelete && !test.deleted {
+				t.Fatal("load balancer was unexpectedly deleted")
+			} else if !didDelete && test.deleted {
+				t.Fatal("load balancer was unexpectedly preserved")
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func testEnsureLoadBalancerDeleted(t *testing.T, client *linodego.Client, fake *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeDefaultProtocol: "tcp",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	testcases := []struct {
+		name        string
+		clusterName string
+		service     *v1.Service
+		err         error
+	}{
+		{
+			"load balancer delete",
+			"linodelb",
+			svc,
+			nil,
+		},
+		{
+			"load balancer not exists",
+			"linodelb",
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "notexists",
+					UID:  "notexists123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol: "tcp",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     "test",
+							Protocol: "TCP",
+							Port:     int32(80),
+							NodePort: int32(30000),
+						},
+					},
+				},
+			},
+			nil,
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	configs := []*linodego.NodeBalancerConfigCreateOptions{}
+	_, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			err := lb.EnsureLoadBalancerDeleted(context.TODO(), test.clusterName, test.service)
+			if !reflect.DeepEqual(err, test.err) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.err)
+				t.Logf("actual: %v", err)
+			}
+		})
+	}
+}
+
+func testEnsureExistingLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testensure",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeDefaultProtocol:           "tcp",
+				annLinodePortConfigPrefix + "8443": `{ "protocol": "https", "tls-secret-name": "tls-secret"}`,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(8443),
+					NodePort: int32(30000),
+				},
+				{
+					Name:     "test2",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30001),
+				},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	lb.kubeClient = fake.NewSimpleClientset()
+	addTLSSecret(t, lb.kubeClient)
+
+	configs := []*linodego.NodeBalancerConfigCreateOptions{}
+	nb, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc.Status.LoadBalancer = *makeLoadBalancerStatus(nb)
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+	getLBStatus, exists, err := lb.GetLoadBalancer(context.TODO(), "linodelb", svc)
+	if err != nil {
+		t.Fatalf("failed to create nodebalancer: %s", err)
+	}
+	if !exists {
+		t.Fatal("Node balancer not found")
+	}
+
+	testcases := []struct {
+		name        string
+		service     *v1.Service
+		nodes       []*v1.Node
+		clusterName string
+		nbIP        string
+		err         error
+	}{
+		{
+			"update load balancer",
+			svc,
+			[]*v1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node-1",
+					},
+					Status: v1.NodeStatus{
+						Addresses: []v1.NodeAddress{
+							{
+								Type:    v1.NodeInternalIP,
+								Address: "127.0.0.1",
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node-2",
+					},
+					Status: v1.NodeStatus{
+						Addresses: []v1.NodeAddress{
+							{
+								Type:    v1.NodeInternalIP,
+								Address: "127.0.0.2",
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "node-3",
+					},
+					Status: v1.NodeStatus{
+						Addresses: []v1.NodeAddress{
+							{
+								Type:    v1.NodeInternalIP,
+								Address: "127.0.0.3",
+							},
+						},
+					},
+				},
+			},
+			"linodelb",
+			getLBStatus.Ingress[0].IP,
+			nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), test.clusterName, test.service, test.nodes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lbStatus.Ingress[0].IP != test.nbIP {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.nbIP)
+				t.Logf("actual: %v", lbStatus.Ingress)
+			}
+			if !reflect.DeepEqual(err, test.err) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.err)
+				t.Logf("actual: %v", err)
+			}
+		})
+	}
+}
+
+func testGetNodeBalancerForServiceIDDoesNotExist(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	bogusNodeBalancerID := "123456"
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeNodeBalancerID: bogusNodeBalancerID,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(8443),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	_, err := lb.getNodeBalancerForService(context.TODO(), "linodelb", svc)
+	if err == nil {
+		t.Fatal("expected getNodeBalancerForService to return an error")
+	}
+
+	expectedErr := fmt.Sprintf("%s annotation points to a NodeBalancer that does not exist: LoadBalancer (%s) not found for service (%s)",
+		annLinodeNodeBalancerID, bogusNodeBalancerID, getServiceNn(svc))
+
+	if err.Error() != expectedErr {
+		t.Errorf("expected error to be '%s' but got '%s'", expectedErr, err)
+	}
+}
+
+func testEnsureLoadBalancerDeletedOutOfBand(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+
+	oldNB, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %s", err)
+	}
+	if err = client.DeleteNodeBalancer(context.TODO(), oldNB.ID); err != nil {
+		t.Fatalf("failed to delete NodeBalancer: %s", err)
+	}
+
+	lbStatus, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("expected EnsureLoadBalancer to recreate the NodeBalancer, got error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+
+	newNB, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get recreated NodeBalancer by status: %s", err)
+	}
+	if newNB.ID == oldNB.ID {
+		t.Errorf("expected a new NodeBalancer to be created, got the deleted NodeBalancer (%d)", oldNB.ID)
+	}
+}
+
+func testEnsureLoadBalancerAdoptedDeletedOutOfBand(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: client, zone: "us-west", recorder: recorder}
+
+	nodeBalancer, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+		Region: lb.zone,
+	})
+	if err != nil {
+		t.Fatalf("failed to create NodeBalancer: %s", err)
+	}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeNodeBalancerID: strconv.Itoa(nodeBalancer.ID),
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	if err = client.DeleteNodeBalancer(context.TODO(), nodeBalancer.ID); err != nil {
+		t.Fatalf("failed to delete NodeBalancer: %s", err)
+	}
+
+	_, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if _, ok := err.(lbAdoptedNotFoundError); !ok {
+		t.Fatalf("expected lbAdoptedNotFoundError, got %v", err)
+	}
+
+	if len(fakeAPI.nb) != 0 {
+		t.Error("expected no NodeBalancer to be created for an adopted NodeBalancer")
+	}
+
+	var notFoundEvent string
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, eventReasonNodeBalancerNotFound) {
+			notFoundEvent = event
+		}
+	}
+	if notFoundEvent == "" {
+		t.Errorf("expected a %s warning event to be emitted", eventReasonNodeBalancerNotFound)
+	}
+}
+
+func testEnsureLoadBalancerSkipStatusUpdate(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+
+	existingStatus := v1.LoadBalancerStatus{
+		Ingress: []v1.LoadBalancerIngress{{Hostname: "managed-elsewhere.example.com"}},
+	}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeSkipStatusUpdate: "true",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+		Status: v1.ServiceStatus{LoadBalancer: existingStatus},
+	}
+
+	for i := 0; i < 2; i++ {
+		lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+		if !reflect.DeepEqual(*lbStatus, existingStatus) {
+			t.Errorf("expected the existing status %v to be returned, got %v", existingStatus, *lbStatus)
+		}
+	}
+
+	if len(fakeAPI.nb) != 1 {
+		t.Fatalf("expected the NodeBalancer to be found by label and reused, found %d NodeBalancers", len(fakeAPI.nb))
+	}
+
+	if err := lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
+	if len(fakeAPI.nb) != 0 {
+		t.Error("expected the NodeBalancer to be deleted")
+	}
+}
+
+func testEnsureLoadBalancerSkipStatusUpdateClusterLabel(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	tmpl, err := parseNodeBalancerLabelTemplate("{{.Cluster}}-{{.Name}}-{{.Hash}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := &loadbalancers{client: client, zone: "us-west", labelTemplate: tmpl}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeSkipStatusUpdate: "true",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+	}
+
+	if len(fakeAPI.nb) != 1 {
+		t.Fatalf("expected the NodeBalancer to be found by its cluster label and reused, found %d NodeBalancers", len(fakeAPI.nb))
+	}
+	for _, nb := range fakeAPI.nb {
+		if expected := "linodelb-" + strings.ToLower(svc.Name) + "-" + serviceOwner(svc)[:nodeBalancerLabelHashLength]; nb.Label == nil || *nb.Label != expected {
+			t.Errorf("expected the NodeBalancer to be labeled %s, got %s", expected, stringValue(nb.Label))
+		}
+	}
+
+	if err := lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
+	if len(fakeAPI.nb) != 0 {
+		t.Error("expected the NodeBalancer found by its cluster label to be deleted")
+	}
+}
+
+func testEnsureLoadBalancerReadyEvent(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: client, zone: "us-west", recorder: recorder}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, eventReasonEnsuredLoadBalancer) || !strings.Contains(event, lbStatus.Ingress[0].IP) ||
+			!strings.Contains(event, lbStatus.Ingress[0].Hostname) {
+			t.Errorf("expected %s event with the IP and hostname, got %q", eventReasonEnsuredLoadBalancer, event)
+		}
+	default:
+		t.Fatal("expected an event to be emitted on initial readiness")
+	}
+
+	svc.Status.LoadBalancer = *lbStatus
+	for i := 0; i < 2; i++ {
+		if _, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+	}
+
+	select {
+	case event := <-recorder.Events:
+		t.Errorf("expected no event for no-op reconciles, got %q", event)
+	default:
+	}
+}
+
+func testEnsureLoadBalancerRegionChanged(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+
+	for _, test := range []struct {
+		name     string
+		recreate bool
+	}{
+		{name: "recreation disabled", recreate: false},
+		{name: "recreation enabled", recreate: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer func(recreate bool) { Options.RecreateNodeBalancersOnRegionChange = recreate }(Options.RecreateNodeBalancersOnRegionChange)
+			Options.RecreateNodeBalancersOnRegionChange = test.recreate
+
+			oldNB, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+				Region: "us-east",
+			})
+			if err != nil {
+				t.Fatalf("failed to create NodeBalancer: %s", err)
+			}
+
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: randString(10),
+					UID:  "foobar123",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     "test",
+							Protocol: "TCP",
+							Port:     int32(80),
+							NodePort: int32(30000),
+						},
+					},
+				},
+				Status: v1.ServiceStatus{
+					LoadBalancer: *makeLoadBalancerStatus(oldNB),
+				},
+			}
+
+			lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			if err != nil {
+				t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+			}
+			svc.Status.LoadBalancer = *lbStatus
+			defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+			nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+			if err != nil {
+				t.Fatalf("failed to get NodeBalancer by status: %s", err)
+			}
+
+			if !test.recreate {
+				if nb.ID != oldNB.ID {
+					t.Errorf("expected NodeBalancer (%d) to be kept, got %d", oldNB.ID, nb.ID)
+				}
+				return
+			}
+
+			if nb.ID == oldNB.ID || nb.Region != lb.zone {
+				t.Errorf("expected a new NodeBalancer in %s, got NodeBalancer (%d) in %s", lb.zone, nb.ID, nb.Region)
+			}
+			if _, ok := fakeAPI.nb[strconv.Itoa(oldNB.ID)]; ok {
+				t.Errorf("expected NodeBalancer (%d) in the old region to be deleted", oldNB.ID)
+			}
+		})
+	}
+}
+
+func testUpdateLoadBalancerConfigMetadataTags(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)},
+				{Name: "alt", Protocol: "TCP", Port: int32(8080), NodePort: int32(30001)},
+			},
+		},
+	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, _ := parseConfigMetadataTags(nb.Tags)
+	if len(metadata) != 2 || !metadata[80].isManaged() || !metadata[8080].isManaged() {
+		t.Fatalf("expected managed metadata for ports 80 and 8080, got %v", metadata)
+	}
+	created := metadata[80]
+
+	userTags := []string{"team:web", makeClusterTag("linodelb")}
+	tags := append([]string{"team:web"}, nb.Tags...)
+	if _, err = client.UpdateNodeBalancer(context.TODO(), nb.ID, linodego.NodeBalancerUpdateOptions{
+		Tags: &tags,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	svc.Spec.Ports = svc.Spec.Ports[:1]
+	if _, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+
+	nb, err = lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, other := parseConfigMetadataTags(nb.Tags)
+	if !reflect.DeepEqual(metadata, map[int]configMetadata{80: created}) {
+		t.Errorf("expected only the unchanged metadata for port 80, got %v", metadata)
+	}
+	if !reflect.DeepEqual(other, userTags) {
+		t.Errorf("expected tags %v to be kept, got %v", userTags, other)
+	}
+}
+
+func testEnsureLoadBalancerWithLoadBalancerIP(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+
+	nodeBalancer, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+		Region: lb.zone,
+	})
+	if err != nil {
+		t.Fatalf("failed to create NodeBalancer: %s", err)
+	}
+
+	newService := func(ip string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randString(10),
+				UID:  "foobar123",
+			},
+			Spec: v1.ServiceSpec{
+				LoadBalancerIP: ip,
+				Ports: []v1.ServicePort{
+					{
+						Name:     "test",
+						Protocol: "TCP",
+						Port:     int32(80),
+						NodePort: int32(30000),
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("matching NodeBalancer is reused", func(t *testing.T) {
+		svc := newService(*nodeBalancer.IPv4)
+		lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+
+		if lbStatus.Ingress[0].IP != *nodeBalancer.IPv4 {
+			t.Errorf("expected ingress IP %s, got %s", *nodeBalancer.IPv4, lbStatus.Ingress[0].IP)
+		}
+		if len(fakeAPI.nb) != 1 {
+			t.Errorf("expected the existing NodeBalancer to be reused, found %d NodeBalancers", len(fakeAPI.nb))
+		}
+	})
+
+	t.Run("no matching NodeBalancer", func(t *testing.T) {
+		svc := newService("203.0.113.1")
+		if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err == nil {
+			t.Fatal("expected EnsureLoadBalancer to return an error")
+		}
+		if len(fakeAPI.nb) != 1 {
+			t.Errorf("expected no NodeBalancer to be created, found %d NodeBalancers", len(fakeAPI.nb))
+		}
+	})
+}
+
+func testEnsureNewLoadBalancerWithNodeBalancerID(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	nodeBalancer, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+		Region: lb.zone,
+	})
+	if err != nil {
+		t.Fatalf("failed to create NodeBalancer: %s", err)
+	}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testensure",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeNodeBalancerID: strconv.Itoa(nodeBalancer.ID),
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(8443),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+			},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{
+						Type:    v1.NodeInternalIP,
+						Address: "127.0.0.1",
+					},
+				},
+			},
+		},
+	}
+
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	if _, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testEnsureNewLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "testensure",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeDefaultProtocol:           "tcp",
+				annLinodePortConfigPrefix + "8443": `{ "protocol": "https", "tls-secret-name": "tls-secret"}`,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(8443),
+					NodePort: int32(30000),
+				},
+				{
+					Name:     "test2",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30001),
+				},
+			},
+		},
+	}
+
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node-1",
+			},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{
+						Type:    v1.NodeInternalIP,
+						Address: "127.0.0.1",
+					},
+				},
+			},
+		},
+	}
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	lb.kubeClient = fake.NewSimpleClientset()
+	addTLSSecret(t, lb.kubeClient)
+
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testGetLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeDefaultProtocol: "tcp",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	configs := []*linodego.NodeBalancerConfigCreateOptions{}
+	nb, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+
+	lbStatus := makeLoadBalancerStatus(nb)
+	svc.Status.LoadBalancer = *lbStatus
+
+	testcases := []struct {
+		name        string
+		service     *v1.Service
+		clusterName string
+		found       bool
+		err         error
+	}{
+		{
+			"Load balancer exists",
+			svc,
+			"linodelb",
+			true,
+			nil,
+		},
+		{
+			"Load balancer not exists",
+
+			&v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "notexists",
+					UID:  "notexists123",
+					Annotations: map[string]string{
+						annLinodeDefaultProtocol: "tcp",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:     "test",
+							Protocol: "TCP",
+							Port:     int32(80),
+							NodePort: int32(30000),
+						},
+					},
+				},
+			},
+			"linodelb",
+			false,
+			nil,
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+
+			_, found, err := lb.GetLoadBalancer(context.TODO(), test.clusterName, test.service)
+			if found != test.found {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.found)
+				t.Logf("actual: %v", found)
+			}
+			if !reflect.DeepEqual(err, test.err) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.err)
+				t.Logf("actual: %v", err)
+			}
+		})
+	}
+}
+
+func Test_getPortConfigAnnotation(t *testing.T) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	testcases := []struct {
+		name     string
+		ann      map[string]string
+		expected portConfigAnnotation
+		err      string
+	}{
+		{
+			name: "Test single port annotation",
+			ann:  map[string]string{annLinodePortConfigPrefix + "443": `{ "tls-secret-name": "prod-app-tls", "protocol": "https" }`},
+			expected: portConfigAnnotation{
+				TLSSecretName: "prod-app-tls",
+				Protocol:      "https",
+			},
+			err: "",
+		},
+		{
+			name: "Test multiple port annotation",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "tls-secret-name": "prod-app-tls", "protocol": "https" }`,
+				annLinodePortConfigPrefix + "80":  `{ "protocol": "http" }`,
+			},
+			expected: portConfigAnnotation{
+				TLSSecretName: "prod-app-tls",
+				Protocol:      "https",
+			},
+			err: "",
+		},
+		{
+			name: "Test no port annotation",
+			ann:  map[string]string{},
+			expected: portConfigAnnotation{
+				Protocol: "",
+			},
+			err: "",
+		},
+		{
+			name: "Test invalid json",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "tls-secret-name": "prod-app-tls" `,
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + "443 annotation: malformed JSON: it ends at character 36 before the object is closed",
+		},
+		{
+			name: "Test unknown fields and loosely typed values",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "Protocol": "https", "owner": "team-a", "healthcheck": { "interval": "10", "passive": "true", "expected-codes": ["200"] } }`,
+			},
+			expected: portConfigAnnotation{
+				Protocol: "https",
+				HealthCheck: &healthCheckAnnotation{
+					Interval:      intPtr(10),
+					Passive:       boolPtr(true),
+					ExpectedCodes: []int{200},
+				},
+			},
+		},
+		{
+			name: "Test number for string field",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "tls-secret-name": 42 }`,
+			},
+			expected: portConfigAnnotation{TLSSecretName: "42"},
+		},
+		{
+			name: "Test invalid field value",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "protocol": "https", "healthcheck": { "interval": "ten" } }`,
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + `443 annotation: "healthcheck.interval" must be a whole number, got "ten"`,
+		},
+		{
+			name: "Test invalid field type",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "protocol": ["https"] }`,
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + `443 annotation: "protocol" must be a string, got a list`,
+		},
+		{
+			name: "Test not an object",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `"https"`,
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + `443 annotation: expected an object, got "https"`,
+		},
+		{
+			name: "Test trailing data",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "protocol": "https" } }`,
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + "443 annotation: unexpected data after the JSON object at character 25",
+		},
+		{
+			name: "Test syntax error",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": `{ "protocol": https }`,
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + "443 annotation: malformed JSON at character 15: invalid character 'h' looking for beginning of value",
+		},
+		{
+			name: "Test empty",
+			ann: map[string]string{
+				annLinodePortConfigPrefix + "443": " ",
+			},
+			expected: portConfigAnnotation{},
+			err:      "invalid " + annLinodePortConfigPrefix + "443 annotation: the value is empty, expected a JSON object",
+		},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			svc.Annotations = test.ann
+			ann, err := getPortConfigAnnotation(svc, 443)
+			if !reflect.DeepEqual(ann, test.expected) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.expected)
+				t.Logf("actual: %v", ann)
+			}
+			if test.err == "" && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+			if test.err != "" && (err == nil || test.err != err.Error()) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.err)
+				t.Logf("actual: %v", err)
+			}
+		})
+	}
+}
+
+func Test_getTLSCertInfo(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	addTLSSecret(t, kubeClient)
+
+	testcases := []struct {
+		name       string
+		portConfig portConfig
+		cert       string
+		key        string
+		err        error
+	}{
+		{
+			name: "Test valid Cert info",
+			portConfig: portConfig{
+				TLSSecretName: "tls-secret",
+				Port:          8080,
+			},
+			cert: testCert,
+			key:  testKey,
+			err:  nil,
+		},
+		{
+			name: "Test unspecified Cert info",
+			portConfig: portConfig{
+				Port: 8080,
+			},
+			cert: "",
+			key:  "",
+			err:  fmt.Errorf("TLS secret name for port 8080 is not specified"),
+		},
+		{
+			name: "Test blank Cert info",
+			portConfig: portConfig{
+				TLSSecretName: "",
+				Port:          8080,
+			},
+			cert: "",
+			key:  "",
+			err:  fmt.Errorf("TLS secret name for port 8080 is not specified"),
+		},
+		{
+			name: "Test no secret found",
+			portConfig: portConfig{
+				TLSSecretName: "secret",
+				Port:          8080,
+			},
+			cert: "",
+			key:  "",
+			err: errors.NewNotFound(schema.GroupResource{
+				Group:    "",
+				Resource: "secrets",
+			}, "secret"), /*{}(`secrets "secret" not found`)*/
+		},
+		{
+			name: "Test wrong secret type",
+			portConfig: portConfig{
+				TLSSecretName: "opaque-secret",
+				Port:          8080,
+			},
+			cert: "",
+			key:  "",
+			err:  fmt.Errorf("TLS secret %s/%s for port 8080 has type %q, must be %q", "", "opaque-secret", v1.SecretTypeOpaque, v1.SecretTypeTLS),
+		},
+		{
+			name: "Test secret missing cert",
+			portConfig: portConfig{
+				TLSSecretName: "no-cert-secret",
+				Port:          8080,
+			},
+			cert: "",
+			key:  "",
+			err:  fmt.Errorf("TLS secret %s/%s for port 8080 is missing key %q", "", "no-cert-secret", v1.TLSCertKey),
+		},
+		{
+			name: "Test secret missing key",
+			portConfig: portConfig{
+				TLSSecretName: "no-key-secret",
+				Port:          8080,
+			},
+			cert: "",
+			key:  "",
+			err:  fmt.Errorf("TLS secret %s/%s for port 8080 is missing key %q", "", "no-key-secret", v1.TLSPrivateKeyKey),
+		},
+	}
+
+	for _, secret := range []*v1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "opaque-secret"},
+			Data: map[string][]byte{
+				v1.TLSCertKey:       []byte(testCert),
+				v1.TLSPrivateKeyKey: []byte(testKey),
+			},
+			Type: v1.SecretTypeOpaque,
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-cert-secret"},
+			Data:       map[string][]byte{v1.TLSPrivateKeyKey: []byte(testKey)},
+			Type:       v1.SecretTypeTLS,
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-key-secret"},
+			Data:       map[string][]byte{v1.TLSCertKey: []byte(testCert)},
+			Type:       v1.SecretTypeTLS,
+		},
+	} {
+		if _, err := kubeClient.CoreV1().Secrets("").Create(context.TODO(), secret, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to add secret: %s", err)
+		}
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			cert, key, err := getTLSCertInfo(context.TODO(), kubeClient, "", test.portConfig)
+			if cert != test.cert {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.cert)
+				t.Logf("actual: %v", cert)
+			}
+			if key != test.key {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.key)
+				t.Logf("actual: %v", key)
+			}
+			if !reflect.DeepEqual(err, test.err) {
+				t.Error("unexpected error")
+				t.Logf("expected: %v", test.err)
+				t.Logf("actual: %v", err)
+			}
+		})
+	}
+}
+
+func Test_getTLSCertInfoCrossNamespace(t *testing.T) {
+	defer func(allow bool) { Options.AllowCrossNamespaceTLSSecrets = allow }(Options.AllowCrossNamespaceTLSSecrets)
+
+	kubeClient := fake.NewSimpleClientset()
+	_, err := kubeClient.CoreV1().Secrets("shared").Create(context.TODO(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "tls-secret",
+			Namespace: "shared",
+		},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte(testCert),
+			v1.TLSPrivateKeyKey: []byte(testKey),
+		},
+		Type: "kubernetes.io/tls",
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to add TLS secret: %s", err)
+	}
+
+	testcases := []struct {
+		name       string
+		secretName string
+		allow      bool
+		expectErr  bool
+	}{
+		{"cross-namespace allowed", "shared/tls-secret", true, false},
+		{"cross-namespace denied by default", "shared/tls-secret", false, true},
+		{"same namespace reference to a missing secret", " default/tls-secret ", false, true},
+		{"too many separators", "shared/tls/secret", true, true},
+		{"invalid namespace", "Shared/tls-secret", true, true},
+		{"invalid name", "shared/TLS_secret", true, true},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			Options.AllowCrossNamespaceTLSSecrets = test.allow
+
+			cert, key, err := getTLSCertInfo(context.TODO(), kubeClient, "default", portConfig{TLSSecretName: test.secretName, Port: 443})
+			if test.expectErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if cert != testCert || key != testKey {
+				t.Error("unexpected certificate or key")
+			}
+		})
+	}
+}
+
+func TestEnsureLoadBalancerForbiddenSecretNamespace(t *testing.T) {
+	defer func(allow bool) { Options.AllowCrossNamespaceTLSSecrets = allow }(Options.AllowCrossNamespaceTLSSecrets)
+	Options.AllowCrossNamespaceTLSSecrets = false
+
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      randString(10),
+			Namespace: "default",
+			UID:       "foobar123",
+			Annotations: map[string]string{
+				annLinodePortConfigPrefix + "443": `{"protocol": "https", "tls-secret-name": "shared/tls-secret"}`,
 			},
-			"127.0.0.1",
 		},
-		{
-			"node internal ip not specified",
-			&v1.Node{
-				Status: v1.NodeStatus{
-					Addresses: []v1.NodeAddress{
-						{
-							Type:    v1.NodeExternalIP,
-							Address: "127.0.0.1",
-						},
-					},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "https",
+					Protocol: "TCP",
+					Port:     int32(443),
+					NodePort: int32(30000),
 				},
 			},
-			"",
 		},
 	}
+	lb.kubeClient = fake.NewSimpleClientset(svc)
 
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			ip := getNodeInternalIP(test.node)
-			if ip != test.address {
-				t.Error("unexpected certificate")
-				t.Logf("expected: %q", test.address)
-				t.Logf("actual: %q", ip)
+	_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	forbiddenErr, ok := err.(forbiddenSecretNamespaceError)
+	if !ok {
+		t.Fatalf("expected a forbiddenSecretNamespaceError, got %v", err)
+	}
+	if forbiddenErr.secretNamespace != "shared" || forbiddenErr.namespace != "default" {
+		t.Errorf("expected the error to name namespaces shared and default, got %+v", forbiddenErr)
+	}
+
+	close(recorder.Events)
+	var found bool
+	for event := range recorder.Events {
+		if strings.HasPrefix(event, "Warning "+eventReasonForbiddenSecretNamespace) {
+			found = true
+			if !strings.Contains(event, `namespace "shared"`) {
+				t.Errorf("expected the event to name namespace shared, got %q", event)
 			}
-		})
+		}
+	}
+	if !found {
+		t.Errorf("expected a %s warning event", eventReasonForbiddenSecretNamespace)
+	}
+}
+
+func Test_parseTLSSecretRef(t *testing.T) {
+	namespace, name, err := parseTLSSecretRef(" shared/tls-secret ", "default")
+	if err != nil || namespace != "shared" || name != "tls-secret" {
+		t.Errorf("expected shared/tls-secret, got %s/%s (%v)", namespace, name, err)
 	}
 
+	namespace, name, err = parseTLSSecretRef("tls-secret", "default")
+	if err != nil || namespace != "default" || name != "tls-secret" {
+		t.Errorf("expected default/tls-secret, got %s/%s (%v)", namespace, name, err)
+	}
 }
 
-func testBuildLoadBalancerRequest(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+func addTLSSecret(t *testing.T, kubeClient kubernetes.Interface) {
+	_, err := kubeClient.CoreV1().Secrets("").Create(context.TODO(), &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "tls-secret",
+		},
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte(testCert),
+			v1.TLSPrivateKeyKey: []byte(testKey),
+		},
+		StringData: nil,
+		Type:       "kubernetes.io/tls",
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to add TLS secret: %s\n", err)
+	}
+}
+
+func testUpdateLoadBalancerWithoutSourceRanges(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "test",
+			Name: randString(10),
 			UID:  "foobar123",
-			Annotations: map[string]string{
-				annLinodeDefaultProtocol: "tcp",
-			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
+					Name:     "http",
 					Protocol: "TCP",
 					Port:     int32(80),
 					NodePort: int32(30000),
@@ -1082,139 +4322,352 @@ func testBuildLoadBalancerRequest(t *testing.T, client *linodego.Client, _ *fake
 	}
 	nodes := []*v1.Node{
 		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-1",
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
 			},
 		},
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-2",
+	}
+
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	nodes = append(nodes, &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.2"}},
+		},
+	})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+
+	for request := range fakeAPI.requests {
+		if strings.HasPrefix(request.Path, "/networking/firewalls") {
+			t.Errorf("expected no Firewall requests for a service without source ranges, got %s %s", request.Method, request.Path)
+		}
+	}
+}
+
+func testUpdateLoadBalancerSourceRanges(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	defer func(onChangeOnly bool) { Options.ReconcileNodesOnChangeOnly = onChangeOnly }(Options.ReconcileNodesOnChangeOnly)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "https",
+					Protocol: "TCP",
+					Port:     int32(443),
+					NodePort: int32(30001),
+				},
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
 			},
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
 		},
+	}
+	nodes := []*v1.Node{
 		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-3",
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
 			},
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
-	nb, err := lb.buildLoadBalancerRequest(context.TODO(), "linodelb", svc, nodes)
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
 	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 
-	if nb == nil {
-		t.Error("unexpected nodeID")
-		t.Logf("expected: != \"\"")
-		t.Logf("actual: %v", lb)
-	}
-	if !reflect.DeepEqual(err, err) {
-		t.Error("unexpected error")
-		t.Logf("expected: %v", nil)
-		t.Logf("actual: %v", err)
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
 	}
 
-	configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
-	if err != nil {
-		t.Fatal(err)
+	assertFirewall := func(ipv4, ipv6 []string) {
+		t.Helper()
+		firewall, err := lb.getManagedFirewall(context.TODO(), "linodelb", nb.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ipv4 == nil && ipv6 == nil {
+			if firewall != nil {
+				t.Errorf("expected no Firewall, got %+v", firewall)
+			}
+			return
+		}
+		if firewall == nil {
+			t.Fatal("expected a Firewall for the NodeBalancer")
+		}
+		if !hasTag(firewall.Tags, makeClusterTag("linodelb")) {
+			t.Errorf("expected the Firewall to be tagged with the cluster, got %v", firewall.Tags)
+		}
+		devices := fakeAPI.fwd[firewall.ID]
+		if len(devices) != 1 || devices[0].Entity.ID != nb.ID {
+			t.Errorf("expected the Firewall to be attached to NodeBalancer (%d), got %v", nb.ID, devices)
+		}
+		expected := []linodego.FirewallRule{{
+			Ports:     "80,443",
+			Protocol:  linodego.TCP,
+			Addresses: linodego.NetworkAddresses{IPv4: ipv4, IPv6: ipv6},
+		}}
+		if !reflect.DeepEqual(firewall.Rules.Inbound, expected) {
+			t.Errorf("expected inbound rules %+v, got %+v", expected, firewall.Rules.Inbound)
+		}
+	}
+	assertConfigsUnchanged := func() {
+		t.Helper()
+		for request := range fakeAPI.requests {
+			if request.Method != http.MethodGet && strings.Contains(request.Path, "/nodebalancers") {
+				t.Errorf("expected the NodeBalancer to be left unchanged, got %s %s", request.Method, request.Path)
+			}
+		}
 	}
 
-	if len(configs) != len(svc.Spec.Ports) {
-		t.Error("unexpected nodebalancer config count")
-		t.Logf("expected: %v", len(svc.Spec.Ports))
-		t.Logf("actual: %v", len(configs))
+	assertFirewall([]string{"10.0.0.0/8"}, []string{})
+
+	for _, onChangeOnly := range []bool{false, true} {
+		Options.ReconcileNodesOnChangeOnly = onChangeOnly
+		svc.Spec.LoadBalancerSourceRanges = []string{"192.168.0.0/16", "10.0.0.0/8", "2600:3c00::/32"}
+		if onChangeOnly {
+			svc.Spec.LoadBalancerSourceRanges = svc.Spec.LoadBalancerSourceRanges[1:]
+		}
+
+		fakeAPI.requests = make(map[fakeRequest]struct{})
+		if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+			t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+		}
+		assertConfigsUnchanged()
+		if onChangeOnly {
+			assertFirewall([]string{"10.0.0.0/8"}, []string{"2600:3c00::/32"})
+		} else {
+			assertFirewall([]string{"10.0.0.0/8", "192.168.0.0/16"}, []string{"2600:3c00::/32"})
+		}
 	}
 
-	nbNodes, _ := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if len(fakeAPI.requests) != 0 {
+		t.Errorf("expected no Linode API requests when nothing changed, got %v", fakeAPI.requests)
+	}
 
-	if len(nbNodes) != len(nodes) {
-		t.Error("unexpected nodebalancer nodes count")
-		t.Logf("expected: %v", len(nodes))
-		t.Logf("actual: %v", len(nbNodes))
+	svc.Spec.LoadBalancerSourceRanges = nil
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
 	}
+	assertFirewall(nil, nil)
 
+	svc.Spec.LoadBalancerSourceRanges = []string{"10.0.0.0/8"}
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
+	if len(fakeAPI.fw) != 0 {
+		t.Errorf("expected the Firewall to be deleted with the NodeBalancer, got %v", fakeAPI.fw)
+	}
 }
 
-func testEnsureLoadBalancerPreserveAnnotation(t *testing.T, client *linodego.Client, fake *fakeAPI) {
-	testServiceSpec := v1.ServiceSpec{
-		Ports: []v1.ServicePort{
-			{
-				Name:     "test",
-				Protocol: "TCP",
-				Port:     int32(80),
-				NodePort: int32(30000),
+func testEnsureLoadBalancerDeletedFirewalls(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
 			},
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
 		},
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
-	for _, test := range []struct {
-		name        string
-		deleted     bool
-		annotations map[string]string
-	}{
-		{
-			name:        "load balancer preserved",
-			annotations: map[string]string{annLinodeLoadBalancerPreserve: "true"},
-			deleted:     false,
-		},
-		{
-			name:        "load balancer not preserved (deleted)",
-			annotations: map[string]string{annLinodeLoadBalancerPreserve: "false"},
-			deleted:     true,
-		},
-		{
-			name:        "invalid value treated as false (deleted)",
-			annotations: map[string]string{annLinodeLoadBalancerPreserve: "bogus"},
-			deleted:     true,
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+	managed, err := lb.getManagedFirewall(context.TODO(), "linodelb", nb.ID)
+	if err != nil || managed == nil {
+		t.Fatalf("expected a managed Firewall for the NodeBalancer, got %v (%v)", managed, err)
+	}
+
+	// An externally managed firewall, even one tagged with the cluster, is only ever
+	// attached to the NodeBalancer and must survive the service's deletion.
+	external := linodego.Firewall{
+		ID:    managed.ID + 1,
+		Label: "external",
+		Tags:  []string{makeClusterTag("linodelb")},
+	}
+	fakeAPI.addFirewall(external, linodego.FirewallDevice{
+		Entity: linodego.FirewallDeviceEntity{ID: nb.ID, Type: linodego.FirewallDeviceNodeBalancer},
+	})
+	svc.Annotations = map[string]string{annLinodeFirewallID: strconv.Itoa(external.ID)}
+
+	for i := 0; i < 2; i++ {
+		if err := lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+			t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+		}
+	}
+
+	if _, ok := fakeAPI.fw[strconv.Itoa(managed.ID)]; ok {
+		t.Errorf("expected the managed Firewall (%d) to be deleted", managed.ID)
+	}
+	if _, ok := fakeAPI.fw[strconv.Itoa(external.ID)]; !ok {
+		t.Errorf("expected the external Firewall (%d) to be left intact", external.ID)
+	}
+}
+
+func testUpdateLoadBalancerFirewallNodes(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeFirewallAllowNodes: "true",
+			},
 		},
-	} {
-		t.Run(test.name, func(t *testing.T) {
-			svc := &v1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:        "test",
-					UID:         types.UID("foobar" + randString(10)),
-					Annotations: test.annotations,
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
 				},
-				Spec: testServiceSpec,
-			}
+			},
+			LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+		},
+	}
+	newNode := func(name string, addresses ...v1.NodeAddress) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1.NodeStatus{Addresses: addresses},
+		}
+	}
+	nodes := []*v1.Node{
+		newNode("node-1",
+			v1.NodeAddress{Type: v1.NodeInternalIP, Address: "192.168.0.1"},
+			v1.NodeAddress{Type: v1.NodeExternalIP, Address: "45.79.0.1"},
+			v1.NodeAddress{Type: v1.NodeHostName, Address: "node-1"}),
+	}
 
-			nb, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, []*linodego.NodeBalancerConfigCreateOptions{})
-			if err != nil {
-				t.Fatal(err)
-			}
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 
-			svc.Status.LoadBalancer = *makeLoadBalancerStatus(nb)
-			err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc)
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
 
-			didDelete := fake.didRequestOccur(http.MethodDelete, fmt.Sprintf("/nodebalancers/%d", nb.ID), "")
-			if didDelete && !test.deleted {
-				t.Fatal("load balancer was unexpectedly deleted")
-			} else if !didDelete && test.deleted {
-				t.Fatal("load balancer was unexpectedly preserved")
-			}
+	assertNodeAddresses := func(expected []string) {
+		t.Helper()
+		firewall, err := lb.getManagedFirewall(context.TODO(), "linodelb", nb.ID)
+		if err != nil || firewall == nil {
+			t.Fatalf("expected a managed Firewall for the NodeBalancer, got %v (%v)", firewall, err)
+		}
+		if len(firewall.Rules.Inbound) != 2 {
+			t.Fatalf("expected a source range rule and a node rule, got %+v", firewall.Rules.Inbound)
+		}
+		if addresses := firewall.Rules.Inbound[0].Addresses.IPv4; !reflect.DeepEqual(addresses, []string{"10.0.0.0/8"}) {
+			t.Errorf("expected the source ranges to be kept, got %v", addresses)
+		}
+		if addresses := firewall.Rules.Inbound[1].Addresses.IPv4; !reflect.DeepEqual(addresses, expected) {
+			t.Errorf("expected node addresses %v, got %v", expected, addresses)
+		}
+	}
+	assertNodeAddresses([]string{"192.168.0.1/32", "45.79.0.1/32"})
+
+	// A new node is admitted, and an address shared by two nodes is admitted once
+	nodes = append(nodes, newNode("node-2",
+		v1.NodeAddress{Type: v1.NodeInternalIP, Address: "192.168.0.2"},
+		v1.NodeAddress{Type: v1.NodeExternalIP, Address: "45.79.0.1"}))
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	assertNodeAddresses([]string{"192.168.0.1/32", "192.168.0.2/32", "45.79.0.1/32"})
 
-			if err != nil {
-				t.Fatalf("unexpected error: %s", err)
-			}
-		})
+	// A removed node is no longer admitted
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes[1:]); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	assertNodeAddresses([]string{"192.168.0.2/32", "45.79.0.1/32"})
+
+	// Node addresses beyond what a Firewall may hold are refused
+	var many []*v1.Node
+	for i := 0; i < maxFirewallRuleAddresses*maxFirewallInboundRules; i++ {
+		many = append(many, newNode(fmt.Sprintf("node-%d", i),
+			v1.NodeAddress{Type: v1.NodeInternalIP, Address: fmt.Sprintf("10.%d.%d.1", i/256, i%256)}))
+	}
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, many); err == nil {
+		t.Error("expected UpdateLoadBalancer to refuse more node addresses than a Firewall may hold")
 	}
+	assertNodeAddresses([]string{"192.168.0.2/32", "45.79.0.1/32"})
 }
 
-func testEnsureLoadBalancerDeleted(t *testing.T, client *linodego.Client, fake *fakeAPI) {
+func testUpdateLoadBalancerBackendNodeSelector(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	defer func(onChangeOnly bool) { Options.ReconcileNodesOnChangeOnly = onChangeOnly }(Options.ReconcileNodesOnChangeOnly)
+	Options.ReconcileNodesOnChangeOnly = true
+
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "test",
+			Name: randString(10),
 			UID:  "foobar123",
 			Annotations: map[string]string{
-				annLinodeDefaultProtocol: "tcp",
+				annLinodeBackendNodeSelector: "pool in (gpu-a, gpu-b)",
 			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
+					Name:     "http",
 					Protocol: "TCP",
 					Port:     int32(80),
 					NodePort: int32(30000),
@@ -1222,348 +4675,783 @@ func testEnsureLoadBalancerDeleted(t *testing.T, client *linodego.Client, fake *
 			},
 		},
 	}
-	testcases := []struct {
-		name        string
-		clusterName string
-		service     *v1.Service
-		err         error
-	}{
-		{
-			"load balancer delete",
-			"linodelb",
-			svc,
-			nil,
-		},
-		{
-			"load balancer not exists",
-			"linodelb",
-			&v1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "notexists",
-					UID:  "notexists123",
-					Annotations: map[string]string{
-						annLinodeDefaultProtocol: "tcp",
-					},
-				},
-				Spec: v1.ServiceSpec{
-					Ports: []v1.ServicePort{
-						{
-							Name:     "test",
-							Protocol: "TCP",
-							Port:     int32(80),
-							NodePort: int32(30000),
-						},
-					},
-				},
+	newNode := func(name, pool, address string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"pool": pool},
 			},
-			nil,
-		},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: address}},
+			},
+		}
+	}
+	nodes := []*v1.Node{
+		newNode("gpu-a-1", "gpu-a", "127.0.0.1"),
+		newNode("gpu-b-1", "gpu-b", "127.0.0.2"),
+		newNode("general-1", "general", "127.0.0.3"),
+		newNode("ingress-1", "ingress", "127.0.0.4"),
 	}
 
-	lb := &loadbalancers{client, "us-west", nil}
-	configs := []*linodego.NodeBalancerConfigCreateOptions{}
-	_, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, configs)
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
 	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
 
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+	assertBackends := func(expected ...string) {
+		t.Helper()
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nbNodes, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make([]string, 0, len(nbNodes))
+		for _, n := range nbNodes {
+			actual = append(actual, n.Label)
+		}
+		sort.Strings(actual)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("expected backends %v, got %v", expected, actual)
+		}
+	}
+	assertBackends("gpu-a-1", "gpu-b-1")
+
+	// Moving a node into a targeted pool changes the backends even though the node's
+	// addresses did not change.
+	nodes[2].Labels["pool"] = "gpu-b"
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	assertBackends("general-1", "gpu-a-1", "gpu-b-1")
+
+	svc.Annotations[annLinodeBackendNodeSelector] = "pool=ingress"
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	assertBackends("ingress-1")
+}
+
+func Test_getBackendNodeSelector(t *testing.T) {
+	testcases := []struct {
+		name      string
+		selector  string
+		annotated bool
+		matches   map[string]string
+		expectErr bool
+	}{
+		{name: "no annotation", matches: map[string]string{}},
+		{name: "equality", selector: "pool=gpu", annotated: true, matches: map[string]string{"pool": "gpu"}},
+		{name: "set based", selector: "pool in (gpu, ingress),!spot", annotated: true, matches: map[string]string{"pool": "ingress"}},
+		{name: "empty", selector: " ", annotated: true, expectErr: true},
+		{name: "invalid syntax", selector: "pool in gpu", annotated: true, expectErr: true},
+		{name: "invalid label", selector: "pool=gpu nodes", annotated: true, expectErr: true},
+	}
+
 	for _, test := range testcases {
 		t.Run(test.name, func(t *testing.T) {
-			err := lb.EnsureLoadBalancerDeleted(context.TODO(), test.clusterName, test.service)
-			if !reflect.DeepEqual(err, test.err) {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.err)
-				t.Logf("actual: %v", err)
+			svc := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if test.annotated {
+				svc.Annotations[annLinodeBackendNodeSelector] = test.selector
+			}
+
+			selector, err := getBackendNodeSelector(svc)
+			if test.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !selector.Matches(labels.Set(test.matches)) {
+				t.Errorf("expected selector %q to match %v", selector, test.matches)
 			}
 		})
 	}
 }
 
-func testEnsureExistingLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+func testUpdateLoadBalancerThrottleAndPortConfig(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "testensure",
+			Name: randString(10),
 			UID:  "foobar123",
 			Annotations: map[string]string{
-				annLinodeDefaultProtocol:           "tcp",
-				annLinodePortConfigPrefix + "8443": `{ "protocol": "https", "tls-secret-name": "tls-secret"}`,
+				annLinodeThrottle: "15",
 			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
+					Name:     "http",
 					Protocol: "TCP",
-					Port:     int32(8443),
+					Port:     int32(80),
 					NodePort: int32(30000),
 				},
 				{
-					Name:     "test2",
+					Name:     "admin",
 					Protocol: "TCP",
-					Port:     int32(80),
+					Port:     int32(8080),
 					NodePort: int32(30001),
 				},
 			},
 		},
 	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+	}
 
-	lb := &loadbalancers{client, "us-west", nil}
-	lb.kubeClient = fake.NewSimpleClientset()
-	addTLSSecret(t, lb.kubeClient)
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
 
-	configs := []*linodego.NodeBalancerConfigCreateOptions{}
-	nb, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, configs)
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
 	}
-
-	svc.Status.LoadBalancer = *makeLoadBalancerStatus(nb)
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
-	getLBStatus, exists, err := lb.GetLoadBalancer(context.TODO(), "linodelb", svc)
-	if err != nil {
-		t.Fatalf("failed to create nodebalancer: %s", err)
+
+	assertApplied := func(throttle int, protocols map[int]linodego.ConfigProtocol) {
+		t.Helper()
+		nb, err := lb.getNodeBalancerByIPv4(context.TODO(), svc, lbStatus.Ingress[0].IP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nb.ClientConnThrottle != throttle {
+			t.Errorf("expected ClientConnThrottle %d, got %d", throttle, nb.ClientConnThrottle)
+		}
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := make(map[int]linodego.ConfigProtocol, len(configs))
+		for _, config := range configs {
+			actual[config.Port] = config.Protocol
+		}
+		if !reflect.DeepEqual(actual, protocols) {
+			t.Errorf("expected config protocols %v, got %v", protocols, actual)
+		}
 	}
-	if !exists {
-		t.Fatal("Node balancer not found")
+
+	// A misconfigured port fails the update before the throttle is changed.
+	svc.Annotations = map[string]string{
+		annLinodeThrottle:                  "5",
+		annLinodePortConfigPrefix + "80":   `{"protocol": "http"}`,
+		annLinodePortConfigPrefix + "8080": `{"protocol": "udp"}`,
+	}
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err == nil {
+		t.Fatal("expected UpdateLoadBalancer to reject the udp port config")
+	}
+	for request := range fakeAPI.requests {
+		if request.Method != http.MethodGet {
+			t.Errorf("expected nothing to be changed, got %s %s", request.Method, request.Path)
+		}
 	}
+	assertApplied(15, map[int]linodego.ConfigProtocol{80: linodego.ProtocolTCP, 8080: linodego.ProtocolTCP})
 
-	testcases := []struct {
-		name        string
-		service     *v1.Service
-		nodes       []*v1.Node
-		clusterName string
-		nbIP        string
-		err         error
-	}{
-		{
-			"update load balancer",
-			svc,
-			[]*v1.Node{
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "node-1",
+	svc.Annotations[annLinodePortConfigPrefix+"8080"] = `{"protocol": "http"}`
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	assertApplied(5, map[int]linodego.ConfigProtocol{80: linodego.ProtocolHTTP, 8080: linodego.ProtocolHTTP})
+}
+
+func testEnsureLoadBalancerNoPorts(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "portless",
+			Namespace: "default",
+			UID:       "foobar123",
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: client, zone: "us-west", recorder: recorder}
+	nodeBalancers := len(fakeAPI.nb)
+
+	_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err == nil || err.Error() != "service default/portless has no ports" {
+		t.Fatalf("expected a no ports error, got %v", err)
+	}
+	if len(fakeAPI.nb) != nodeBalancers {
+		t.Errorf("expected no NodeBalancer to be created, found %d new", len(fakeAPI.nb)-nodeBalancers)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonNoServicePorts) {
+			t.Errorf("unexpected event %q", event)
+		}
+	default:
+		t.Errorf("expected a %s event", eventReasonNoServicePorts)
+	}
+}
+
+func testUpdateLoadBalancerNodePortChanged(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	defer func(onChangeOnly bool) { Options.ReconcileNodesOnChangeOnly = onChangeOnly }(Options.ReconcileNodesOnChangeOnly)
+
+	for _, onChangeOnly := range []bool{false, true} {
+		Options.ReconcileNodesOnChangeOnly = onChangeOnly
+
+		svc := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: randString(10),
+				UID:  "foobar123",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{
+					{
+						Name:     "http",
+						Protocol: "TCP",
+						Port:     int32(80),
+						NodePort: int32(30000),
 					},
-					Status: v1.NodeStatus{
-						Addresses: []v1.NodeAddress{
-							{
-								Type:    v1.NodeInternalIP,
-								Address: "127.0.0.1",
-							},
-						},
+					{
+						Name:     "https",
+						Protocol: "TCP",
+						Port:     int32(443),
+						NodePort: int32(30001),
 					},
 				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "node-2",
-					},
-					Status: v1.NodeStatus{
-						Addresses: []v1.NodeAddress{
-							{
-								Type:    v1.NodeInternalIP,
-								Address: "127.0.0.2",
-							},
-						},
-					},
+			},
+		}
+		nodes := []*v1.Node{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
 				},
-				{
-					ObjectMeta: metav1.ObjectMeta{
-						Name: "node-3",
-					},
-					Status: v1.NodeStatus{
-						Addresses: []v1.NodeAddress{
-							{
-								Type:    v1.NodeInternalIP,
-								Address: "127.0.0.3",
-							},
-						},
-					},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+				Status: v1.NodeStatus{
+					Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.2"}},
 				},
 			},
-			"linodelb",
-			getLBStatus.Ingress[0].IP,
-			nil,
-		},
-	}
+		}
 
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), test.clusterName, test.service, test.nodes)
+		lb := &loadbalancers{client: client, zone: "us-west"}
+		fakeClientset := fake.NewSimpleClientset()
+		lb.kubeClient = fakeClientset
+
+		lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+		svc.Status.LoadBalancer = *lbStatus
+		stubService(fakeClientset, svc)
+
+		nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+		if err != nil {
+			t.Fatalf("failed to get NodeBalancer by status: %v", err)
+		}
+		configIDs := func() map[int]int {
+			configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
-			if lbStatus.Ingress[0].IP != test.nbIP {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.nbIP)
-				t.Logf("actual: %v", lbStatus.Ingress)
+			ids := make(map[int]int, len(configs))
+			for _, config := range configs {
+				ids[config.Port] = config.ID
+			}
+			return ids
+		}
+		before := configIDs()
+
+		svc.Spec.Ports[0].NodePort = 31000
+		fakeAPI.requests = make(map[fakeRequest]struct{})
+		if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+			t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+		}
+
+		if after := configIDs(); !reflect.DeepEqual(before, after) {
+			t.Errorf("expected the configs to be rebuilt in place, had %v, now %v", before, after)
+		}
+		for port, nodePort := range map[int]string{80: "31000", 443: "30001"} {
+			nbNodes, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, before[port], nil)
+			if err != nil {
+				t.Fatal(err)
 			}
-			if !reflect.DeepEqual(err, test.err) {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.err)
-				t.Logf("actual: %v", err)
+			if len(nbNodes) != len(nodes) {
+				t.Errorf("expected %d backends for port %d, got %d", len(nodes), port, len(nbNodes))
 			}
-		})
+			for _, n := range nbNodes {
+				if !strings.HasSuffix(n.Address, ":"+nodePort) {
+					t.Errorf("expected the port %d backend %s to use NodePort %s, got %s", port, n.Label, nodePort, n.Address)
+				}
+			}
+		}
+		for request := range fakeAPI.requests {
+			if strings.Contains(request.Path, fmt.Sprintf("/configs/%d/rebuild", before[443])) {
+				t.Error("expected the config for port 443, whose NodePort did not change, not to be rebuilt")
+			}
+		}
+
+		if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+			t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+		}
 	}
 }
 
-func testGetNodeBalancerForServiceIDDoesNotExist(t *testing.T, client *linodego.Client, _ *fakeAPI) {
-	lb := &loadbalancers{client, "us-west", nil}
-	bogusNodeBalancerID := "123456"
-
+func testUpdateLoadBalancerHealthCheckInterval(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "test",
+			Name: randString(10),
 			UID:  "foobar123",
 			Annotations: map[string]string{
-				annLinodeNodeBalancerID: bogusNodeBalancerID,
+				annLinodeHealthCheckInterval: "5",
 			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
+					Name:     "http",
 					Protocol: "TCP",
-					Port:     int32(8443),
+					Port:     int32(80),
 					NodePort: int32(30000),
 				},
 			},
 		},
 	}
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+	}
 
-	_, err := lb.getNodeBalancerForService(context.TODO(), svc)
-	if err == nil {
-		t.Fatal("expected getNodeBalancerForService to return an error")
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
 	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 
-	expectedErr := fmt.Sprintf("%s annotation points to a NodeBalancer that does not exist: LoadBalancer (%s) not found for service (%s)",
-		annLinodeNodeBalancerID, bogusNodeBalancerID, getServiceNn(svc))
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
 
-	if err.Error() != expectedErr {
-		t.Errorf("expected error to be '%s' but got '%s'", expectedErr, err)
+	svc.Annotations[annLinodeHealthCheckInterval] = "10"
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+
+	configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 1 || configs[0].CheckInterval != 10 {
+		t.Fatalf("expected a single config with a check interval of 10, got %+v", configs)
+	}
+
+	updated := false
+	for request := range fakeAPI.requests {
+		switch {
+		case request.Method == http.MethodPut && request.Path == fmt.Sprintf("/nodebalancers/%d/configs/%d", nb.ID, configs[0].ID):
+			updated = true
+		case strings.Contains(request.Path, "/configs") && request.Method != http.MethodGet && request.Method != http.MethodPut:
+			t.Errorf("expected the config to be updated in place, got %s %s", request.Method, request.Path)
+		}
+	}
+	if !updated {
+		t.Error("expected the config to be updated")
+	}
+
+	nbNodes, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nbNodes) != len(nodes) {
+		t.Errorf("expected the config to keep its %d backends, got %d", len(nodes), len(nbNodes))
+	}
+
+	if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
 	}
 }
 
-func testEnsureNewLoadBalancerWithNodeBalancerID(t *testing.T, client *linodego.Client, _ *fakeAPI) {
-	lb := &loadbalancers{client, "us-west", nil}
-	nodeBalancer, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
-		Region: lb.zone,
-	})
+func testUpdateLoadBalancerMinBackendRatio(t *testing.T, client *linodego.Client, fakeAPI *fakeAPI) {
+	defer func(ratio float64) { Options.MinBackendRatio = ratio }(Options.MinBackendRatio)
+	Options.MinBackendRatio = 0.5
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	var nodes []*v1.Node
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: fmt.Sprintf("127.0.0.%d", i+1)}},
+			},
+		})
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: client, zone: "us-west", recorder: recorder}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
 	if err != nil {
-		t.Fatalf("failed to create NodeBalancer: %s", err)
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+	backends := func() []string {
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+		if err != nil || len(configs) != 1 {
+			t.Fatalf("expected a single config, got %d: %v", len(configs), err)
+		}
+		nbNodes, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		labels := make([]string, 0, len(nbNodes))
+		for _, n := range nbNodes {
+			labels = append(labels, n.Label)
+		}
+		sort.Strings(labels)
+		return labels
+	}
+
+	// Dropping 8 of the 10 nodes keeps half of the backends, the remaining nodes and
+	// the first of the dropped ones, and the next reconcile halves them again.
+	for _, expected := range [][]string{
+		{"node-0", "node-1", "node-2", "node-3", "node-4"},
+		{"node-0", "node-1", "node-2"},
+	} {
+		if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes[:2]); err != nil {
+			t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+		}
+		if got := backends(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected backends %v, got %v", expected, got)
+		}
+	}
+
+	var cappedEvents int
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, eventReasonBackendRemovalCapped) {
+			cappedEvents++
+		}
+	}
+	if cappedEvents != 2 {
+		t.Errorf("expected 2 %s events, got %d", eventReasonBackendRemovalCapped, cappedEvents)
+	}
+
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes[:2]); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if got := backends(); !reflect.DeepEqual(got, []string{"node-0", "node-1"}) {
+		t.Errorf("expected the removal to complete, got backends %v", got)
+	}
+
+	if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
 	}
+}
 
+func testUpdateLoadBalancerServiceTags(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "testensure",
+			Name: randString(10),
 			UID:  "foobar123",
 			Annotations: map[string]string{
-				annLinodeNodeBalancerID: strconv.Itoa(nodeBalancer.ID),
+				annLinodeNodeBalancerTags: "team-a, prod",
 			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
+					Name:     "http",
 					Protocol: "TCP",
-					Port:     int32(8443),
+					Port:     int32(80),
 					NodePort: int32(30000),
 				},
 			},
 		},
 	}
-
 	nodes := []*v1.Node{
 		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-1",
-			},
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
 			Status: v1.NodeStatus{
-				Addresses: []v1.NodeAddress{
-					{
-						Type:    v1.NodeInternalIP,
-						Address: "127.0.0.1",
-					},
-				},
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
 			},
 		},
 	}
 
-	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+	hasTag := func(tags []string, tag string) bool {
+		for _, existing := range tags {
+			if existing == tag {
+				return true
+			}
+		}
+		return false
+	}
 
-	if _, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+	for _, tag := range []string{"ccm:tag=team-a", "ccm:tag=prod", makeClusterTag("linodelb")} {
+		if !hasTag(nb.Tags, tag) {
+			t.Errorf("expected the new NodeBalancer to be tagged %q, got %v", tag, nb.Tags)
+		}
+	}
+
+	tags := append([]string{"team:web"}, nb.Tags...)
+	if _, err = client.UpdateNodeBalancer(context.TODO(), nb.ID, linodego.NodeBalancerUpdateOptions{
+		Tags: &tags,
+	}); err != nil {
 		t.Fatal(err)
 	}
+
+	svc.Annotations[annLinodeNodeBalancerTags] = "team-b,prod"
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+
+	nb, err = lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+	for _, tag := range []string{"ccm:tag=team-b", "ccm:tag=prod", "team:web", makeClusterTag("linodelb")} {
+		if !hasTag(nb.Tags, tag) {
+			t.Errorf("expected the NodeBalancer to be tagged %q, got %v", tag, nb.Tags)
+		}
+	}
+	if hasTag(nb.Tags, "ccm:tag=team-a") {
+		t.Errorf("expected the removed tag to be dropped, got %v", nb.Tags)
+	}
+	if metadata, _ := parseConfigMetadataTags(nb.Tags); !metadata[80].isManaged() {
+		t.Errorf("expected the config metadata to be kept, got %v", nb.Tags)
+	}
+
+	if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
 }
 
-func testEnsureNewLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+func testEnsureLoadBalancerNodeBalancerAnnotations(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "testensure",
+			Name: randString(10),
 			UID:  "foobar123",
-			Annotations: map[string]string{
-				annLinodeDefaultProtocol:           "tcp",
-				annLinodePortConfigPrefix + "8443": `{ "protocol": "https", "tls-secret-name": "tls-secret"}`,
-			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
-					Protocol: "TCP",
-					Port:     int32(8443),
-					NodePort: int32(30000),
-				},
-				{
-					Name:     "test2",
+					Name:     "http",
 					Protocol: "TCP",
 					Port:     int32(80),
-					NodePort: int32(30001),
+					NodePort: int32(30000),
 				},
 			},
 		},
 	}
 
-	nodes := []*v1.Node{
-		{
-			ObjectMeta: metav1.ObjectMeta{
-				Name: "node-1",
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+	stubService(fakeClientset, svc)
+
+	expectAnnotations := func(id, region string) *v1.Service {
+		t.Helper()
+		latest, err := fakeClientset.CoreV1().Services("").Get(context.TODO(), svc.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latest.Annotations[annLinodeStatusNodeBalancerID] != id || latest.Annotations[annLinodeStatusNodeBalancerRegion] != region {
+			t.Errorf("expected NodeBalancer annotations %q and %q, got %v", id, region, latest.Annotations)
+		}
+		return latest
+	}
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	nb, err := lb.getNodeBalancerByIPv4(context.TODO(), svc, lbStatus.Ingress[0].IP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc = expectAnnotations(strconv.Itoa(nb.ID), "us-west")
+	svc.Status.LoadBalancer = *lbStatus
+
+	// A NodeBalancer deleted outside the CCM is recreated, and the annotations follow.
+	if err = client.DeleteNodeBalancer(context.TODO(), nb.ID); err != nil {
+		t.Fatal(err)
+	}
+	lbStatus, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	recreated, err := lb.getNodeBalancerByIPv4(context.TODO(), svc, lbStatus.Ingress[0].IP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recreated.ID == nb.ID {
+		t.Fatalf("expected a new NodeBalancer, got %d again", nb.ID)
+	}
+	svc = expectAnnotations(strconv.Itoa(recreated.ID), "us-west")
+	svc.Status.LoadBalancer = *lbStatus
+
+	if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
+	expectAnnotations("", "")
+}
+
+func testEnsureLoadBalancerConfigIDAnnotation(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeAnnotateConfigIDs: "true",
 			},
-			Status: v1.NodeStatus{
-				Addresses: []v1.NodeAddress{
-					{
-						Type:    v1.NodeInternalIP,
-						Address: "127.0.0.1",
-					},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
 				},
 			},
 		},
 	}
-	lb := &loadbalancers{client, "us-west", nil}
-	lb.kubeClient = fake.NewSimpleClientset()
-	addTLSSecret(t, lb.kubeClient)
 
-	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+	stubService(fakeClientset, svc)
 
-	_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
-	if err != nil {
-		t.Fatal(err)
+	// ensure reconciles the latest version of the service, with its ports set to ports,
+	// and checks its config ID annotation against the NodeBalancer's configs.
+	ensure := func(ports ...v1.ServicePort) {
+		t.Helper()
+		latest, err := fakeClientset.CoreV1().Services("").Get(context.TODO(), svc.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		latest.Spec.Ports = ports
+		latest.Status = svc.Status
+		lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", latest, nil)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+		svc.Status.LoadBalancer = *lbStatus
+
+		nb, err := lb.getNodeBalancerByIPv4(context.TODO(), latest, lbStatus.Ingress[0].IP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := make(map[string]int)
+		for _, config := range configs {
+			expected[strconv.Itoa(config.Port)] = config.ID
+		}
+		if len(expected) != len(ports) {
+			t.Fatalf("expected %d configs, got %v", len(ports), configs)
+		}
+
+		annotated, err := fakeClientset.CoreV1().Services("").Get(context.TODO(), svc.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var configIDs map[string]int
+		if err = json.Unmarshal([]byte(annotated.Annotations[annLinodeStatusNodeBalancerConfigIDs]), &configIDs); err != nil {
+			t.Fatalf("failed to parse the config ID annotation %q: %s", annotated.Annotations[annLinodeStatusNodeBalancerConfigIDs], err)
+		}
+		if !reflect.DeepEqual(configIDs, expected) {
+			t.Errorf("expected config IDs %v, got %v", expected, configIDs)
+		}
 	}
+
+	httpPort := v1.ServicePort{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}
+	httpsPort := v1.ServicePort{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30001)}
+	ensure(httpPort)
+	ensure(httpPort, httpsPort)
+	ensure(httpsPort)
 }
 
-func testGetLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
-	lb := &loadbalancers{client, "us-west", nil}
+func testUpdateLoadBalancerNotReadyNodeGracePeriod(t *testing.T, client *linodego.Client, _ *fakeAPI) {
+	defer func(grace time.Duration) { Options.NotReadyNodeGracePeriod = grace }(Options.NotReadyNodeGracePeriod)
+	Options.NotReadyNodeGracePeriod = time.Minute
+
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "test",
+			Name: randString(10),
 			UID:  "foobar123",
-			Annotations: map[string]string{
-				annLinodeDefaultProtocol: "tcp",
-			},
 		},
 		Spec: v1.ServiceSpec{
 			Ports: []v1.ServicePort{
 				{
-					Name:     "test",
+					Name:     "http",
 					Protocol: "TCP",
 					Port:     int32(80),
 					NodePort: int32(30000),
@@ -1571,246 +5459,152 @@ func testGetLoadBalancer(t *testing.T, client *linodego.Client, _ *fakeAPI) {
 			},
 		},
 	}
+	var nodes []*v1.Node
+	for i := 0; i < 3; i++ {
+		nodes = append(nodes, &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)},
+			Status: v1.NodeStatus{
+				Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: fmt.Sprintf("127.0.0.%d", i+1)}},
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		})
+	}
 
-	configs := []*linodego.NodeBalancerConfigCreateOptions{}
-	nb, err := lb.createNodeBalancer(context.TODO(), "linodelb", svc, configs)
+	lb := &loadbalancers{client: client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset(nodes[0], nodes[1], nodes[2])
+	lb.kubeClient = fakeClientset
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
 	if err != nil {
-		t.Fatal(err)
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
 	}
-	defer func() { _ = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc) }()
-
-	lbStatus := makeLoadBalancerStatus(nb)
 	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
 
-	testcases := []struct {
-		name        string
-		service     *v1.Service
-		clusterName string
-		found       bool
-		err         error
-	}{
-		{
-			"Load balancer exists",
-			svc,
-			"linodelb",
-			true,
-			nil,
-		},
-		{
-			"Load balancer not exists",
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatalf("failed to get NodeBalancer by status: %v", err)
+	}
+	backends := func() map[string]linodego.NodeMode {
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+		if err != nil || len(configs) != 1 {
+			t.Fatalf("expected a single config, got %d: %v", len(configs), err)
+		}
+		nbNodes, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		modes := make(map[string]linodego.NodeMode, len(nbNodes))
+		for _, n := range nbNodes {
+			modes[n.Label] = n.Mode
+		}
+		return modes
+	}
+	setReady := func(node *v1.Node, status v1.ConditionStatus, since time.Time) {
+		node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(since)}}
+		if _, err := fakeClientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-			&v1.Service{
-				ObjectMeta: metav1.ObjectMeta{
-					Name: "notexists",
-					UID:  "notexists123",
-					Annotations: map[string]string{
-						annLinodeDefaultProtocol: "tcp",
-					},
-				},
-				Spec: v1.ServiceSpec{
-					Ports: []v1.ServicePort{
-						{
-							Name:     "test",
-							Protocol: "TCP",
-							Port:     int32(80),
-							NodePort: int32(30000),
-						},
-					},
-				},
-			},
-			"linodelb",
-			false,
-			nil,
-		},
+	// node-1 blips NotReady, so the service controller stops passing it, and it is
+	// drained rather than removed. node-2 has been deleted, so it is removed.
+	setReady(nodes[1], v1.ConditionFalse, time.Now())
+	if err = fakeClientset.CoreV1().Nodes().Delete(context.TODO(), "node-2", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes[:1]); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	expected := map[string]linodego.NodeMode{"node-0": linodego.ModeAccept, "node-1": linodego.ModeDrain}
+	if got := backends(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected backends %v while node-1 is NotReady, got %v", expected, got)
 	}
 
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
+	// node-1 recovers within the grace period and accepts connections again
+	setReady(nodes[1], v1.ConditionTrue, time.Now())
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes[:2]); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	expected = map[string]linodego.NodeMode{"node-0": linodego.ModeAccept, "node-1": linodego.ModeAccept}
+	if got := backends(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected backends %v once node-1 is Ready, got %v", expected, got)
+	}
 
-			_, found, err := lb.GetLoadBalancer(context.TODO(), test.clusterName, test.service)
-			if found != test.found {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.found)
-				t.Logf("actual: %v", found)
-			}
-			if !reflect.DeepEqual(err, test.err) {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.err)
-				t.Logf("actual: %v", err)
-			}
-		})
+	// node-1 stays NotReady for longer than the grace period and is removed
+	setReady(nodes[1], v1.ConditionFalse, time.Now().Add(-2*time.Minute))
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes[:1]); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	expected = map[string]linodego.NodeMode{"node-0": linodego.ModeAccept}
+	if got := backends(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected backends %v once node-1's grace period is over, got %v", expected, got)
 	}
 }
 
-func Test_getPortConfigAnnotation(t *testing.T) {
+func TestUpdateLoadBalancerProtocolSwitchInPlace(t *testing.T) {
+	var configRequests []string
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && strings.Contains(r.URL.Path, "/configs") {
+			configRequests = append(configRequests, r.Method+" "+r.URL.Path)
+		}
+		fakeAPI.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
 	svc := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "test",
+			Name:        "test",
+			UID:         "foobar123",
+			Annotations: map[string]string{},
 		},
 		Spec: v1.ServiceSpec{
-			Ports: []v1.ServicePort{
-				{
-					Name:     "test",
-					Protocol: "TCP",
-					Port:     int32(80),
-					NodePort: int32(30000),
-				},
-			},
+			Ports: []v1.ServicePort{{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30000)}},
 		},
 	}
-
-	testcases := []struct {
-		name     string
-		ann      map[string]string
-		expected portConfigAnnotation
-		err      string
-	}{
-		{
-			name: "Test single port annotation",
-			ann:  map[string]string{annLinodePortConfigPrefix + "443": `{ "tls-secret-name": "prod-app-tls", "protocol": "https" }`},
-			expected: portConfigAnnotation{
-				TLSSecretName: "prod-app-tls",
-				Protocol:      "https",
-			},
-			err: "",
-		},
-		{
-			name: "Test multiple port annotation",
-			ann: map[string]string{
-				annLinodePortConfigPrefix + "443": `{ "tls-secret-name": "prod-app-tls", "protocol": "https" }`,
-				annLinodePortConfigPrefix + "80":  `{ "protocol": "http" }`,
-			},
-			expected: portConfigAnnotation{
-				TLSSecretName: "prod-app-tls",
-				Protocol:      "https",
-			},
-			err: "",
-		},
-		{
-			name: "Test no port annotation",
-			ann:  map[string]string{},
-			expected: portConfigAnnotation{
-				Protocol: "",
-			},
-			err: "",
-		},
+	nodes := []*v1.Node{
 		{
-			name: "Test invalid json",
-			ann: map[string]string{
-				annLinodePortConfigPrefix + "443": `{ "tls-secret-name": "prod-app-tls" `,
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
 			},
-			expected: portConfigAnnotation{},
-			err:      "unexpected end of JSON input",
 		},
 	}
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			svc.Annotations = test.ann
-			ann, err := getPortConfigAnnotation(svc, 443)
-			if !reflect.DeepEqual(ann, test.expected) {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.expected)
-				t.Logf("actual: %v", ann)
-			}
-			if test.err != "" && test.err != err.Error() {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.err)
-				t.Logf("actual: %v", err)
-			}
-		})
-	}
-}
 
-func Test_getTLSCertInfo(t *testing.T) {
-	kubeClient := fake.NewSimpleClientset()
-	addTLSSecret(t, kubeClient)
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	lb.kubeClient = fake.NewSimpleClientset(svc)
+	addTLSSecret(t, lb.kubeClient)
 
-	testcases := []struct {
-		name       string
-		portConfig portConfig
-		cert       string
-		key        string
-		err        error
-	}{
-		{
-			name: "Test valid Cert info",
-			portConfig: portConfig{
-				TLSSecretName: "tls-secret",
-				Port:          8080,
-			},
-			cert: testCert,
-			key:  testKey,
-			err:  nil,
-		},
-		{
-			name: "Test unspecified Cert info",
-			portConfig: portConfig{
-				Port: 8080,
-			},
-			cert: "",
-			key:  "",
-			err:  fmt.Errorf("TLS secret name for port 8080 is not specified"),
-		},
-		{
-			name: "Test blank Cert info",
-			portConfig: portConfig{
-				TLSSecretName: "",
-				Port:          8080,
-			},
-			cert: "",
-			key:  "",
-			err:  fmt.Errorf("TLS secret name for port 8080 is not specified"),
-		},
-		{
-			name: "Test no secret found",
-			portConfig: portConfig{
-				TLSSecretName: "secret",
-				Port:          8080,
-			},
-			cert: "",
-			key:  "",
-			err: errors.NewNotFound(schema.GroupResource{
-				Group:    "",
-				Resource: "secrets",
-			}, "secret"), /*{}(`secrets "secret" not found`)*/
-		},
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil || len(before) != 1 || before[0].Protocol != linodego.ProtocolTCP {
+		t.Fatalf("expected one tcp config, got %v (%v)", before, err)
 	}
 
-	for _, test := range testcases {
-		t.Run(test.name, func(t *testing.T) {
-			cert, key, err := getTLSCertInfo(context.TODO(), kubeClient, "", test.portConfig)
-			if cert != test.cert {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.cert)
-				t.Logf("actual: %v", cert)
-			}
-			if key != test.key {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.key)
-				t.Logf("actual: %v", key)
-			}
-			if !reflect.DeepEqual(err, test.err) {
-				t.Error("unexpected error")
-				t.Logf("expected: %v", test.err)
-				t.Logf("actual: %v", err)
-			}
-		})
+	svc.Annotations[annLinodePortConfigPrefix+"443"] = `{"protocol": "https", "tls-secret-name": "tls-secret"}`
+	configRequests = nil
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
 	}
-}
 
-func addTLSSecret(t *testing.T, kubeClient kubernetes.Interface) {
-	_, err := kubeClient.CoreV1().Secrets("").Create(context.TODO(), &v1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "tls-secret",
-		},
-		Data: map[string][]byte{
-			v1.TLSCertKey:       []byte(testCert),
-			v1.TLSPrivateKeyKey: []byte(testKey),
-		},
-		StringData: nil,
-		Type:       "kubernetes.io/tls",
-	}, metav1.CreateOptions{})
-	if err != nil {
-		t.Fatalf("failed to add TLS secret: %s\n", err)
+	expected := []string{fmt.Sprintf("PUT /nodebalancers/%d/configs/%d", nb.ID, before[0].ID)}
+	if !reflect.DeepEqual(configRequests, expected) {
+		t.Errorf("expected the config to be updated in place with %v, got %v", expected, configRequests)
+	}
+	after, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil || len(after) != 1 || after[0].ID != before[0].ID || after[0].Protocol != linodego.ProtocolHTTPS {
+		t.Errorf("expected config %d to now be https, got %v (%v)", before[0].ID, after, err)
 	}
 }
@@ -0,0 +1,66 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/linode/linodego"
+)
+
+// unknownRegionError is returned when a region is not among those the Linode API lists.
+type unknownRegionError struct {
+	region string
+}
+
+func (e unknownRegionError) Error() string {
+	return fmt.Sprintf("unknown region %q", e.region)
+}
+
+// lookupCache caches the results of Linode API lookups which rarely change, such as the
+// list of regions, for Options.LookupCacheTTL, so that they are not repeated on every
+// reconcile. It is safe for concurrent use.
+type lookupCache struct {
+	client *linodego.Client
+
+	mu             sync.Mutex
+	regions        []linodego.Region
+	regionsFetched time.Time
+}
+
+func newLookupCache(client *linodego.Client) *lookupCache {
+	return &lookupCache{client: client}
+}
+
+// getRegions returns the regions the Linode API lists, fetching them if they have not
+// been fetched within Options.LookupCacheTTL or refresh is set.
+func (c *lookupCache) getRegions(ctx context.Context, refresh bool) ([]linodego.Region, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !refresh && c.regions != nil && time.Since(c.regionsFetched) < Options.LookupCacheTTL {
+		return c.regions, nil
+	}
+	regions, err := c.client.ListRegions(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list regions: %w", err)
+	}
+	c.regions, c.regionsFetched = regions, time.Now()
+	return regions, nil
+}
+
+// validateRegion returns an unknownRegionError if region is not listed by the Linode API,
+// refetching the list of regions first if refresh is set.
+func (c *lookupCache) validateRegion(ctx context.Context, region string, refresh bool) error {
+	regions, err := c.getRegions(ctx, refresh)
+	if err != nil {
+		return err
+	}
+	for _, r := range regions {
+		if r.ID == region {
+			return nil
+		}
+	}
+	return unknownRegionError{region: region}
+}
@@ -0,0 +1,62 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/linode/linodego"
+)
+
+func TestLookupCacheValidateRegion(t *testing.T) {
+	defer func(ttl time.Duration) { Options.LookupCacheTTL = ttl }(Options.LookupCacheTTL)
+	Options.LookupCacheTTL = time.Hour
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/regions" {
+			t.Errorf("unexpected request for %s", r.URL.Path)
+		}
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data": [{"id": "us-east"}, {"id": "us-west"}], "page": 1, "pages": 1, "results": 2}`))
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lookups := newLookupCache(&client)
+
+	expectRequests := func(expected int32) {
+		t.Helper()
+		if got := atomic.LoadInt32(&requests); got != expected {
+			t.Errorf("expected %d region lookups, got %d", expected, got)
+		}
+	}
+
+	for _, region := range []string{"us-east", "us-west"} {
+		if err := lookups.validateRegion(context.TODO(), region, false); err != nil {
+			t.Errorf("expected region %s to be valid, got %s", region, err)
+		}
+	}
+	expectRequests(1)
+
+	if _, ok := lookups.validateRegion(context.TODO(), "eu-nowhere", false).(unknownRegionError); !ok {
+		t.Error("expected an unknownRegionError for an unlisted region")
+	}
+	expectRequests(1)
+
+	if err := lookups.validateRegion(context.TODO(), "us-east", true); err != nil {
+		t.Error(err)
+	}
+	expectRequests(2)
+
+	lookups.regionsFetched = time.Now().Add(-Options.LookupCacheTTL)
+	if err := lookups.validateRegion(context.TODO(), "us-east", false); err != nil {
+		t.Error(err)
+	}
+	expectRequests(3)
+}
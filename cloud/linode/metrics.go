@@ -0,0 +1,77 @@
+package linode
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const metricsSubsystem = "linode_ccm"
+
+const (
+	reconcileOperationEnsure = "ensure"
+	reconcileOperationUpdate = "update"
+	reconcileOperationDelete = "delete"
+
+	reconcileResultSuccess = "success"
+	reconcileResultError   = "error"
+)
+
+// reconcileDurationBuckets suit reconciles whose latency is dominated by Linode API
+// calls: a handful of requests take from a fraction of a second to a few seconds, and
+// linodego backs off for up to 30 seconds on rate limiting before giving up.
+var reconcileDurationBuckets = []float64{0.25, 0.5, 1, 2, 4, 8, 15, 30, 60, 120}
+
+// Reconcile metrics are labeled by namespace and load balancer class rather than by
+// service, so their cardinality stays bounded however many services there are.
+var (
+	reconcileTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "loadbalancer_reconcile_total",
+			Help:           "Number of LoadBalancer service reconciles, by operation and result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation", "result", "namespace", "load_balancer_class"},
+	)
+
+	reconcileDuration = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      metricsSubsystem,
+			Name:           "loadbalancer_reconcile_duration_seconds",
+			Help:           "End-to-end duration of LoadBalancer service reconciles, by operation.",
+			Buckets:        reconcileDurationBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"operation", "namespace", "load_balancer_class"},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(reconcileTotal, reconcileDuration)
+}
+
+// getLoadBalancerClass returns the load balancer class to label the service's metrics
+// with. The Kubernetes API this CCM is built against predates spec.loadBalancerClass,
+// so every service it manages has the default, empty, class.
+func getLoadBalancerClass(service *v1.Service) string {
+	return ""
+}
+
+// observeReconcile records a reconcile of service by operation which began at start and
+// finished with err. Reconciles held back by Options.MinReconcileInterval are not
+// recorded.
+func observeReconcile(operation string, service *v1.Service, start time.Time, err error) {
+	if _, ok := err.(reconcileIntervalError); ok {
+		return
+	}
+	result := reconcileResultSuccess
+	if err != nil {
+		result = reconcileResultError
+	}
+	class := getLoadBalancerClass(service)
+	reconcileTotal.WithLabelValues(operation, result, service.Namespace, class).Inc()
+	reconcileDuration.WithLabelValues(operation, service.Namespace, class).Observe(time.Since(start).Seconds())
+}
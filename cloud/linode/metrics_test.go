@@ -0,0 +1,174 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestReconcileMetrics(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
+	newService := func(protocol v1.Protocol) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      randString(10),
+				Namespace: "metrics",
+				UID:       "foobar123",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{
+					{
+						Name:     "test",
+						Protocol: protocol,
+						Port:     int32(80),
+						NodePort: int32(30000),
+					},
+				},
+			},
+		}
+	}
+
+	counterValue := func(result string) float64 {
+		value, err := testutil.GetCounterMetricValue(reconcileTotal.WithLabelValues(reconcileOperationEnsure, result, "metrics", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value
+	}
+	successes, failures := counterValue(reconcileResultSuccess), counterValue(reconcileResultError)
+
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", newService(v1.ProtocolTCP), nil); err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", newService(v1.ProtocolUDP), nil); err == nil {
+		t.Fatal("expected EnsureLoadBalancer to reject a UDP port")
+	}
+
+	if got := counterValue(reconcileResultSuccess); got != successes+1 {
+		t.Errorf("expected %v successful reconciles, got %v", successes+1, got)
+	}
+	if got := counterValue(reconcileResultError); got != failures+1 {
+		t.Errorf("expected %v failed reconciles, got %v", failures+1, got)
+	}
+
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"linode_ccm_loadbalancer_reconcile_total":            "load_balancer_class,namespace,operation,result",
+		"linode_ccm_loadbalancer_reconcile_duration_seconds": "load_balancer_class,namespace,operation",
+	}
+	for _, family := range families {
+		labels, ok := expected[family.GetName()]
+		if !ok {
+			continue
+		}
+		delete(expected, family.GetName())
+		for _, metric := range family.GetMetric() {
+			var names []string
+			for _, label := range metric.GetLabel() {
+				names = append(names, label.GetName())
+			}
+			sort.Strings(names)
+			if got := strings.Join(names, ","); got != labels {
+				t.Errorf("expected %s to have labels %s, got %s", family.GetName(), labels, got)
+			}
+		}
+	}
+	for name := range expected {
+		t.Errorf("expected metric %s to be registered", name)
+	}
+}
+
+// reconcileDurationSamples returns the number of reconcile durations observed for
+// operation in namespace.
+func reconcileDurationSamples(t *testing.T, operation, namespace string) uint64 {
+	t.Helper()
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "linode_ccm_loadbalancer_reconcile_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["operation"] == operation && labels["namespace"] == namespace {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestReconcileDurationHistogram(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	fakeClientset := fake.NewSimpleClientset()
+	lb := &loadbalancers{client: &client, zone: "us-west", kubeClient: fakeClientset}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      randString(10),
+			Namespace: "histogram",
+			UID:       "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: v1.ProtocolTCP,
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *status
+	if _, err := fakeClientset.CoreV1().Services(svc.Namespace).Create(context.TODO(), svc, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	if err := lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
+
+	for _, operation := range []string{reconcileOperationEnsure, reconcileOperationUpdate, reconcileOperationDelete} {
+		if got := reconcileDurationSamples(t, operation, "histogram"); got != 1 {
+			t.Errorf("expected the %s histogram to observe 1 sample, got %d", operation, got)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package linode
+
+import (
+	"fmt"
+)
+
+const (
+	// noBackendNodesCreate creates a service's NodeBalancer even when none of its nodes
+	// are eligible backends, while noBackendNodesDefer holds back its creation until
+	// some are.
+	noBackendNodesCreate = "create"
+	noBackendNodesDefer  = "defer"
+)
+
+// validateNoBackendNodesPolicy returns an error if policy is not a usable
+// --no-backend-nodes-policy.
+func validateNoBackendNodesPolicy(policy string) error {
+	switch policy {
+	case noBackendNodesCreate, noBackendNodesDefer:
+		return nil
+	default:
+		return fmt.Errorf("--no-backend-nodes-policy must be %q or %q, got %q", noBackendNodesCreate, noBackendNodesDefer, policy)
+	}
+}
+
+// noBackendNodesError is returned when the creation of a service's NodeBalancer is
+// deferred because none of its nodes are eligible backends. The service controller
+// retries the service, so the NodeBalancer is created once eligible nodes appear.
+type noBackendNodesError struct {
+	serviceNn string
+	nodes     int
+}
+
+func (e noBackendNodesError) Error() string {
+	return fmt.Sprintf("deferring the creation of a NodeBalancer for service (%s) until it has eligible backend nodes; none of the %d nodes are, will retry",
+		e.serviceNn, e.nodes)
+}
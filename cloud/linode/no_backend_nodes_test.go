@@ -0,0 +1,86 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEnsureLoadBalancerDefersWithoutBackendNodes(t *testing.T) {
+	defer func(policy string) { Options.NoBackendNodesPolicy = policy }(Options.NoBackendNodesPolicy)
+	Options.NoBackendNodesPolicy = noBackendNodesDefer
+
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "deferred",
+			Namespace: "default",
+			UID:       "foobar123",
+			Annotations: map[string]string{
+				annLinodeBackendNodeSelector: "pool=web",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}},
+		},
+	}
+	node := func(name, pool string) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": pool}},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+			},
+		}
+	}
+	nodeBalancerCreated := func() bool {
+		for request := range fakeAPI.requests {
+			if request.Method == http.MethodPost && request.Path == "/nodebalancers" {
+				return true
+			}
+		}
+		return false
+	}
+
+	recorder := record.NewFakeRecorder(100)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+
+	// No node matches the backend node selector, so creation is postponed
+	_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, []*v1.Node{node("node-1", "batch")})
+	if _, ok := err.(noBackendNodesError); !ok {
+		t.Fatalf("expected a noBackendNodesError, got %v", err)
+	}
+	if nodeBalancerCreated() {
+		t.Error("expected no NodeBalancer to be created without eligible backend nodes")
+	}
+
+	// Once an eligible node appears the NodeBalancer is created
+	status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, []*v1.Node{node("node-1", "batch"), node("node-2", "web")})
+	if err != nil {
+		t.Fatalf("expected the NodeBalancer to be created, got %s", err)
+	}
+	if len(status.Ingress) != 1 || !nodeBalancerCreated() {
+		t.Errorf("expected the NodeBalancer to be created, got status %v", status.Ingress)
+	}
+
+	close(recorder.Events)
+	var deferredEvent bool
+	for event := range recorder.Events {
+		deferredEvent = deferredEvent || (strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonNoBackendNodes) && strings.Contains(event, "deferring"))
+	}
+	if !deferredEvent {
+		t.Errorf("expected a %s warning event for the deferred creation", eventReasonNoBackendNodes)
+	}
+}
@@ -0,0 +1,199 @@
+package linode
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// annLinodeNodeBalancerGroup names a group of services which may share the
+	// NodeBalancer they adopt through the nodebalancer-id annotation. Services which
+	// adopt a NodeBalancer already claimed by a service outside their group are not
+	// reconciled onto it.
+	annLinodeNodeBalancerGroup = "service.beta.kubernetes.io/linode-loadbalancer-nodebalancer-group"
+
+	eventReasonNodeBalancerClaimed = "NodeBalancerClaimed"
+)
+
+// nodeBalancerClaimedError is returned for a service adopting a NodeBalancer which
+// another service, outside the service's group, has already claimed.
+type nodeBalancerClaimedError struct {
+	nodeBalancerID int
+	serviceNn      string
+	claimedBy      string
+}
+
+func (e nodeBalancerClaimedError) Error() string {
+	return fmt.Sprintf("NodeBalancer (%d) adopted by service (%s) through %s is already claimed by service (%s); services may only share a NodeBalancer when they set the same %s annotation",
+		e.nodeBalancerID, e.serviceNn, annLinodeNodeBalancerID, e.claimedBy, annLinodeNodeBalancerGroup)
+}
+
+// nodeBalancerClaim records the services which have adopted a NodeBalancer, with the
+// ports each of them serves on it.
+type nodeBalancerClaim struct {
+	group    string
+	services map[string][]int
+}
+
+// nodeBalancerClaims tracks, in memory, which services have adopted which NodeBalancers
+// through the nodebalancer-id annotation, so that two services do not fight over the
+// configs of one NodeBalancer. Claims are lost when the CCM restarts; the first service
+// reconciled afterwards claims the NodeBalancer again, and the configs of the services
+// sharing it are known from the owners recorded in its tags until they are reconciled
+// too. The zero value is ready to use.
+type nodeBalancerClaims struct {
+	mu     sync.Mutex
+	claims map[int]*nodeBalancerClaim
+}
+
+// claim records that the service serviceNn, in group, adopts the NodeBalancer with the
+// given ID and serves ports on it, releasing any other NodeBalancer it had claimed. It
+// returns a nodeBalancerClaimedError if the NodeBalancer is claimed by a service
+// outside group. Services without a group share with no one.
+func (c *nodeBalancerClaims) claim(id int, serviceNn, group string, ports []int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.claims == nil {
+		c.claims = make(map[int]*nodeBalancerClaim)
+	}
+	for otherID := range c.claims {
+		if otherID != id {
+			c.releaseLocked(otherID, serviceNn)
+		}
+	}
+
+	claim, ok := c.claims[id]
+	if !ok {
+		claim = &nodeBalancerClaim{services: make(map[string][]int)}
+		c.claims[id] = claim
+	}
+	if others := claim.othersLocked(serviceNn); len(others) > 0 && (group == "" || group != claim.group) {
+		return nodeBalancerClaimedError{nodeBalancerID: id, serviceNn: serviceNn, claimedBy: others[0]}
+	}
+	claim.group = group
+	claim.services[serviceNn] = ports
+	return nil
+}
+
+// release forgets any NodeBalancer claimed by the service serviceNn.
+func (c *nodeBalancerClaims) release(serviceNn string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id := range c.claims {
+		c.releaseLocked(id, serviceNn)
+	}
+}
+
+func (c *nodeBalancerClaims) releaseLocked(id int, serviceNn string) {
+	claim := c.claims[id]
+	delete(claim.services, serviceNn)
+	if len(claim.services) == 0 {
+		delete(c.claims, id)
+	}
+}
+
+// sharedWith returns the services sharing the NodeBalancer with the given ID with
+// serviceNn, sorted.
+func (c *nodeBalancerClaims) sharedWith(id int, serviceNn string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	claim, ok := c.claims[id]
+	if !ok {
+		return nil
+	}
+	return claim.othersLocked(serviceNn)
+}
+
+// sharedPorts returns the ports served on the NodeBalancer with the given ID by the
+// services sharing it with serviceNn, whose configs serviceNn must leave alone.
+func (c *nodeBalancerClaims) sharedPorts(id int, serviceNn string) []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	claim, ok := c.claims[id]
+	if !ok {
+		return nil
+	}
+	var ports []int
+	for _, other := range claim.othersLocked(serviceNn) {
+		ports = append(ports, claim.services[other]...)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// othersLocked returns the services other than serviceNn holding the claim, sorted.
+func (claim *nodeBalancerClaim) othersLocked(serviceNn string) []string {
+	var others []string
+	for other := range claim.services {
+		if other != serviceNn {
+			others = append(others, other)
+		}
+	}
+	sort.Strings(others)
+	return others
+}
+
+// claimAdoptedNodeBalancer claims the NodeBalancer with the given ID, adopted through
+// the nodebalancer-id annotation, for service, recording a warning event if another
+// service has already claimed it.
+func (l *loadbalancers) claimAdoptedNodeBalancer(service *v1.Service, id int) error {
+	group, _ := getServiceAnnotation(service, annLinodeNodeBalancerGroup)
+	ports := make([]int, 0, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		ports = append(ports, int(port.Port))
+	}
+
+	serviceNn := getServiceNn(service)
+	if err := l.claims.claim(id, serviceNn, group, ports); err != nil {
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonNodeBalancerClaimed, "%s", err)
+		return err
+	}
+	return nil
+}
+
+// sharedPorts returns the ports served on nb by the services sharing it with service,
+// whose configs service must leave alone. They are read from the owners recorded in
+// nb's config metadata, which outlive the CCM, as well as from the claims of the
+// services reconciled since it started. A service in a group also leaves alone the
+// configs recorded before owners were, other than those of its own ports, as they may
+// be its peers'.
+func (l *loadbalancers) sharedPorts(service *v1.Service, nb *linodego.NodeBalancer) []int {
+	own := make(map[int]bool, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		own[int(port.Port)] = true
+	}
+	_, grouped := getServiceAnnotation(service, annLinodeNodeBalancerGroup)
+	owner := serviceOwner(service)
+
+	shared := make(map[int]bool)
+	for _, port := range l.claims.sharedPorts(nb.ID, getServiceNn(service)) {
+		shared[port] = true
+	}
+	metadata, _ := parseConfigMetadataTags(nb.Tags)
+	for port, m := range metadata {
+		if (m.Owner != "" && m.Owner != owner) || (m.Owner == "" && grouped && !own[port]) {
+			shared[port] = true
+		}
+	}
+
+	ports := make([]int, 0, len(shared))
+	for port := range shared {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// sharesNodeBalancer reports whether other services serve ports on nb, or have claimed
+// it, so that it must not be deleted along with service.
+func (l *loadbalancers) sharesNodeBalancer(service *v1.Service, nb *linodego.NodeBalancer) bool {
+	return len(l.claims.sharedWith(nb.ID, getServiceNn(service))) > 0 || len(l.sharedPorts(service, nb)) > 0
+}
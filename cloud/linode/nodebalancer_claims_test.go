@@ -0,0 +1,179 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEnsureLoadBalancerNodeBalancerClaims(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	newService := func(name, group string, id int, port int32) *v1.Service {
+		svc := &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(name),
+				Annotations: map[string]string{
+					annLinodeNodeBalancerID: strconv.Itoa(id),
+				},
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{Name: "test", Protocol: "TCP", Port: port, NodePort: 30000 + port}},
+			},
+		}
+		if group != "" {
+			svc.Annotations[annLinodeNodeBalancerGroup] = group
+		}
+		return svc
+	}
+	configPorts := func(t *testing.T, id int) []int {
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ports []int
+		for _, config := range configs {
+			ports = append(ports, config.Port)
+		}
+		return ports
+	}
+
+	t.Run("conflicting claim is refused", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(10)
+		lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+		nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first, second := newService("first", "", nb.ID, 80), newService("second", "", nb.ID, 443)
+
+		status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", first, nil)
+		if err != nil {
+			t.Fatalf("expected the first service to claim the NodeBalancer, got %s", err)
+		}
+		first.Status.LoadBalancer = *status
+		second.Status.LoadBalancer = *status
+		_, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", second, nil)
+		claimedErr, ok := err.(nodeBalancerClaimedError)
+		if !ok {
+			t.Fatalf("expected a nodeBalancerClaimedError, got %v", err)
+		}
+		if claimedErr.claimedBy != getServiceNn(first) {
+			t.Errorf("expected the NodeBalancer to be claimed by %s, got %s", getServiceNn(first), claimedErr.claimedBy)
+		}
+		if ports := configPorts(t, nb.ID); len(ports) != 1 || ports[0] != 80 {
+			t.Errorf("expected only the first service's config, got ports %v", ports)
+		}
+
+		// Deleting the refused service leaves the NodeBalancer to the first
+		if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", second); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = client.GetNodeBalancer(context.TODO(), nb.ID); err != nil {
+			t.Errorf("expected the NodeBalancer to be kept, got %s", err)
+		}
+
+		close(recorder.Events)
+		var claimedEvent bool
+		for event := range recorder.Events {
+			claimedEvent = claimedEvent || strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonNodeBalancerClaimed)
+		}
+		if !claimedEvent {
+			t.Errorf("expected a %s warning event", eventReasonNodeBalancerClaimed)
+		}
+	})
+
+	t.Run("services in a group share", func(t *testing.T) {
+		lb := &loadbalancers{client: &client, zone: "us-west"}
+		nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first, second := newService("first", "web", nb.ID, 80), newService("second", "web", nb.ID, 443)
+
+		for _, svc := range []*v1.Service{first, second, first} {
+			status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			if err != nil {
+				t.Fatalf("expected %s to share the NodeBalancer, got %s", getServiceNn(svc), err)
+			}
+			svc.Status.LoadBalancer = *status
+		}
+		if ports := configPorts(t, nb.ID); len(ports) != 2 {
+			t.Errorf("expected configs for both services, got ports %v", ports)
+		}
+
+		// Deleting one member keeps the NodeBalancer for the other
+		if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", first); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = client.GetNodeBalancer(context.TODO(), nb.ID); err != nil {
+			t.Errorf("expected the shared NodeBalancer to be kept, got %s", err)
+		}
+		if ports := configPorts(t, nb.ID); len(ports) != 1 || ports[0] != 443 {
+			t.Errorf("expected only the second service's config, got ports %v", ports)
+		}
+	})
+
+	t.Run("group membership survives a restart", func(t *testing.T) {
+		lb := &loadbalancers{client: &client, zone: "us-west"}
+		nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		first, second := newService("first", "api", nb.ID, 80), newService("second", "api", nb.ID, 443)
+		for _, svc := range []*v1.Service{first, second} {
+			status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+			if err != nil {
+				t.Fatalf("expected %s to share the NodeBalancer, got %s", getServiceNn(svc), err)
+			}
+			svc.Status.LoadBalancer = *status
+		}
+
+		// A restarted CCM starts without claims
+		lb = &loadbalancers{client: &client, zone: "us-west"}
+		if _, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", first, nil); err != nil {
+			t.Fatal(err)
+		}
+		if ports := configPorts(t, nb.ID); len(ports) != 2 {
+			t.Errorf("expected the second service's config to survive, got ports %v", ports)
+		}
+
+		lb = &loadbalancers{client: &client, zone: "us-west"}
+		if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", first); err != nil {
+			t.Fatal(err)
+		}
+		got, err := client.GetNodeBalancer(context.TODO(), nb.ID)
+		if err != nil {
+			t.Fatalf("expected the shared NodeBalancer to be kept, got %s", err)
+		}
+		if ports := configPorts(t, nb.ID); len(ports) != 1 || ports[0] != 443 {
+			t.Errorf("expected only the second service's config, got ports %v", ports)
+		}
+		metadata, _ := parseConfigMetadataTags(got.Tags)
+		if _, ok := metadata[80]; ok {
+			t.Errorf("expected the first service's metadata to be removed, got tags %v", got.Tags)
+		}
+		if metadata[443].Owner != serviceOwner(second) {
+			t.Errorf("expected the second service's metadata to be kept, got tags %v", got.Tags)
+		}
+	})
+}
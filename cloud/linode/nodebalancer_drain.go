@@ -0,0 +1,64 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/linode/linodego"
+	"k8s.io/klog/v2"
+)
+
+// maxNodeBalancerDrainPeriod bounds --nodebalancer-drain-period. The wait holds up the
+// service controller, which reconciles no other service meanwhile.
+const maxNodeBalancerDrainPeriod = time.Minute
+
+// validateNodeBalancerDrainPeriod checks --nodebalancer-drain-period is within bounds.
+func validateNodeBalancerDrainPeriod(period time.Duration) error {
+	if period < 0 || period > maxNodeBalancerDrainPeriod {
+		return fmt.Errorf("invalid --nodebalancer-drain-period %s, must be between 0 and %s", period, maxNodeBalancerDrainPeriod)
+	}
+	return nil
+}
+
+// drainNodeBalancer sets each backend of nb to drain mode, so that it receives no new
+// connections while those in flight finish, and then waits for
+// Options.NodeBalancerDrainPeriod. Backends which are already draining are left as they
+// are, but the wait is not skipped, as a previous attempt may not have waited it out.
+func (l *loadbalancers) drainNodeBalancer(ctx context.Context, nb *linodego.NodeBalancer) error {
+	period := Options.NodeBalancerDrainPeriod
+	if period <= 0 {
+		return nil
+	}
+
+	configs, err := l.client.ListNodeBalancerConfigs(ctx, nb.ID, nil)
+	if err != nil {
+		return err
+	}
+	drained := 0
+	for _, config := range configs {
+		nodes, err := l.client.ListNodeBalancerNodes(ctx, nb.ID, config.ID, nil)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			if node.Mode == linodego.ModeDrain {
+				continue
+			}
+			if _, err := l.client.UpdateNodeBalancerNode(ctx, nb.ID, config.ID, node.ID, linodego.NodeBalancerNodeUpdateOptions{
+				Mode: linodego.ModeDrain,
+			}); err != nil {
+				return err
+			}
+			drained++
+		}
+	}
+
+	klog.Infof("drained %d backends of NodeBalancer (%d); waiting %s before deleting it", drained, nb.ID, period)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(period):
+		return nil
+	}
+}
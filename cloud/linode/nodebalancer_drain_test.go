@@ -0,0 +1,107 @@
+package linode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateNodeBalancerDrainPeriod(t *testing.T) {
+	for _, period := range []time.Duration{0, 30 * time.Second, maxNodeBalancerDrainPeriod} {
+		if err := validateNodeBalancerDrainPeriod(period); err != nil {
+			t.Errorf("expected %s to be accepted, got %s", period, err)
+		}
+	}
+	for _, period := range []time.Duration{-time.Second, maxNodeBalancerDrainPeriod + time.Second} {
+		if err := validateNodeBalancerDrainPeriod(period); err == nil {
+			t.Errorf("expected %s to be rejected", period)
+		}
+	}
+}
+
+func TestEnsureLoadBalancerDeletedDrains(t *testing.T) {
+	defer func(period time.Duration) { Options.NodeBalancerDrainPeriod = period }(Options.NodeBalancerDrainPeriod)
+	Options.NodeBalancerDrainPeriod = 50 * time.Millisecond
+
+	nodePath := regexp.MustCompile(`^/nodebalancers/[0-9]+/configs/[0-9]+/nodes/[0-9]+$`)
+	var (
+		drainedAt []time.Time
+		deletedAt time.Time
+	)
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && nodePath.MatchString(r.URL.Path):
+			body, _ := ioutil.ReadAll(r.Body)
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			var opts linodego.NodeBalancerNodeUpdateOptions
+			if err := json.Unmarshal(body, &opts); err != nil {
+				t.Fatal(err)
+			}
+			if opts.Mode == linodego.ModeDrain {
+				drainedAt = append(drainedAt, time.Now())
+			}
+		case r.Method == http.MethodDelete && regexp.MustCompile(`^/nodebalancers/[0-9]+$`).MatchString(r.URL.Path):
+			deletedAt = time.Now()
+		}
+		fakeAPI.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", UID: "foobar123"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)},
+				{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30001)},
+			},
+		},
+	}
+	var nodes []*v1.Node
+	for i := 1; i <= 2; i++ {
+		nodes = append(nodes, &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: fmt.Sprintf("127.0.0.%d", i)}},
+			},
+		})
+	}
+
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+
+	if err = lb.EnsureLoadBalancerDeleted(context.TODO(), "linodelb", svc); err != nil {
+		t.Fatalf("EnsureLoadBalancerDeleted returned an error: %s", err)
+	}
+
+	if len(drainedAt) != 4 {
+		t.Fatalf("expected the 4 backends to be drained, got %d", len(drainedAt))
+	}
+	if deletedAt.IsZero() {
+		t.Fatal("expected the NodeBalancer to be deleted")
+	}
+	for _, drained := range drainedAt {
+		if deletedAt.Sub(drained) < Options.NodeBalancerDrainPeriod {
+			t.Errorf("expected the NodeBalancer to be deleted at least %s after its backends were drained, got %s",
+				Options.NodeBalancerDrainPeriod, deletedAt.Sub(drained))
+		}
+	}
+}
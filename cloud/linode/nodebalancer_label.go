@@ -0,0 +1,62 @@
+package linode
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// nodeBalancerLabelMinLength is the shortest label Linode accepts for a NodeBalancer.
+const nodeBalancerLabelMinLength = 3
+
+// nodeBalancerLabelData is what a --nodebalancer-label-template is rendered with.
+type nodeBalancerLabelData struct {
+	Cluster   string
+	Namespace string
+	Name      string
+	UID       string
+	// Hash is a short hash of the service's UID, which keeps labels unique between
+	// services whose other fields would collide once truncated.
+	Hash string
+}
+
+// parseNodeBalancerLabelTemplate parses text as a Go template for NodeBalancer labels,
+// rendering it for two services to reject references to fields nodeBalancerLabelData
+// lacks, and templates which do not tell services apart by their UID or hash. NodeBalancers
+// are found by their label, so services sharing one would reconcile the same NodeBalancer.
+func parseNodeBalancerLabelTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("nodebalancer-label").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NodeBalancer label template: %s", err)
+	}
+	sample := nodeBalancerLabelData{Cluster: "cluster", Namespace: "namespace", Name: "name", UID: "uid", Hash: "hash"}
+	label, err := renderNodeBalancerLabel(tmpl, sample)
+	if err != nil {
+		return nil, err
+	}
+	sample.UID, sample.Hash = "other-uid", "otherhash"
+	if other, err := renderNodeBalancerLabel(tmpl, sample); err != nil || other == label {
+		return nil, fmt.Errorf("invalid NodeBalancer label template %q: it must include {{.Hash}} or {{.UID}} to keep labels unique", text)
+	}
+	return tmpl, nil
+}
+
+// renderNodeBalancerLabel renders tmpl with data into a NodeBalancer label, sanitized and
+// truncated to fit Linode's constraints. Labels are truncated from the end, which the
+// hash is then appended to, so that they stay unique wherever the template puts it.
+func renderNodeBalancerLabel(tmpl *template.Template, data nodeBalancerLabelData) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("invalid NodeBalancer label template: %s", err)
+	}
+
+	label := sanitizeNodeBalancerLabel(b.String())
+	if len(label) > nodeBalancerLabelMaxLength {
+		hash := sanitizeNodeBalancerLabel(data.Hash)
+		label = strings.TrimRight(label[:nodeBalancerLabelMaxLength-len(hash)-1], "-") + "-" + hash
+	}
+	if len(label) < nodeBalancerLabelMinLength {
+		return "", fmt.Errorf("NodeBalancer label template rendered %q, which is shorter than %d characters once sanitized", b.String(), nodeBalancerLabelMinLength)
+	}
+	return label, nil
+}
@@ -0,0 +1,111 @@
+package linode
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func Test_parseNodeBalancerLabelTemplate(t *testing.T) {
+	for _, text := range []string{"{{.Cluster", "{{.Region}}-{{.Name}}", "{{.Cluster}}-{{.Namespace}}-{{.Name}}"} {
+		if _, err := parseNodeBalancerLabelTemplate(text); err == nil {
+			t.Errorf("expected template %q to be rejected", text)
+		}
+	}
+	if _, err := parseNodeBalancerLabelTemplate("{{.Cluster}}-{{.Namespace}}-{{.Name}}-{{.UID}}-{{.Hash}}"); err != nil {
+		t.Errorf("expected template to be accepted, got %s", err)
+	}
+}
+
+func Test_GetLoadBalancerNameTemplate(t *testing.T) {
+	labelRegexp := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	newService := func(namespace, name string) *v1.Service {
+		return &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, UID: "uid-1"}}
+	}
+	defaultLabel := (&loadbalancers{}).GetLoadBalancerName(context.TODO(), "prod", newService("default", "web"))
+	hash := defaultLabel[len(defaultLabel)-nodeBalancerLabelHashLength:]
+	noUID := newService("default", "a_")
+	noUID.UID = ""
+
+	testcases := []struct {
+		name     string
+		template string
+		service  *v1.Service
+		expected string
+	}{
+		{
+			name:     "rendered",
+			template: "{{.Cluster}}-{{.Namespace}}-{{.Name}}-{{.Hash}}",
+			service:  newService("default", "web"),
+			expected: "prod-default-web-" + hash,
+		},
+		{
+			name:     "sanitized",
+			template: "--{{.Cluster}}__{{.Namespace}}.{{.Name}}!!{{.Hash}}",
+			service:  newService("default", "Web_App"),
+			expected: "prod-default-web-app-" + hash,
+		},
+		{
+			name:     "truncated",
+			template: "{{.Cluster}}-{{.Namespace}}-{{.Name}}-{{.Hash}}",
+			service:  newService("default", "a-very-long-service-name-indeed"),
+			expected: "prod-default-a-very-lon-" + hash,
+		},
+		{
+			name:     "hash",
+			template: "lb-{{.Hash}}",
+			service:  newService("default", "web"),
+			expected: "lb-" + hash,
+		},
+		{
+			name:     "too short falls back",
+			template: "{{.UID}}",
+			service:  noUID,
+			expected: (&loadbalancers{}).GetLoadBalancerName(context.TODO(), "prod", noUID),
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := parseNodeBalancerLabelTemplate(test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lb := &loadbalancers{labelTemplate: tmpl}
+
+			label := lb.GetLoadBalancerName(context.TODO(), "prod", test.service)
+			if label != test.expected {
+				t.Errorf("expected label %q, got %q", test.expected, label)
+			}
+			if len(label) > nodeBalancerLabelMaxLength || !labelRegexp.MatchString(label) || strings.HasSuffix(label, "-") {
+				t.Errorf("label %q is not a valid NodeBalancer label", label)
+			}
+		})
+	}
+}
+
+func Test_GetLoadBalancerNameTemplateUnique(t *testing.T) {
+	tmpl, err := parseNodeBalancerLabelTemplate("{{.Cluster}}-{{.Namespace}}-{{.Name}}-{{.Hash}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := &loadbalancers{labelTemplate: tmpl}
+
+	// The namespaces and names only differ past the length of a label
+	first := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "production-frontend", Name: "checkout-service-blue", UID: "uid-1"}}
+	second := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: "production-frontend", Name: "checkout-service-green", UID: "uid-2"}}
+	firstLabel := lb.GetLoadBalancerName(context.TODO(), "prod", first)
+	secondLabel := lb.GetLoadBalancerName(context.TODO(), "prod", second)
+	if firstLabel == secondLabel {
+		t.Errorf("expected the services to get different labels, both got %q", firstLabel)
+	}
+	for _, label := range []string{firstLabel, secondLabel} {
+		if len(label) > nodeBalancerLabelMaxLength {
+			t.Errorf("label %q is longer than %d characters", label, nodeBalancerLabelMaxLength)
+		}
+	}
+}
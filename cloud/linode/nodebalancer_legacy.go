@@ -0,0 +1,60 @@
+package linode
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const eventReasonLegacyNodeBalancerMigrated = "LegacyNodeBalancerMigrated"
+
+// legacyNodeBalancerLabel matches the labels earlier CCM versions gave the NodeBalancers
+// they created: "ccm-" and the last 12 hex digits of the time of creation. Those versions
+// did not tag NodeBalancers either, and found them by the service's status alone.
+var legacyNodeBalancerLabel = regexp.MustCompile(`^ccm-[0-9a-f]{12}$`)
+
+// isLegacyNodeBalancer reports whether nb was created by an earlier CCM version for a
+// service in clusterName, and has not been migrated since.
+func isLegacyNodeBalancer(clusterName string, nb *linodego.NodeBalancer) bool {
+	return nb.Label != nil && legacyNodeBalancerLabel.MatchString(*nb.Label) && !hasTag(nb.Tags, makeClusterTag(clusterName))
+}
+
+// migrateLegacyNodeBalancer brings nb, if it was created for service by an earlier CCM
+// version, in line with the NodeBalancers created now: it is given the service's label,
+// so it can be found by label, and the cluster tag, so DeleteAllManaged removes it. It is
+// updated in place, keeping its IP, and as a migrated NodeBalancer is no longer legacy,
+// later reconciles leave it alone. Per-config metadata and service tags are recorded by
+// the reconcile as usual. NodeBalancers adopted through the nodebalancer-id annotation
+// were not created by the CCM and are never migrated, nor is anything when
+// Options.MigrateLegacyNodeBalancers is unset.
+func (l *loadbalancers) migrateLegacyNodeBalancer(ctx context.Context, clusterName string, service *v1.Service, nb *linodego.NodeBalancer) (*linodego.NodeBalancer, error) {
+	if !Options.MigrateLegacyNodeBalancers || !isLegacyNodeBalancer(clusterName, nb) {
+		return nb, nil
+	}
+	if _, adopted := getNodeBalancerIDAnnotation(service); adopted {
+		return nb, nil
+	}
+
+	oldLabel := *nb.Label
+	label := l.GetLoadBalancerName(ctx, clusterName, service)
+	clusterTag := makeClusterTag(clusterName)
+	tags := append(append([]string(nil), nb.Tags...), clusterTag)
+
+	update := nb.GetUpdateOptions()
+	update.Label = &label
+	update.Tags = &tags
+	migrated, err := l.client.UpdateNodeBalancer(ctx, nb.ID, update)
+	if err != nil {
+		return nil, err
+	}
+
+	klog.Infof("migrated NodeBalancer (%d) of service (%s) created by an earlier CCM version: relabeled %s from %s and tagged %s",
+		nb.ID, getServiceNn(service), label, oldLabel, clusterTag)
+	l.recordEvent(service, v1.EventTypeNormal, eventReasonLegacyNodeBalancerMigrated,
+		"NodeBalancer (%d) created by an earlier CCM version was relabeled %s and tagged %s in place; its IP %s is unchanged",
+		nb.ID, label, clusterTag, stringValue(migrated.IPv4))
+	return migrated, nil
+}
@@ -0,0 +1,95 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestEnsureLoadBalancerMigratesLegacyNodeBalancer(t *testing.T) {
+	defer func(migrate bool) { Options.MigrateLegacyNodeBalancers = migrate }(Options.MigrateLegacyNodeBalancers)
+	Options.MigrateLegacyNodeBalancers = true
+
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	// A NodeBalancer as earlier CCM versions created them: labeled by time of creation
+	// and untagged
+	legacyLabel := "ccm-0174f3a2b9c1"
+	legacy, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: "us-west", Label: &legacyLabel})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "legacy",
+			Namespace: "default",
+			UID:       "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}},
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: *makeLoadBalancerStatus(legacy),
+		},
+	}
+
+	recorder := record.NewFakeRecorder(100)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+	for i := 0; i < 2; i++ {
+		status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+		}
+		if len(status.Ingress) != 1 || status.Ingress[0].IP != *legacy.IPv4 {
+			t.Fatalf("expected the legacy NodeBalancer's IP %s to be kept, got %v", *legacy.IPv4, status.Ingress)
+		}
+	}
+
+	nbs, err := client.ListNodeBalancers(context.TODO(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nbs) != 1 || nbs[0].ID != legacy.ID {
+		t.Fatalf("expected the legacy NodeBalancer (%d) to be adopted in place, got %v", legacy.ID, nbs)
+	}
+	migrated := nbs[0]
+	if label := lb.GetLoadBalancerName(context.TODO(), "linodelb", svc); migrated.Label == nil || *migrated.Label != label {
+		t.Errorf("expected the NodeBalancer to be relabeled %s, got %v", label, migrated.Label)
+	}
+	clusterTags := 0
+	for _, tag := range migrated.Tags {
+		if tag == makeClusterTag("linodelb") {
+			clusterTags++
+		}
+	}
+	if clusterTags != 1 {
+		t.Errorf("expected the NodeBalancer to be tagged %s once, got %v", makeClusterTag("linodelb"), migrated.Tags)
+	}
+	if metadata, _ := parseConfigMetadataTags(migrated.Tags); !metadata[80].isManaged() {
+		t.Errorf("expected the config metadata to be recorded in the tags, got %v", migrated.Tags)
+	}
+
+	close(recorder.Events)
+	migratedEvents := 0
+	for event := range recorder.Events {
+		if strings.HasPrefix(event, v1.EventTypeNormal+" "+eventReasonLegacyNodeBalancerMigrated) {
+			migratedEvents++
+		}
+	}
+	if migratedEvents != 1 {
+		t.Errorf("expected the NodeBalancer to be migrated once, got %d %s events", migratedEvents, eventReasonLegacyNodeBalancerMigrated)
+	}
+}
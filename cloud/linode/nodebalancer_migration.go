@@ -0,0 +1,79 @@
+package linode
+
+import (
+	"context"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const eventReasonNodeBalancerMigrated = "NodeBalancerMigrated"
+
+const (
+	nodeBalancerShared    = "shared"
+	nodeBalancerDedicated = "dedicated"
+)
+
+// nodeBalancerSharing returns whether nb is shared by the service with other services,
+// or dedicated to it.
+func (l *loadbalancers) nodeBalancerSharing(service *v1.Service, nb *linodego.NodeBalancer) string {
+	if l.sharesNodeBalancer(service, nb) {
+		return nodeBalancerShared
+	}
+	return nodeBalancerDedicated
+}
+
+// hasLeftSharedNodeBalancer reports whether nb, found through the service's status, is a
+// NodeBalancer the service shared with other services before dropping its
+// nodebalancer-id annotation. Its tags record configs owned by other services, which
+// still use it, so it is no longer the service's to reconcile.
+func (l *loadbalancers) hasLeftSharedNodeBalancer(service *v1.Service, nb *linodego.NodeBalancer) bool {
+	if _, ok := getNodeBalancerIDAnnotation(service); ok {
+		return false
+	}
+	owner := serviceOwner(service)
+	metadata, _ := parseConfigMetadataTags(nb.Tags)
+	for _, m := range metadata {
+		if m.Owner != "" && m.Owner != owner {
+			return true
+		}
+	}
+	return false
+}
+
+// leaveSharedNodeBalancer removes the configs the service served on the shared
+// NodeBalancer nb, and their metadata, leaving those of the services still sharing it.
+func (l *loadbalancers) leaveSharedNodeBalancer(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer) error {
+	configs, err := l.client.ListNodeBalancerConfigs(ctx, nb.ID, nil)
+	if err != nil {
+		return err
+	}
+	sharedPorts := l.sharedPorts(service, nb)
+	remaining := make([]v1.ServicePort, 0, len(sharedPorts))
+	for _, port := range sharedPorts {
+		remaining = append(remaining, v1.ServicePort{Port: int32(port)})
+	}
+	if err := l.deleteUnusedConfigs(ctx, configs, remaining); err != nil {
+		return err
+	}
+
+	applied, _ := parseConfigMetadataTags(nb.Tags)
+	metadata := make(map[int]configMetadata, len(sharedPorts))
+	for _, port := range sharedPorts {
+		if m, ok := applied[port]; ok {
+			metadata[port] = m
+		}
+	}
+	tags, err := mergeConfigMetadataTags(nb.Tags, metadata)
+	if err != nil {
+		return err
+	}
+	update := nb.GetUpdateOptions()
+	update.Tags = &tags
+	if _, err := l.client.UpdateNodeBalancer(ctx, nb.ID, update); err != nil {
+		return err
+	}
+	klog.Infof("removed the configs of service (%s) from shared NodeBalancer (%d)", getServiceNn(service), nb.ID)
+	return nil
+}
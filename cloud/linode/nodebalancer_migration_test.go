@@ -0,0 +1,231 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestNodeBalancerSharingTransitions(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	newService := func(name string, port int32) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				UID:         types.UID(name),
+				Annotations: map[string]string{},
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{Name: "test", Protocol: "TCP", Port: port, NodePort: 30000 + port}},
+			},
+		}
+	}
+	share := func(svc *v1.Service, id int) {
+		svc.Annotations[annLinodeNodeBalancerID] = strconv.Itoa(id)
+		svc.Annotations[annLinodeNodeBalancerGroup] = "web"
+	}
+	ensure := func(t *testing.T, lb *loadbalancers, svc *v1.Service) {
+		t.Helper()
+		status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		if err != nil {
+			t.Fatalf("EnsureLoadBalancer returned an error for %s: %s", getServiceNn(svc), err)
+		}
+		svc.Status.LoadBalancer = *status
+	}
+	configPorts := func(t *testing.T, id int) []int {
+		t.Helper()
+		configs, err := client.ListNodeBalancerConfigs(context.TODO(), id, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ports []int
+		for _, config := range configs {
+			ports = append(ports, config.Port)
+		}
+		sort.Ints(ports)
+		return ports
+	}
+	migratedEvent := func(recorder *record.FakeRecorder) string {
+		close(recorder.Events)
+		for event := range recorder.Events {
+			if strings.HasPrefix(event, v1.EventTypeWarning+" "+eventReasonNodeBalancerMigrated) {
+				return event
+			}
+		}
+		return ""
+	}
+
+	t.Run("dedicated to shared", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(100)
+		lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+		shared, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		member, mover := newService("member", 80), newService("mover", 443)
+		share(member, shared.ID)
+		ensure(t, lb, member)
+		ensure(t, lb, mover)
+		dedicated, err := lb.getNodeBalancerByStatus(context.TODO(), mover)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		share(mover, shared.ID)
+		ensure(t, lb, mover)
+
+		if ip := mover.Status.LoadBalancer.Ingress[0].IP; ip != *shared.IPv4 {
+			t.Errorf("expected the status IP to change to the shared NodeBalancer's %s, got %s", *shared.IPv4, ip)
+		}
+		if ports := configPorts(t, shared.ID); len(ports) != 2 || ports[0] != 80 || ports[1] != 443 {
+			t.Errorf("expected the shared NodeBalancer to serve both services, got ports %v", ports)
+		}
+		if _, err = client.GetNodeBalancer(context.TODO(), dedicated.ID); err == nil {
+			t.Errorf("expected the dedicated NodeBalancer (%d) to be deleted", dedicated.ID)
+		}
+		event := migratedEvent(recorder)
+		if !strings.Contains(event, "from dedicated NodeBalancer") || !strings.Contains(event, "to shared NodeBalancer") ||
+			!strings.Contains(event, *dedicated.IPv4) || !strings.Contains(event, *shared.IPv4) {
+			t.Errorf("expected a %s event naming both IPs, got %q", eventReasonNodeBalancerMigrated, event)
+		}
+	})
+
+	t.Run("dedicated to shared on update", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(100)
+		lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+		fakeClientset := fake.NewSimpleClientset()
+		lb.kubeClient = fakeClientset
+		shared, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		member, mover := newService("update-member", 80), newService("update-mover", 443)
+		share(member, shared.ID)
+		ensure(t, lb, member)
+		ensure(t, lb, mover)
+		dedicated, err := lb.getNodeBalancerByStatus(context.TODO(), mover)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = fakeClientset.CoreV1().Services(mover.Namespace).Create(context.TODO(), mover, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		// UpdateLoadBalancer is not given the service's status
+		share(mover, shared.ID)
+		mover.Status = v1.ServiceStatus{}
+		if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", mover, nil); err != nil {
+			t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+		}
+
+		if ports := configPorts(t, shared.ID); len(ports) != 2 || ports[0] != 80 || ports[1] != 443 {
+			t.Errorf("expected the shared NodeBalancer to serve both services, got ports %v", ports)
+		}
+		if _, err = client.GetNodeBalancer(context.TODO(), dedicated.ID); err == nil {
+			t.Errorf("expected the dedicated NodeBalancer (%d) to be deleted", dedicated.ID)
+		}
+		event := migratedEvent(recorder)
+		if !strings.Contains(event, "from dedicated NodeBalancer") || !strings.Contains(event, "to shared NodeBalancer") ||
+			!strings.Contains(event, *dedicated.IPv4) || !strings.Contains(event, *shared.IPv4) {
+			t.Errorf("expected a %s event naming both IPs, got %q", eventReasonNodeBalancerMigrated, event)
+		}
+	})
+
+	t.Run("shared to dedicated", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(100)
+		lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+		shared, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		member, mover := newService("member", 80), newService("mover", 443)
+		share(member, shared.ID)
+		share(mover, shared.ID)
+		ensure(t, lb, member)
+		ensure(t, lb, mover)
+
+		delete(mover.Annotations, annLinodeNodeBalancerID)
+		delete(mover.Annotations, annLinodeNodeBalancerGroup)
+		ensure(t, lb, mover)
+
+		dedicated, err := lb.getNodeBalancerByStatus(context.TODO(), mover)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dedicated.ID == shared.ID {
+			t.Fatalf("expected the service to move off the shared NodeBalancer (%d)", shared.ID)
+		}
+		if ports := configPorts(t, dedicated.ID); len(ports) != 1 || ports[0] != 443 {
+			t.Errorf("expected the dedicated NodeBalancer to serve the service, got ports %v", ports)
+		}
+		if ports := configPorts(t, shared.ID); len(ports) != 1 || ports[0] != 80 {
+			t.Errorf("expected the shared NodeBalancer to keep only the remaining service, got ports %v", ports)
+		}
+		event := migratedEvent(recorder)
+		if !strings.Contains(event, "from shared NodeBalancer") || !strings.Contains(event, "to dedicated NodeBalancer") ||
+			!strings.Contains(event, *shared.IPv4) || !strings.Contains(event, *dedicated.IPv4) {
+			t.Errorf("expected a %s event naming both IPs, got %q", eventReasonNodeBalancerMigrated, event)
+		}
+	})
+
+	t.Run("shared to dedicated after a restart", func(t *testing.T) {
+		recorder := record.NewFakeRecorder(100)
+		lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+		shared, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{Region: lb.zone})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		member, mover := newService("restart-member", 80), newService("restart-mover", 443)
+		share(member, shared.ID)
+		share(mover, shared.ID)
+		ensure(t, lb, member)
+		ensure(t, lb, mover)
+
+		// A restarted CCM knows the services sharing the NodeBalancer only from its tags
+		lb = &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+		delete(mover.Annotations, annLinodeNodeBalancerID)
+		delete(mover.Annotations, annLinodeNodeBalancerGroup)
+		ensure(t, lb, mover)
+
+		dedicated, err := lb.getNodeBalancerByStatus(context.TODO(), mover)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dedicated.ID == shared.ID {
+			t.Fatalf("expected the service to move off the shared NodeBalancer (%d)", shared.ID)
+		}
+		if ports := configPorts(t, dedicated.ID); len(ports) != 1 || ports[0] != 443 {
+			t.Errorf("expected the dedicated NodeBalancer to serve the service, got ports %v", ports)
+		}
+		if ports := configPorts(t, shared.ID); len(ports) != 1 || ports[0] != 80 {
+			t.Errorf("expected the shared NodeBalancer to keep only the remaining service, got ports %v", ports)
+		}
+		event := migratedEvent(recorder)
+		if !strings.Contains(event, "from shared NodeBalancer") || !strings.Contains(event, "to dedicated NodeBalancer") ||
+			!strings.Contains(event, *shared.IPv4) || !strings.Contains(event, *dedicated.IPv4) {
+			t.Errorf("expected a %s event naming both IPs, got %q", eventReasonNodeBalancerMigrated, event)
+		}
+	})
+}
@@ -0,0 +1,230 @@
+package linode
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// NodeBalancer configs cannot be tagged, so per-config metadata is stored in the
+	// NodeBalancer's tags as "ccm:<port>:<key>=<value>".
+	configMetadataTagPrefix = "ccm:"
+
+	configMetadataKeyHash      = "hash"
+	configMetadataKeyManagedBy = "managed-by"
+	configMetadataKeyOwner     = "owner"
+
+	configMetadataManagedBy  = "linode-ccm"
+	configMetadataHashLength = 16
+
+	// clusterTagPrefix prefixes the tag identifying the cluster which created a
+	// NodeBalancer or Cloud Firewall.
+	clusterTagPrefix = "ccm:cluster="
+
+	// serviceTagPrefix prefixes the tags applied to a NodeBalancer from its service's
+	// tags annotation, which tells them apart from tags added outside of the CCM.
+	serviceTagPrefix = "ccm:tag="
+
+	// Linode rejects tags shorter than 3 or longer than 50 characters.
+	maxTagLength = 50
+)
+
+// makeClusterTag returns the tag identifying resources created for clusterName. Cluster
+// names too long to fit in a tag are replaced by a hash.
+func makeClusterTag(clusterName string) string {
+	tag := clusterTagPrefix + clusterName
+	if len(tag) <= maxTagLength {
+		return tag
+	}
+	sum := sha256.Sum256([]byte(clusterName))
+	return clusterTagPrefix + hex.EncodeToString(sum[:])[:configMetadataHashLength]
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceOwner returns the owner recorded in the config metadata of service: a hash of
+// its UID, so that a later service reusing its name does not inherit its configs.
+func serviceOwner(service *v1.Service) string {
+	uid := string(service.UID)
+	if uid == "" {
+		uid = getServiceNn(service)
+	}
+	sum := sha256.Sum256([]byte(uid))
+	return hex.EncodeToString(sum[:])[:configMetadataHashLength]
+}
+
+// configMetadata is the reconcile metadata kept for a single NodeBalancer config.
+type configMetadata struct {
+	// Hash is the configFingerprint of the config and backends last applied by the CCM.
+	Hash string
+	// ManagedBy marks the config as owned by the CCM.
+	ManagedBy string
+	// Owner is the serviceOwner of the service the config serves, which tells apart the
+	// configs of services sharing a NodeBalancer. Configs recorded by earlier versions
+	// have none.
+	Owner string
+}
+
+func (m configMetadata) isManaged() bool {
+	return m.ManagedBy == configMetadataManagedBy
+}
+
+// newConfigMetadata returns the metadata to record for a config created or rebuilt
+// from opts.
+func newConfigMetadata(opts linodego.NodeBalancerConfigCreateOptions) (configMetadata, error) {
+	hash, err := configFingerprint(opts)
+	if err != nil {
+		return configMetadata{}, err
+	}
+	return configMetadata{
+		Hash:      hash,
+		ManagedBy: configMetadataManagedBy,
+	}, nil
+}
+
+// makeConfigMetadataTags returns the tags encoding metadata, ordered by port.
+func makeConfigMetadataTags(metadata map[int]configMetadata) ([]string, error) {
+	ports := make([]int, 0, len(metadata))
+	for port := range metadata {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	tags := make([]string, 0, 2*len(ports))
+	for _, port := range ports {
+		m := metadata[port]
+		for _, kv := range [][2]string{
+			{configMetadataKeyHash, m.Hash},
+			{configMetadataKeyManagedBy, m.ManagedBy},
+			{configMetadataKeyOwner, m.Owner},
+		} {
+			if kv[1] == "" {
+				continue
+			}
+			if strings.ContainsAny(kv[1], ":=") {
+				return nil, fmt.Errorf("invalid NodeBalancer config metadata value %q for port %d", kv[1], port)
+			}
+			tag := fmt.Sprintf("%s%d:%s=%s", configMetadataTagPrefix, port, kv[0], kv[1])
+			if len(tag) > maxTagLength {
+				return nil, fmt.Errorf("NodeBalancer config metadata tag %q is longer than %d characters", tag, maxTagLength)
+			}
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// parseConfigMetadataTags splits tags into the per-port config metadata they encode and
+// the remaining tags. Tags which look like metadata but cannot be parsed are treated as
+// ordinary tags.
+func parseConfigMetadataTags(tags []string) (map[int]configMetadata, []string) {
+	metadata := make(map[int]configMetadata)
+	var other []string
+	for _, tag := range tags {
+		port, key, value, ok := parseConfigMetadataTag(tag)
+		if !ok {
+			other = append(other, tag)
+			continue
+		}
+
+		m := metadata[port]
+		switch key {
+		case configMetadataKeyHash:
+			m.Hash = value
+		case configMetadataKeyManagedBy:
+			m.ManagedBy = value
+		case configMetadataKeyOwner:
+			m.Owner = value
+		}
+		metadata[port] = m
+	}
+	return metadata, other
+}
+
+func parseConfigMetadataTag(tag string) (port int, key, value string, ok bool) {
+	if !strings.HasPrefix(tag, configMetadataTagPrefix) {
+		return 0, "", "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(tag, configMetadataTagPrefix), ":", 2)
+	if len(parts) != 2 {
+		return 0, "", "", false
+	}
+	port, err := strconv.Atoi(parts[0])
+	if err != nil || port < 1 || port > 65535 {
+		return 0, "", "", false
+	}
+	kv := strings.SplitN(parts[1], "=", 2)
+	if len(kv) != 2 || kv[1] == "" {
+		return 0, "", "", false
+	}
+	switch kv[0] {
+	case configMetadataKeyHash, configMetadataKeyManagedBy, configMetadataKeyOwner:
+		return port, kv[0], kv[1], true
+	}
+	return 0, "", "", false
+}
+
+// mergeConfigMetadataTags returns tags with any existing config metadata replaced by
+// metadata. Tags which do not hold config metadata are kept as they are.
+func mergeConfigMetadataTags(tags []string, metadata map[int]configMetadata) ([]string, error) {
+	_, other := parseConfigMetadataTags(tags)
+	metadataTags, err := makeConfigMetadataTags(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return append(other, metadataTags...), nil
+}
+
+// getServiceTags returns the NodeBalancer tags made from the service's tags annotation,
+// sorted and without duplicates.
+func getServiceTags(service *v1.Service) ([]string, error) {
+	raw, ok := getServiceAnnotation(service, annLinodeNodeBalancerTags)
+	if !ok {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	var tags []string
+	for _, value := range strings.Split(raw, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		tag := serviceTagPrefix + value
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("invalid %s annotation: tag %q is longer than %d characters", annLinodeNodeBalancerTags, tag, maxTagLength)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// mergeServiceTags returns tags with any existing service tags replaced by serviceTags.
+// Tags which were not made from a service annotation are kept as they are.
+func mergeServiceTags(tags, serviceTags []string) []string {
+	merged := make([]string, 0, len(tags)+len(serviceTags))
+	for _, tag := range tags {
+		if !strings.HasPrefix(tag, serviceTagPrefix) {
+			merged = append(merged, tag)
+		}
+	}
+	return append(merged, serviceTags...)
+}
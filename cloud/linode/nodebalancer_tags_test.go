@@ -0,0 +1,78 @@
+package linode
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/linode/linodego"
+)
+
+func Test_configMetadataTagsRoundTrip(t *testing.T) {
+	metadata := map[int]configMetadata{
+		80:    {Hash: "0123456789abcdef", ManagedBy: configMetadataManagedBy},
+		443:   {Hash: "fedcba9876543210", ManagedBy: configMetadataManagedBy},
+		65535: {ManagedBy: configMetadataManagedBy},
+	}
+	userTags := []string{"team:web", "ccm:not-a-port:hash=abc", "ccm:80:unknown=1"}
+
+	tags, err := mergeConfigMetadataTags(append(userTags, "ccm:8080:hash=stale"), metadata)
+	if err != nil {
+		t.Fatalf("mergeConfigMetadataTags returned an error: %s", err)
+	}
+	for _, tag := range tags {
+		if len(tag) < 3 || len(tag) > maxTagLength {
+			t.Errorf("tag %q is not between 3 and %d characters", tag, maxTagLength)
+		}
+	}
+
+	parsed, other := parseConfigMetadataTags(tags)
+	if !reflect.DeepEqual(parsed, metadata) {
+		t.Errorf("expected metadata %v, got %v", metadata, parsed)
+	}
+	sort.Strings(other)
+	sort.Strings(userTags)
+	if !reflect.DeepEqual(other, userTags) {
+		t.Errorf("expected other tags %v, got %v", userTags, other)
+	}
+	for port, m := range parsed {
+		if !m.isManaged() {
+			t.Errorf("expected config for port %d to be managed", port)
+		}
+	}
+}
+
+func Test_makeConfigMetadataTagsInvalid(t *testing.T) {
+	if _, err := makeConfigMetadataTags(map[int]configMetadata{80: {Hash: "a=b"}}); err == nil {
+		t.Error("expected an error for a value containing '='")
+	}
+}
+
+func Test_newConfigMetadata(t *testing.T) {
+	opts := linodego.NodeBalancerConfigCreateOptions{
+		Port:     80,
+		Protocol: linodego.ProtocolTCP,
+		Nodes: []linodego.NodeBalancerNodeCreateOptions{
+			{Address: "10.0.0.1:30000", Label: "node-1"},
+		},
+	}
+
+	first, err := newConfigMetadata(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Hash) != configMetadataHashLength || !first.isManaged() {
+		t.Errorf("unexpected metadata %v", first)
+	}
+
+	same, _ := newConfigMetadata(opts)
+	if same != first {
+		t.Errorf("expected identical options to produce the same metadata, got %v and %v", first, same)
+	}
+
+	opts.Nodes = append(opts.Nodes, linodego.NodeBalancerNodeCreateOptions{Address: "10.0.0.2:30000", Label: "node-2"})
+	changed, _ := newConfigMetadata(opts)
+	if changed.Hash == first.Hash {
+		t.Error("expected changed options to produce a different hash")
+	}
+}
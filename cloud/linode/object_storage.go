@@ -0,0 +1,167 @@
+package linode
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	objectStorageEndpointEnv  = "LINODE_OBJ_ENDPOINT"
+	objectStorageAccessKeyEnv = "LINODE_OBJ_ACCESS_KEY"
+	objectStorageSecretKeyEnv = "LINODE_OBJ_SECRET_KEY"
+
+	// Objects holding a certificate and key are small; anything larger is rejected
+	// rather than read into memory.
+	maxObjectStorageObjectSize = 1 << 20
+
+	awsSigningAlgorithm = "AWS4-HMAC-SHA256"
+	awsTimeFormat       = "20060102T150405Z"
+	awsDateFormat       = "20060102"
+)
+
+// objectStorageClient fetches objects from Linode Object Storage through its
+// S3-compatible API.
+type objectStorageClient struct {
+	endpoint   *url.URL
+	region     string
+	accessKey  string
+	secretKey  string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// newObjectStorageClientFromEnv returns a client for the Object Storage endpoint and
+// credentials in the environment, or nil if none are configured.
+func newObjectStorageClientFromEnv() (*objectStorageClient, error) {
+	endpoint := os.Getenv(objectStorageEndpointEnv)
+	accessKey := os.Getenv(objectStorageAccessKeyEnv)
+	secretKey := os.Getenv(objectStorageSecretKeyEnv)
+	if endpoint == "" && accessKey == "" && secretKey == "" {
+		return nil, nil
+	}
+	if endpoint == "" || accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("%s, %s and %s must all be set to use Object Storage (use a k8s secret)",
+			objectStorageEndpointEnv, objectStorageAccessKeyEnv, objectStorageSecretKeyEnv)
+	}
+	return newObjectStorageClient(endpoint, accessKey, secretKey)
+}
+
+// newObjectStorageClient returns a client for endpoint, e.g.
+// https://us-east-1.linodeobjects.com. Requests are signed for the cluster named by the
+// endpoint's first host label.
+func newObjectStorageClient(endpoint, accessKey, secretKey string) (*objectStorageClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Object Storage endpoint %q: %s", endpoint, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Object Storage endpoint %q: must be an http or https URL", endpoint)
+	}
+	return &objectStorageClient{
+		endpoint:   u,
+		region:     strings.SplitN(u.Hostname(), ".", 2)[0],
+		accessKey:  accessKey,
+		secretKey:  secretKey,
+		httpClient: http.DefaultClient,
+		now:        time.Now,
+	}, nil
+}
+
+// getObject returns the contents of key in bucket.
+func (c *objectStorageClient) getObject(ctx context.Context, bucket, key string) ([]byte, error) {
+	u := *c.endpoint
+	u.Path = "/" + bucket + "/" + strings.TrimPrefix(key, "/")
+	u.RawPath = awsURIEscape(u.Path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	c.sign(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get Object Storage object %s/%s: %s", bucket, key, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxObjectStorageObjectSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Object Storage object %s/%s: %s", bucket, key, err)
+	}
+	return body, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req, which must have an empty body.
+func (c *objectStorageClient) sign(req *http.Request) {
+	now := c.now().UTC()
+	amzDate := now.Format(awsTimeFormat)
+	payloadHash := sha256Hex(nil)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(awsDateFormat), c.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{awsSigningAlgorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := []byte("AWS4" + c.secretKey)
+	for _, part := range []string{now.Format(awsDateFormat), c.region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsSigningAlgorithm, c.accessKey, scope, signedHeaders, signature))
+}
+
+// awsURIEscape escapes path as SigV4 requires: everything but unreserved characters and
+// slashes is percent-encoded.
+func awsURIEscape(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || ch == '/' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,143 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newFakeObjectStorage serves objects, keyed by path, to requests signed with
+// accessKey.
+func newFakeObjectStorage(t *testing.T, accessKey string, objects map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, awsSigningAlgorithm+" Credential="+accessKey+"/") ||
+			!strings.Contains(auth, "SignedHeaders=host;x-amz-content-sha256;x-amz-date") ||
+			r.Header.Get("X-Amz-Date") == "" {
+			t.Errorf("request for %s is not signed: %q", r.URL.Path, auth)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		object, ok := objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(object))
+	}))
+}
+
+func TestObjectStorageClientSign(t *testing.T) {
+	client, err := newObjectStorageClient("https://us-east-1.linodeobjects.com", "AKIDEXAMPLE", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.now = func() time.Time { return time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC) }
+
+	req, err := http.NewRequest(http.MethodGet, "https://us-east-1.linodeobjects.com/certs/app/tls.crt", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.sign(req)
+
+	if got := req.Header.Get("X-Amz-Date"); got != "20201001T120000Z" {
+		t.Errorf("unexpected X-Amz-Date %q", got)
+	}
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20201001/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, expected) || len(got) != len(expected)+64 {
+		t.Errorf("unexpected Authorization header %q", got)
+	}
+}
+
+func TestGetObjectStorageTLSCertInfo(t *testing.T) {
+	ts := newFakeObjectStorage(t, "access", map[string]string{
+		"/certs/prod/app/tls.crt":    testCert,
+		"/certs/prod/app/tls.key":    testKey,
+		"/certs/mismatch/tls.crt":    testCert,
+		"/certs/mismatch/tls.key":    "not a key",
+		"/certs/missing-key/tls.crt": testCert,
+	})
+	defer ts.Close()
+
+	objectStorage, err := newObjectStorageClient(ts.URL, "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		name          string
+		ref           string
+		objectStorage *objectStorageClient
+		wantErr       string
+	}{
+		{"valid", "certs/prod/app", objectStorage, ""},
+		{"invalid key", "certs/mismatch", objectStorage, "invalid TLS certificate or key"},
+		{"missing key", "certs/missing-key", objectStorage, "404"},
+		{"invalid bucket", "Certs/prod/app", objectStorage, "invalid bucket name"},
+		{"not configured", "certs/prod/app", nil, "no Object Storage credentials are configured"},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			lb := &loadbalancers{objectStorage: test.objectStorage}
+			cert, key, err := lb.getObjectStorageTLSCertInfo(context.TODO(), portConfig{Port: 443, TLSObjectStorage: test.ref})
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cert != strings.TrimSpace(testCert) || key != strings.TrimSpace(testKey) {
+				t.Error("unexpected certificate or key")
+			}
+		})
+	}
+}
+
+func TestBuildNodeBalancerConfigObjectStorageTLS(t *testing.T) {
+	ts := newFakeObjectStorage(t, "access", map[string]string{
+		"/certs/tls.crt": testCert,
+		"/certs/tls.key": testKey,
+	})
+	defer ts.Close()
+
+	objectStorage, err := newObjectStorageClient(ts.URL, "access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := &loadbalancers{objectStorage: objectStorage}
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "https",
+			UID:  "abc123",
+			Annotations: map[string]string{
+				annLinodePortConfigPrefix + "443": `{"protocol": "https", "tls-object-storage": "certs"}`,
+			},
+		},
+	}
+
+	config, err := lb.buildNodeBalancerConfig(context.TODO(), service, 443)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Protocol != linodego.ProtocolHTTPS || config.SSLCert != strings.TrimSpace(testCert) || config.SSLKey != strings.TrimSpace(testKey) {
+		t.Errorf("expected the https config to use the certificate from Object Storage, got %+v", config)
+	}
+
+	service.Annotations[annLinodePortConfigPrefix+"443"] = `{"protocol": "https", "tls-object-storage": "certs", "tls-secret-name": "tls"}`
+	if _, err := lb.buildNodeBalancerConfig(context.TODO(), service, 443); err == nil {
+		t.Error("expected an error when both tls-secret-name and tls-object-storage are set")
+	}
+}
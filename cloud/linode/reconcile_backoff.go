@@ -0,0 +1,83 @@
+package linode
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// reconcileBackoffError is returned in place of reconciling a service which is backing
+// off after consecutive failures.
+type reconcileBackoffError struct {
+	serviceNn  string
+	failures   int
+	retryAfter time.Duration
+}
+
+func (e reconcileBackoffError) Error() string {
+	return fmt.Sprintf("not reconciling service (%s) for another %s after %d consecutive failures",
+		e.serviceNn, e.retryAfter.Round(time.Second), e.failures)
+}
+
+// reconcileBackoffInterval returns how long to wait before reconciling a service again
+// after the given number of consecutive failures: Options.ReconcileBackoffBase, doubled
+// for each failure after the first and capped at Options.ReconcileBackoffMax. It is zero
+// when backoff is disabled.
+func reconcileBackoffInterval(failures int) time.Duration {
+	base, max := Options.ReconcileBackoffBase, Options.ReconcileBackoffMax
+	if base <= 0 || failures <= 0 {
+		return 0
+	}
+	interval := base
+	for i := 1; i < failures && interval < max; i++ {
+		interval *= 2
+	}
+	if interval > max && max > base {
+		interval = max
+	}
+	return interval
+}
+
+// checkReconcileBackoff returns a reconcileBackoffError if the service is still backing
+// off after its last failure.
+func (l *loadbalancers) checkReconcileBackoff(service *v1.Service) error {
+	serviceNn := getServiceNn(service)
+	state := l.states.get(serviceNn)
+	if wait := time.Until(state.reconcileRetryAt); wait > 0 {
+		return reconcileBackoffError{serviceNn: serviceNn, failures: state.reconcileFailures, retryAfter: wait}
+	}
+	return nil
+}
+
+// recordReconcileResult records the outcome of a reconcile of service, counting a
+// failure towards its backoff, or resetting the backoff once it has been reconciled
+// successfully. Reconciles skipped by checkReconcileBackoff are not recorded.
+func (l *loadbalancers) recordReconcileResult(service *v1.Service, err error) {
+	switch err.(type) {
+	case reconcileBackoffError, reconcileIntervalError:
+		return
+	}
+	serviceNn := getServiceNn(service)
+	l.states.update(serviceNn, func(state *serviceState) {
+		state.lastReconcile = time.Now()
+		state.lastReconcileError = ""
+		if err != nil {
+			state.lastReconcileError = err.Error()
+		}
+		if err == nil {
+			state.reconcileFailures = 0
+			state.reconcileBackoff = 0
+			state.reconcileRetryAt = time.Time{}
+			return
+		}
+		state.reconcileFailures++
+		state.reconcileBackoff = reconcileBackoffInterval(state.reconcileFailures)
+		state.reconcileRetryAt = time.Now().Add(state.reconcileBackoff)
+		if state.reconcileBackoff > 0 {
+			klog.Warningf("backing off reconciling service (%s) for %s after %d consecutive failures",
+				serviceNn, state.reconcileBackoff, state.reconcileFailures)
+		}
+	})
+}
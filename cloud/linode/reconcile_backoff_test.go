@@ -0,0 +1,90 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileBackoff(t *testing.T) {
+	defer func(base, max time.Duration) {
+		Options.ReconcileBackoffBase = base
+		Options.ReconcileBackoffMax = max
+	}(Options.ReconcileBackoffBase, Options.ReconcileBackoffMax)
+	Options.ReconcileBackoffBase = time.Second
+	Options.ReconcileBackoffMax = 4 * time.Second
+
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: v1.ProtocolUDP,
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+	serviceNn := getServiceNn(svc)
+	// expireBackoff lets the service be reconciled again as if its backoff had passed.
+	expireBackoff := func() {
+		lb.states.update(serviceNn, func(state *serviceState) {
+			state.reconcileRetryAt = time.Time{}
+		})
+	}
+
+	for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		_, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		if _, ok := err.(reconcileBackoffError); err == nil || ok {
+			t.Fatalf("expected failure %d to reconcile, got %v", i+1, err)
+		}
+		if got := lb.states.get(serviceNn).reconcileBackoff; got != expected {
+			t.Errorf("expected a backoff of %s after %d failures, got %s", expected, i+1, got)
+		}
+
+		fake.requests = make(map[fakeRequest]struct{})
+		_, err = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		if _, ok := err.(reconcileBackoffError); !ok {
+			t.Errorf("expected a reconcileBackoffError while backing off, got %v", err)
+		}
+		if len(fake.requests) != 0 {
+			t.Errorf("expected no API requests while backing off, got %d", len(fake.requests))
+		}
+		expireBackoff()
+	}
+
+	svc.Spec.Ports[0].Protocol = v1.ProtocolTCP
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	if state := lb.states.get(serviceNn); state.reconcileFailures != 0 || state.reconcileBackoff != 0 {
+		t.Errorf("expected the backoff to reset after a success, got %d failures and a backoff of %s", state.reconcileFailures, state.reconcileBackoff)
+	}
+
+	svc.Spec.Ports[0].Protocol = v1.ProtocolUDP
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err == nil {
+		t.Fatal("expected EnsureLoadBalancer to reject a UDP port")
+	}
+	if got := lb.states.get(serviceNn).reconcileBackoff; got != time.Second {
+		t.Errorf("expected the backoff to start over at %s, got %s", time.Second, got)
+	}
+}
@@ -0,0 +1,110 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annLinodeReconciledCondition is written by the CCM with a JSON condition of type
+	// conditionLoadBalancerReconciled describing the outcome of the service's last
+	// reconcile, for GitOps tools and dashboards to gate on. It is an annotation rather
+	// than an entry in the service's status, which the service controller owns.
+	annLinodeReconciledCondition = "service.linode.com/loadbalancer-reconciled"
+
+	conditionLoadBalancerReconciled = "LoadBalancerReconciled"
+
+	conditionReasonReconciled      = "Reconciled"
+	conditionReasonReconcileFailed = "ReconcileFailed"
+)
+
+// reconcileConditionReason returns the reason for a reconcile of service which failed
+// with err: the reason of the warning event recorded for it where there is one, and
+// conditionReasonReconcileFailed otherwise.
+func reconcileConditionReason(service *v1.Service, err error) string {
+	switch err.(type) {
+	case nodeBalancerClaimedError:
+		return eventReasonNodeBalancerClaimed
+	case configMismatchError:
+		return eventReasonNodeBalancerConfigMismatch
+	case regionCapacityError:
+		return eventReasonRegionAtCapacity
+	case forbiddenSecretNamespaceError:
+		return eventReasonForbiddenSecretNamespace
+	case noBackendNodesError:
+		return eventReasonNoBackendNodes
+	}
+	if validateServiceConfig(service) != nil {
+		return eventReasonInvalidServiceConfig
+	}
+	return conditionReasonReconcileFailed
+}
+
+// makeReconciledCondition returns the condition for a reconcile of service which
+// returned err. The transition time of the service's current condition is kept unless
+// its status changes.
+func makeReconciledCondition(service *v1.Service, err error, now time.Time) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               conditionLoadBalancerReconciled,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: service.Generation,
+		LastTransitionTime: metav1.NewTime(now),
+		Reason:             conditionReasonReconciled,
+		Message:            "the NodeBalancer matches the service",
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = reconcileConditionReason(service, err)
+		condition.Message = err.Error()
+	}
+	if current, ok := getReconciledCondition(service); ok && current.Status == condition.Status {
+		condition.LastTransitionTime = current.LastTransitionTime
+	}
+	return condition
+}
+
+// getReconciledCondition returns the condition recorded on the service, if there is a
+// readable one.
+func getReconciledCondition(service *v1.Service) (metav1.Condition, bool) {
+	var condition metav1.Condition
+	raw, ok := service.Annotations[annLinodeReconciledCondition]
+	if !ok || json.Unmarshal([]byte(raw), &condition) != nil {
+		return metav1.Condition{}, false
+	}
+	return condition, true
+}
+
+// setReconciledCondition records the outcome err of a reconcile of service in its
+// condition annotation. The service is only patched when the condition changes, as every
+// change to its annotations makes the service controller reconcile it again. Reconciles
+// held back by the backoff or minimum interval, or aborted as the service is deleted,
+// leave the condition as it is. Like the other annotations written by the CCM, failures
+// are only logged.
+func (l *loadbalancers) setReconciledCondition(ctx context.Context, service *v1.Service, err error) {
+	switch err.(type) {
+	case reconcileBackoffError, reconcileIntervalError, reconcileAbortedError:
+		return
+	}
+
+	condition := makeReconciledCondition(service, err, time.Now())
+	if current, ok := getReconciledCondition(service); ok && current.Status == condition.Status &&
+		current.Reason == condition.Reason && current.Message == condition.Message &&
+		current.ObservedGeneration == condition.ObservedGeneration {
+		return
+	}
+	raw, err := json.Marshal(condition)
+	if err != nil {
+		klog.Warningf("failed to encode %s condition of service (%s): %s", conditionLoadBalancerReconciled, getServiceNn(service), err)
+		return
+	}
+	err = l.patchServiceAnnotations(ctx, service, map[string]interface{}{annLinodeReconciledCondition: string(raw)})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to set %s condition of service (%s): %s", conditionLoadBalancerReconciled, getServiceNn(service), err)
+	}
+}
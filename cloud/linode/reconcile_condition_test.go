@@ -0,0 +1,73 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconciledCondition(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "condition",
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "dns", Protocol: "UDP", Port: int32(53), NodePort: int32(30000)}},
+		},
+	}
+
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+	stubService(fakeClientset, svc)
+
+	reconcile := func(t *testing.T) metav1.Condition {
+		t.Helper()
+		_, _ = lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+		latest, err := fakeClientset.CoreV1().Services(svc.Namespace).Get(context.TODO(), svc.Name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		svc.Annotations = latest.Annotations
+		condition, ok := getReconciledCondition(latest)
+		if !ok {
+			t.Fatalf("expected the service to be annotated with a %s condition, got %v", conditionLoadBalancerReconciled, latest.Annotations)
+		}
+		if condition.Type != conditionLoadBalancerReconciled {
+			t.Errorf("expected a condition of type %s, got %s", conditionLoadBalancerReconciled, condition.Type)
+		}
+		return condition
+	}
+
+	failed := reconcile(t)
+	if failed.Status != metav1.ConditionFalse || failed.Reason != eventReasonInvalidServiceConfig || failed.Message == "" {
+		t.Errorf("expected the condition to be False with reason %s and a message, got %+v", eventReasonInvalidServiceConfig, failed)
+	}
+
+	svc.Spec.Ports[0] = v1.ServicePort{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}
+	reconciled := reconcile(t)
+	if reconciled.Status != metav1.ConditionTrue || reconciled.Reason != conditionReasonReconciled {
+		t.Errorf("expected the condition to be True with reason %s, got %+v", conditionReasonReconciled, reconciled)
+	}
+
+	// An unchanged outcome leaves the condition, and its transition time, alone
+	raw := svc.Annotations[annLinodeReconciledCondition]
+	reconcile(t)
+	if svc.Annotations[annLinodeReconciledCondition] != raw {
+		t.Errorf("expected the condition to be left alone, got %s", svc.Annotations[annLinodeReconciledCondition])
+	}
+}
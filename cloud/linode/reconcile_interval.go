@@ -0,0 +1,73 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// reconcileIntervalError is returned in place of reconciling a service which was last
+// reconciled less than Options.MinReconcileInterval ago. The service is requeued for
+// when retryAfter has passed.
+type reconcileIntervalError struct {
+	serviceNn  string
+	retryAfter time.Duration
+}
+
+func (e reconcileIntervalError) Error() string {
+	return fmt.Sprintf("not reconciling service (%s) for another %s, to keep %s between reconciles",
+		e.serviceNn, e.retryAfter.Round(time.Millisecond), Options.MinReconcileInterval)
+}
+
+// requeueAfter is called to reconcile a service held back by Options.MinReconcileInterval
+// once it may be reconciled again. It is replaced in tests.
+var requeueAfter = time.AfterFunc
+
+// checkReconcileInterval returns a reconcileIntervalError if the service's last
+// reconcile which could change its NodeBalancer began less than
+// Options.MinReconcileInterval ago, scheduling a single reconcile for the end of the
+// interval however many are held back, so that the changes held back are coalesced
+// into it. Otherwise it records that such a reconcile is beginning.
+func (l *loadbalancers) checkReconcileInterval(service *v1.Service) error {
+	if Options.MinReconcileInterval <= 0 {
+		return nil
+	}
+	serviceNn := getServiceNn(service)
+
+	var wait time.Duration
+	schedule := false
+	l.states.update(serviceNn, func(state *serviceState) {
+		wait = Options.MinReconcileInterval - time.Since(state.lastMutatingReconcile)
+		if wait <= 0 {
+			state.lastMutatingReconcile = time.Now()
+			state.requeueScheduled = false
+			return
+		}
+		schedule = !state.requeueScheduled
+		state.requeueScheduled = true
+	})
+	if wait <= 0 {
+		return nil
+	}
+
+	if schedule {
+		klog.V(2).Infof("coalescing reconciles of service (%s) into one in %s", serviceNn, wait)
+		requeueAfter(wait, func() { l.requeueService(service) })
+	}
+	return reconcileIntervalError{serviceNn: serviceNn, retryAfter: wait}
+}
+
+// requeueService annotates service with the current time, so that the service
+// controller reconciles it again.
+func (l *loadbalancers) requeueService(service *v1.Service) {
+	target := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: service.Namespace, Name: service.Name}}
+	if err := l.patchServiceAnnotations(context.Background(), target, map[string]interface{}{
+		annLinodeRefreshedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	}); err != nil {
+		klog.Warningf("failed to requeue service (%s) after the minimum reconcile interval: %s", getServiceNn(service), err)
+	}
+}
@@ -0,0 +1,126 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcileIntervalCoalescesUpdates(t *testing.T) {
+	defer func(interval time.Duration) { Options.MinReconcileInterval = interval }(Options.MinReconcileInterval)
+	defer func(f func(time.Duration, func()) *time.Timer) { requeueAfter = f }(requeueAfter)
+	Options.MinReconcileInterval = time.Hour
+
+	var requeues []func()
+	requeueAfter = func(wait time.Duration, f func()) *time.Timer {
+		if wait <= 0 || wait > time.Hour {
+			t.Errorf("expected a requeue within the interval, got one after %s", wait)
+		}
+		requeues = append(requeues, f)
+		return nil
+	}
+
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "interval",
+			UID:  "foobar123",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http", Protocol: "TCP", Port: int32(80), NodePort: int32(30000)}},
+		},
+	}
+	var nodes []*v1.Node
+	addNode := func() {
+		nodes = append(nodes, &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", len(nodes)+1)},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: fmt.Sprintf("10.0.0.%d", len(nodes)+1)}},
+			},
+		})
+	}
+
+	lb := &loadbalancers{client: &client, zone: "us-west"}
+	fakeClientset := fake.NewSimpleClientset()
+	lb.kubeClient = fakeClientset
+
+	addNode()
+	lbStatus, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	svc.Status.LoadBalancer = *lbStatus
+	stubService(fakeClientset, svc)
+
+	// Rapid node changes within the interval are held back without touching the API,
+	// and requeue the service only once
+	fakeAPI.requests = make(map[fakeRequest]struct{})
+	for i := 0; i < 3; i++ {
+		addNode()
+		err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes)
+		if _, ok := err.(reconcileIntervalError); !ok {
+			t.Fatalf("expected a reconcileIntervalError, got %v", err)
+		}
+	}
+	if len(fakeAPI.requests) != 0 {
+		t.Errorf("expected no Linode API requests within the interval, got %v", fakeAPI.requests)
+	}
+	if len(requeues) != 1 {
+		t.Fatalf("expected the service to be requeued once, got %d", len(requeues))
+	}
+	if state := lb.states.get(getServiceNn(svc)); state.reconcileFailures != 0 {
+		t.Errorf("expected held back reconciles not to count as failures, got %d", state.reconcileFailures)
+	}
+
+	// Once the interval has passed the requeue reconciles every change at once
+	lb.states.update(getServiceNn(svc), func(state *serviceState) {
+		state.lastMutatingReconcile = time.Now().Add(-time.Hour)
+	})
+	requeues[0]()
+	latest, err := fakeClientset.CoreV1().Services(svc.Namespace).Get(context.TODO(), svc.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Annotations[annLinodeRefreshedAt] == "" {
+		t.Errorf("expected the requeue to annotate the service with %s", annLinodeRefreshedAt)
+	}
+
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("UpdateLoadBalancer returned an error: %s", err)
+	}
+	nb, err := lb.getNodeBalancerByStatus(context.TODO(), svc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs, err := client.ListNodeBalancerConfigs(context.TODO(), nb.ID, nil)
+	if err != nil || len(configs) != 1 {
+		t.Fatalf("expected one config, got %v (%v)", configs, err)
+	}
+	backends, err := client.ListNodeBalancerNodes(context.TODO(), nb.ID, configs[0].ID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backends) != len(nodes) {
+		t.Errorf("expected all %d nodes to be backends after the coalesced reconcile, got %d", len(nodes), len(backends))
+	}
+
+	// The coalesced reconcile starts a new interval
+	addNode()
+	if err = lb.UpdateLoadBalancer(context.TODO(), "linodelb", svc, nodes); err == nil {
+		t.Error("expected an update right after the coalesced reconcile to be held back")
+	}
+}
@@ -0,0 +1,167 @@
+package linode
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// refreshKindSecret identifies Kubernetes secrets, such as TLS secrets, as a source.
+	refreshKindSecret = "Secret"
+
+	// annLinodeRefreshedAt is set by the CCM to when material a service depends on last
+	// changed. The change to the service's annotations makes the service controller
+	// reconcile it, which picks up the new material.
+	annLinodeRefreshedAt = "service.linode.com/refreshed-at"
+)
+
+// refreshSource identifies externally sourced material, such as a certificate, which
+// services are built from.
+type refreshSource struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// refreshCallback is called with the services, as namespace/name, which depend on a
+// source which has changed.
+type refreshCallback func(source refreshSource, services []string)
+
+// refreshRegistry records which services depend on which sources, and calls the
+// callbacks registered for a kind of source when one changes. A nil *refreshRegistry
+// records nothing.
+type refreshRegistry struct {
+	mu         sync.Mutex
+	callbacks  map[string][]refreshCallback
+	dependents map[refreshSource]map[string]bool
+}
+
+func newRefreshRegistry() *refreshRegistry {
+	return &refreshRegistry{
+		callbacks:  make(map[string][]refreshCallback),
+		dependents: make(map[refreshSource]map[string]bool),
+	}
+}
+
+// register adds callback to those called when a source of the given kind changes.
+func (r *refreshRegistry) register(kind string, callback refreshCallback) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks[kind] = append(r.callbacks[kind], callback)
+}
+
+// track records that the service serviceNn depends on source. Dependencies are kept
+// until the service is forgotten; one which is no longer used only costs a needless
+// reconcile if its source changes.
+func (r *refreshRegistry) track(serviceNn string, source refreshSource) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.dependents[source] == nil {
+		r.dependents[source] = make(map[string]bool)
+	}
+	r.dependents[source][serviceNn] = true
+}
+
+// forget removes the dependencies of the service serviceNn.
+func (r *refreshRegistry) forget(serviceNn string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for source, services := range r.dependents {
+		delete(services, serviceNn)
+		if len(services) == 0 {
+			delete(r.dependents, source)
+		}
+	}
+}
+
+// sourceChanged calls the callbacks registered for source's kind with the services
+// depending on source, if there are any.
+func (r *refreshRegistry) sourceChanged(source refreshSource) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	services := make([]string, 0, len(r.dependents[source]))
+	for serviceNn := range r.dependents[source] {
+		services = append(services, serviceNn)
+	}
+	callbacks := append([]refreshCallback(nil), r.callbacks[source.kind]...)
+	r.mu.Unlock()
+
+	if len(services) == 0 {
+		return
+	}
+	sort.Strings(services)
+	for _, callback := range callbacks {
+		callback(source, services)
+	}
+}
+
+// watchTLSSecrets reports changes to TLS secrets to the registry until stopCh is
+// closed. Only secrets of type kubernetes.io/tls are watched, as those are the only
+// ones the CCM reads.
+func (r *refreshRegistry) watchTLSSecrets(kubeClient kubernetes.Interface, stopCh <-chan struct{}) {
+	factory := informers.NewSharedInformerFactoryWithOptions(kubeClient, 0,
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("type", string(v1.SecretTypeTLS)).String()
+		}))
+	changed := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if secret, ok := obj.(*v1.Secret); ok {
+			r.sourceChanged(refreshSource{kind: refreshKindSecret, namespace: secret.Namespace, name: secret.Name})
+		}
+	}
+	factory.Core().V1().Secrets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		// Services reconciled before a secret they reference is created are refreshed
+		// once it is, and the initial list only reports secrets no service depends on yet.
+		AddFunc: changed,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret, oldOK := oldObj.(*v1.Secret)
+			newSecret, newOK := newObj.(*v1.Secret)
+			if oldOK && newOK && oldSecret.ResourceVersion == newSecret.ResourceVersion {
+				return
+			}
+			changed(newObj)
+		},
+		DeleteFunc: changed,
+	})
+	factory.Start(stopCh)
+}
+
+// refreshServices annotates each of services with the current time, so that they are
+// reconciled with the changed source.
+func (l *loadbalancers) refreshServices(source refreshSource, services []string) {
+	refreshedAt := time.Now().UTC().Format(time.RFC3339Nano)
+	for _, serviceNn := range services {
+		namespace, name, err := cache.SplitMetaNamespaceKey(serviceNn)
+		if err != nil {
+			klog.Warningf("failed to refresh service (%s): %s", serviceNn, err)
+			continue
+		}
+		service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+		klog.Infof("reconciling service (%s) as %s %s/%s changed", serviceNn, source.kind, source.namespace, source.name)
+		if err := l.patchServiceAnnotations(context.Background(), service, map[string]interface{}{
+			annLinodeRefreshedAt: refreshedAt,
+		}); err != nil {
+			klog.Warningf("failed to refresh service (%s) after %s %s/%s changed: %s", serviceNn, source.kind, source.namespace, source.name, err)
+		}
+	}
+}
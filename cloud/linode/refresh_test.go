@@ -0,0 +1,95 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func Test_refreshRegistry(t *testing.T) {
+	registry := newRefreshRegistry()
+	var fired [][]string
+	registry.register(refreshKindSecret, func(source refreshSource, services []string) {
+		fired = append(fired, services)
+	})
+
+	cert := refreshSource{kind: refreshKindSecret, namespace: "default", name: "cert"}
+	registry.track("default/b", cert)
+	registry.track("default/a", cert)
+	registry.track("default/c", refreshSource{kind: refreshKindSecret, namespace: "default", name: "other"})
+
+	registry.sourceChanged(cert)
+	if expected := [][]string{{"default/a", "default/b"}}; !reflect.DeepEqual(fired, expected) {
+		t.Errorf("expected the callback to fire with %v, got %v", expected, fired)
+	}
+
+	fired = nil
+	registry.forget("default/a")
+	registry.forget("default/b")
+	registry.sourceChanged(cert)
+	registry.sourceChanged(refreshSource{kind: "ConfigMap", namespace: "default", name: "other"})
+	if len(fired) != 0 {
+		t.Errorf("expected no callback for sources without dependent services, got %v", fired)
+	}
+
+	// A nil registry, as in loadbalancers built without newLoadbalancers, does nothing
+	var nilRegistry *refreshRegistry
+	nilRegistry.track("default/a", cert)
+	nilRegistry.sourceChanged(cert)
+	nilRegistry.forget("default/a")
+}
+
+func TestTLSSecretRefresh(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fakeAPI.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	lb := newLoadbalancers(&client, "us-west").(*loadbalancers)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodePortConfigPrefix + "443": `{"protocol": "https", "tls-secret-name": "tls-secret"}`,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "https", Protocol: "TCP", Port: int32(443), NodePort: int32(30000)}},
+		},
+	}
+	lb.kubeClient = fake.NewSimpleClientset(svc)
+	addTLSSecret(t, lb.kubeClient)
+	nodes := []*v1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.1"}},
+			},
+		},
+	}
+
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nodes); err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+
+	lb.refresh.sourceChanged(refreshSource{kind: refreshKindSecret, namespace: "", name: "tls-secret"})
+	refreshed, err := lb.kubeClient.CoreV1().Services(svc.Namespace).Get(context.TODO(), svc.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.Annotations[annLinodeRefreshedAt] == "" {
+		t.Errorf("expected the service to be annotated with %s once its TLS secret changed, got %v", annLinodeRefreshedAt, refreshed.Annotations)
+	}
+}
@@ -0,0 +1,138 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// annLinodeStatusNodeBalancerID and annLinodeStatusNodeBalancerRegion are written by
+	// the CCM to record which NodeBalancer serves the service, for operators and tooling
+	// to find it without searching by label. Unlike the linode-loadbalancer- annotations,
+	// they are not configuration and are overwritten on every change.
+	annLinodeStatusNodeBalancerID     = "service.linode.com/nodebalancer-id"
+	annLinodeStatusNodeBalancerRegion = "service.linode.com/nodebalancer-region"
+
+	// annLinodeStatusNodeBalancerConfigIDs is written by the CCM, for services annotated
+	// with annotate-config-ids, with a JSON object mapping each of the service's ports
+	// to the ID of its NodeBalancer config.
+	annLinodeStatusNodeBalancerConfigIDs = "service.linode.com/nodebalancer-config-ids"
+
+	// annLinodeAnnotateConfigIDs, when true, makes the CCM write
+	// annLinodeStatusNodeBalancerConfigIDs on the service.
+	annLinodeAnnotateConfigIDs = "service.beta.kubernetes.io/linode-loadbalancer-annotate-config-ids"
+)
+
+func shouldAnnotateConfigIDs(service *v1.Service) bool {
+	raw, ok := getServiceAnnotation(service, annLinodeAnnotateConfigIDs)
+	if !ok {
+		return false
+	}
+	annotate, err := strconv.ParseBool(raw)
+	return err == nil && annotate
+}
+
+// annotateNodeBalancer records nb's ID and region on the service, and the IDs of its
+// configs if the service is annotated with annotate-config-ids, unless they are already
+// there. Failing to do so does not fail the reconcile, as the annotations are only
+// informational; they are written on the next one instead.
+func (l *loadbalancers) annotateNodeBalancer(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer) {
+	annotations := make(map[string]interface{})
+	id := strconv.Itoa(nb.ID)
+	if service.Annotations[annLinodeStatusNodeBalancerID] != id || service.Annotations[annLinodeStatusNodeBalancerRegion] != nb.Region {
+		annotations[annLinodeStatusNodeBalancerID] = id
+		annotations[annLinodeStatusNodeBalancerRegion] = nb.Region
+	}
+
+	current, annotated := service.Annotations[annLinodeStatusNodeBalancerConfigIDs]
+	switch {
+	case shouldAnnotateConfigIDs(service):
+		configIDs, err := l.makeConfigIDsAnnotation(ctx, service, nb)
+		if err != nil {
+			klog.Warningf("failed to get config IDs of NodeBalancer (%d) to annotate service (%s): %s", nb.ID, getServiceNn(service), err)
+		} else if configIDs != current || !annotated {
+			annotations[annLinodeStatusNodeBalancerConfigIDs] = configIDs
+		}
+	case annotated:
+		annotations[annLinodeStatusNodeBalancerConfigIDs] = nil
+	}
+
+	if len(annotations) == 0 {
+		return
+	}
+	err := l.patchServiceAnnotations(ctx, service, annotations)
+	if err != nil {
+		klog.Warningf("failed to annotate service (%s) with NodeBalancer (%d): %s", getServiceNn(service), nb.ID, err)
+		return
+	}
+	klog.V(2).Infof("annotated service (%s) with NodeBalancer (%d) in %s", getServiceNn(service), nb.ID, nb.Region)
+}
+
+// makeConfigIDsAnnotation returns the value of annLinodeStatusNodeBalancerConfigIDs for
+// the service: a JSON object mapping each of its ports which has a config on nb to that
+// config's ID.
+func (l *loadbalancers) makeConfigIDsAnnotation(ctx context.Context, service *v1.Service, nb *linodego.NodeBalancer) (string, error) {
+	configs, err := l.client.ListNodeBalancerConfigs(ctx, nb.ID, nil)
+	if err != nil {
+		return "", err
+	}
+	configIDs := make(map[string]int, len(service.Spec.Ports))
+	for _, port := range service.Spec.Ports {
+		for _, config := range configs {
+			if config.Port == int(port.Port) {
+				configIDs[strconv.Itoa(config.Port)] = config.ID
+				break
+			}
+		}
+	}
+	b, err := json.Marshal(configIDs)
+	return string(b), err
+}
+
+// clearNodeBalancerAnnotations removes the annotations written by annotateNodeBalancer
+// once the service's NodeBalancer is deleted. Like those, failures are only logged.
+func (l *loadbalancers) clearNodeBalancerAnnotations(ctx context.Context, service *v1.Service) {
+	_, hasID := service.Annotations[annLinodeStatusNodeBalancerID]
+	_, hasRegion := service.Annotations[annLinodeStatusNodeBalancerRegion]
+	_, hasConfigIDs := service.Annotations[annLinodeStatusNodeBalancerConfigIDs]
+	if !hasID && !hasRegion && !hasConfigIDs {
+		return
+	}
+
+	err := l.patchServiceAnnotations(ctx, service, map[string]interface{}{
+		annLinodeStatusNodeBalancerID:        nil,
+		annLinodeStatusNodeBalancerRegion:    nil,
+		annLinodeStatusNodeBalancerConfigIDs: nil,
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to remove NodeBalancer annotations from service (%s): %s", getServiceNn(service), err)
+	}
+}
+
+// patchServiceAnnotations merges annotations into the service's, removing those whose
+// value is nil.
+func (l *loadbalancers) patchServiceAnnotations(ctx context.Context, service *v1.Service, annotations map[string]interface{}) error {
+	if err := l.retrieveKubeClient(); err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = l.kubeClient.CoreV1().Services(service.Namespace).Patch(ctx, service.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,134 @@
+package linode
+
+import (
+	"sync"
+	"time"
+)
+
+// serviceState is the in-memory state tracked for a service between reconciles.
+type serviceState struct {
+	// nodeSnapshot describes the nodes and service settings the NodeBalancer was last
+	// successfully reconciled with.
+	nodeSnapshot string
+
+	// announcedIP is the NodeBalancer IP last announced in an EnsuredLoadBalancer
+	// event.
+	announcedIP string
+
+	// backendHealth is the summary of backend health last reported, at
+	// backendHealthReportedAt.
+	backendHealth           string
+	backendHealthReportedAt time.Time
+
+	// firewallRules identifies the Cloud Firewall rules last reconciled from the
+	// service's source ranges.
+	firewallRules string
+
+	// managedFirewallID is the ID of the Cloud Firewall the CCM manages for the
+	// service's NodeBalancer, or 0 while it manages none.
+	managedFirewallID int
+
+	// duplicatesCheckedID is the ID of the NodeBalancer last checked for duplicates by
+	// checkDuplicateNodeBalancers.
+	duplicatesCheckedID int
+
+	// warnedDeprecatedAnnotationPrefix is set once the service has been warned about
+	// its beta-prefixed annotations.
+	warnedDeprecatedAnnotationPrefix bool
+
+	// backendRemovalCapped is set while the last reconcile of the service left backends
+	// in place which it would otherwise have removed.
+	backendRemovalCapped bool
+
+	// reconcileFailures counts the service's consecutive failed reconciles, which are
+	// not retried for reconcileBackoff, until reconcileRetryAt.
+	reconcileFailures int
+	reconcileBackoff  time.Duration
+	reconcileRetryAt  time.Time
+
+	// nodeBalancerID and backends are the NodeBalancer and number of backend nodes the
+	// service was last reconciled with, for the debug state handler.
+	nodeBalancerID int
+	backends       int
+
+	// lastReconcile is when the service was last reconciled, and lastReconcileError
+	// how that reconcile failed, if it did.
+	lastReconcile      time.Time
+	lastReconcileError string
+
+	// nodePortRoutingExplained lists the ports with named targetPorts last explained in
+	// a NodePortRouting event.
+	nodePortRoutingExplained string
+
+	// webSocketTCPWarned lists the WebSocket ports last warned about being proxied as
+	// tcp in a WebSocketTCP event.
+	webSocketTCPWarned string
+
+	// describedNonce is the describe annotation the service was last described for in a
+	// NodeBalancerDescribed event.
+	describedNonce string
+
+	// deleting is set while the service's NodeBalancer is being deleted, which stops
+	// updates in flight from carrying on with it.
+	deleting bool
+
+	// lastMutatingReconcile is when the service last started a reconcile which could
+	// change its NodeBalancer, and requeueScheduled is set while a reconcile held back
+	// by Options.MinReconcileInterval is scheduled.
+	lastMutatingReconcile time.Time
+	requeueScheduled      bool
+}
+
+// serviceStates tracks serviceState by the service's namespaced name. The zero
+// value is ready to use.
+type serviceStates struct {
+	mu     sync.Mutex
+	states map[string]*serviceState
+}
+
+// get returns a copy of the state of the service.
+func (s *serviceStates) get(serviceNn string) serviceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if state, ok := s.states[serviceNn]; ok {
+		return *state
+	}
+	return serviceState{}
+}
+
+// all returns a copy of the state of every service, by namespaced name.
+func (s *serviceStates) all() map[string]serviceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make(map[string]serviceState, len(s.states))
+	for serviceNn, state := range s.states {
+		states[serviceNn] = *state
+	}
+	return states
+}
+
+// update calls fn with the state of the service while holding the lock.
+func (s *serviceStates) update(serviceNn string, fn func(*serviceState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.states == nil {
+		s.states = make(map[string]*serviceState)
+	}
+	state, ok := s.states[serviceNn]
+	if !ok {
+		state = &serviceState{}
+		s.states[serviceNn] = state
+	}
+	fn(state)
+}
+
+// delete forgets the state of the service.
+func (s *serviceStates) delete(serviceNn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.states, serviceNn)
+}
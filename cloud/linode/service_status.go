@@ -0,0 +1,61 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// annLinodeStatusServerSideApply makes the CCM write the service's ingress status itself
+// with server-side apply, owning only that field, instead of leaving the cloud-provider
+// service controller to update the whole status.
+const annLinodeStatusServerSideApply = "service.beta.kubernetes.io/linode-loadbalancer-status-server-side-apply"
+
+// statusFieldManager is the field manager the CCM applies service statuses as.
+const statusFieldManager = "linode-cloud-controller-manager"
+
+func shouldApplyStatus(service *v1.Service) bool {
+	raw, ok := getServiceAnnotation(service, annLinodeStatusServerSideApply)
+	if !ok {
+		return false
+	}
+	apply, err := strconv.ParseBool(raw)
+	return err == nil && apply
+}
+
+// applyServiceStatus sets the service's ingress to status with a server-side apply patch
+// of the status subresource, as statusFieldManager.
+func (l *loadbalancers) applyServiceStatus(ctx context.Context, service *v1.Service, status *v1.LoadBalancerStatus) error {
+	if err := l.retrieveKubeClient(); err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata": map[string]interface{}{
+			"name":      service.Name,
+			"namespace": service.Namespace,
+		},
+		"status": map[string]interface{}{
+			"loadBalancer": status,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	force := true
+	_, err = l.kubeClient.CoreV1().Services(service.Namespace).Patch(ctx, service.Name, types.ApplyPatchType, patch,
+		metav1.PatchOptions{FieldManager: statusFieldManager, Force: &force}, "status")
+	if err != nil {
+		return err
+	}
+	klog.V(2).Infof("applied ingress %v to the status of service (%s)", status.Ingress, getServiceNn(service))
+	return nil
+}
@@ -0,0 +1,103 @@
+package linode
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestEnsureLoadBalancerStatusServerSideApply(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ssa",
+			Namespace: "default",
+			UID:       "foobar123",
+			Annotations: map[string]string{
+				annLinodeStatusServerSideApply: "true",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http",
+					Protocol: "TCP",
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	var applied *http.Request
+	var appliedBody []byte
+	kubeAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the service's annotations are patched too, which is not what is under test
+		if strings.HasSuffix(r.URL.Path, "/status") {
+			applied = r
+			appliedBody, _ = ioutil.ReadAll(r.Body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(svc)
+	}))
+	defer kubeAPI.Close()
+
+	kubeClient, err := kubernetes.NewForConfig(&rest.Config{Host: kubeAPI.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := &loadbalancers{client: &client, zone: "us-west", kubeClient: kubeClient}
+
+	status, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil)
+	if err != nil {
+		t.Fatalf("EnsureLoadBalancer returned an error: %s", err)
+	}
+	if len(status.Ingress) != 0 {
+		t.Errorf("expected the service's own status to be returned, got %v", status.Ingress)
+	}
+
+	if applied == nil {
+		t.Fatal("expected the status to be applied")
+	}
+	if applied.Method != http.MethodPatch || applied.URL.Path != "/api/v1/namespaces/default/services/ssa/status" {
+		t.Errorf("unexpected request %s %s", applied.Method, applied.URL.Path)
+	}
+	if got := applied.Header.Get("Content-Type"); got != string(types.ApplyPatchType) {
+		t.Errorf("expected an apply patch, got %s", got)
+	}
+	if got := applied.URL.Query().Get("fieldManager"); got != statusFieldManager {
+		t.Errorf("expected field manager %s, got %q", statusFieldManager, got)
+	}
+
+	var patch v1.Service
+	if err := json.Unmarshal(appliedBody, &patch); err != nil {
+		t.Fatal(err)
+	}
+	nb, err := lb.getNodeBalancerByLabel(context.TODO(), "linodelb", svc, lb.GetLoadBalancerName(context.TODO(), "linodelb", svc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patch.Status.LoadBalancer.Ingress) != 1 || patch.Status.LoadBalancer.Ingress[0].IP != *nb.IPv4 {
+		t.Errorf("expected the ingress of NodeBalancer (%d) to be applied, got %v", nb.ID, patch.Status.LoadBalancer.Ingress)
+	}
+	if patch.Name != "ssa" || patch.Namespace != "default" || len(patch.Spec.Ports) != 0 {
+		t.Errorf("expected the patch to hold only the service's identity and status, got %s", appliedBody)
+	}
+}
@@ -0,0 +1,68 @@
+package linode
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// validateServiceConfig checks the service's ports and annotations for settings a
+// NodeBalancer cannot be configured with, such as http_cookie stickiness on a tcp port
+// or PROXY protocol on an http port. It makes no API calls and returns every problem it
+// finds at once, so they can all be fixed before the service is reconciled again.
+func validateServiceConfig(service *v1.Service) error {
+	// NodeBalancer configs are keyed by port alone, so a port number served over both
+	// TCP and UDP, such as DNS on 53, could not have a config for each even if
+	// NodeBalancers supported UDP.
+	tcpPorts := make(map[int32]bool)
+	for _, port := range service.Spec.Ports {
+		if port.Protocol != v1.ProtocolUDP {
+			tcpPorts[port.Port] = true
+		}
+	}
+
+	var errs []error
+	for _, port := range sortedServicePorts(service) {
+		if port.Protocol == v1.ProtocolUDP {
+			if tcpPorts[port.Port] {
+				errs = append(errs, fmt.Errorf("port %d is served over both TCP and UDP; NodeBalancers do not support UDP and have one config per port number, so UDP needs a separate load balancer", port.Port))
+				continue
+			}
+			errs = append(errs, fmt.Errorf("port %d uses the UDP protocol, which NodeBalancers do not support", port.Port))
+			continue
+		}
+		config, err := getPortConfig(service, int(port.Port))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("port %d: %s", port.Port, err))
+			// the port's health check is still checked, as it does not depend on the rest
+			// of its config
+			annotation, annErr := getPortConfigAnnotation(service, int(port.Port))
+			if annErr != nil {
+				continue
+			}
+			config = portConfig{Port: int(port.Port), HealthCheck: annotation.HealthCheck}
+		}
+		if _, err := getHealthCheck(service, config); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if _, err := getBackendNodeSelector(service); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := getBackendAddressResolver(service); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := getServiceTags(service); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := configOrderedServicePorts(service); err != nil {
+		errs = append(errs, err)
+	}
+
+	if agg := utilerrors.NewAggregate(errs); agg != nil {
+		return fmt.Errorf("invalid configuration for service (%s): %w", getServiceNn(service), agg)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestValidateServiceConfig(t *testing.T) {
+	testcases := []struct {
+		name        string
+		annotations map[string]string
+		protocols   []v1.Protocol
+		wantErrs    []string
+	}{
+		{
+			name:      "valid",
+			protocols: []v1.Protocol{v1.ProtocolTCP, v1.ProtocolTCP},
+		},
+		{
+			name:        "http_cookie stickiness with tcp",
+			annotations: map[string]string{annLinodeDefaultStickiness: "http_cookie"},
+			protocols:   []v1.Protocol{v1.ProtocolTCP},
+			wantErrs:    []string{`port 80: NodeBalancer stickiness 'http_cookie' cannot be used with protocol "tcp"`},
+		},
+		{
+			name: "PROXY protocol with http",
+			annotations: map[string]string{
+				annLinodeDefaultProtocol:      "http",
+				annLinodeDefaultProxyProtocol: "v2",
+			},
+			protocols: []v1.Protocol{v1.ProtocolTCP},
+			wantErrs:  []string{`port 80: NodeBalancer proxy protocol 'v2' cannot be used with protocol "http"`},
+		},
+		{
+			name: "UDP with an http check",
+			annotations: map[string]string{
+				annLinodeHealthCheckType: "http",
+			},
+			protocols: []v1.Protocol{v1.ProtocolUDP},
+			wantErrs:  []string{"port 80 uses the UDP protocol"},
+		},
+		{
+			name: "every invalid combination is reported",
+			annotations: map[string]string{
+				annLinodePortConfigPrefix + "80": `{"stickiness": "http_cookie"}`,
+				annLinodeHealthCheckType:         "connection",
+				annLinodeCheckPath:               "/healthz",
+				annLinodeBackendNodeSelector:     " ",
+			},
+			protocols: []v1.Protocol{v1.ProtocolTCP, v1.ProtocolUDP},
+			wantErrs: []string{
+				"port 80: NodeBalancer stickiness 'http_cookie'",
+				"port 81 uses the UDP protocol",
+				"invalid health check for port 80",
+				"selector must not be empty",
+			},
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Namespace:   "default",
+					Annotations: test.annotations,
+				},
+			}
+			for i, protocol := range test.protocols {
+				service.Spec.Ports = append(service.Spec.Ports, v1.ServicePort{
+					Protocol: protocol,
+					Port:     int32(80 + i),
+					NodePort: int32(30000 + i),
+				})
+			}
+
+			err := validateServiceConfig(service)
+			if len(test.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("expected no error, got %s", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), "invalid configuration for service (default/test)") {
+				t.Errorf("expected the error to name the service, got %s", err)
+			}
+			for _, want := range test.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected the error to contain %q, got %s", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateServiceConfigTCPAndUDPOnOnePort(t *testing.T) {
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "default"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "dns-tcp", Protocol: v1.ProtocolTCP, Port: 53, NodePort: 30053},
+				{Name: "dns-udp", Protocol: v1.ProtocolUDP, Port: 53, NodePort: 30053},
+			},
+		},
+	}
+
+	err := validateServiceConfig(service)
+	if err == nil {
+		t.Fatal("expected TCP and UDP on port 53 to be rejected, as NodeBalancers cannot serve UDP")
+	}
+	if !strings.Contains(err.Error(), "port 53 is served over both TCP and UDP") {
+		t.Errorf("expected the error to explain the UDP port 53, got %s", err)
+	}
+	if strings.Count(err.Error(), "port 53") != 1 {
+		t.Errorf("expected only the UDP port to be reported, got %s", err)
+	}
+}
+
+func TestEnsureLoadBalancerInvalidServiceConfig(t *testing.T) {
+	fake := newFake(t)
+	ts := httptest.NewServer(fake)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{client: &client, zone: "us-west", recorder: recorder}
+
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: randString(10),
+			UID:  "foobar123",
+			Annotations: map[string]string{
+				annLinodeDefaultProtocol:      "http",
+				annLinodeDefaultProxyProtocol: "v1",
+				annLinodeDefaultStickiness:    "bogus",
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{
+					Name:     "test",
+					Protocol: v1.ProtocolTCP,
+					Port:     int32(80),
+					NodePort: int32(30000),
+				},
+			},
+		},
+	}
+
+	if _, err := lb.EnsureLoadBalancer(context.TODO(), "linodelb", svc, nil); err == nil || !strings.Contains(err.Error(), "proxy protocol") {
+		t.Fatalf("expected EnsureLoadBalancer to reject the service, got %v", err)
+	}
+	if len(fake.requests) != 0 {
+		t.Errorf("expected no API requests for an invalid service, got %d", len(fake.requests))
+	}
+
+	var warned bool
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, eventReasonInvalidServiceConfig) {
+			warned = strings.HasPrefix(event, v1.EventTypeWarning)
+		}
+	}
+	if !warned {
+		t.Errorf("expected a %s warning event", eventReasonInvalidServiceConfig)
+	}
+}
@@ -0,0 +1,117 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// DeleteAllManaged deletes every NodeBalancer and Cloud Firewall tagged as created for
+// clusterName, for use when tearing the cluster down. NodeBalancers fronting a service
+// annotated with preserve, and the firewalls attached to them, are skipped and logged.
+func (l *loadbalancers) DeleteAllManaged(ctx context.Context, clusterName string) error {
+	clusterTag := makeClusterTag(clusterName)
+
+	nodeBalancers, err := l.client.ListNodeBalancers(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	protected, err := l.getPreservedNodeBalancerIDs(ctx, nodeBalancers)
+	if err != nil {
+		return err
+	}
+
+	firewalls, err := l.client.ListFirewalls(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Firewalls are deleted first so none are left attached to a NodeBalancer which
+	// is already gone.
+	for _, firewall := range firewalls {
+		if !hasTag(firewall.Tags, clusterTag) {
+			continue
+		}
+
+		devices, err := l.client.ListFirewallDevices(ctx, firewall.ID, nil)
+		if err != nil {
+			return err
+		}
+
+		skip := false
+		for _, device := range devices {
+			if device.Entity.Type == linodego.FirewallDeviceNodeBalancer && protected[device.Entity.ID] {
+				skip = true
+				break
+			}
+		}
+		if skip {
+			klog.Infof("skipping deletion of Firewall (%d) as it is attached to a preserved NodeBalancer", firewall.ID)
+			continue
+		}
+
+		if err := l.client.DeleteFirewall(ctx, firewall.ID); err != nil {
+			return fmt.Errorf("failed to delete Firewall (%d): %s", firewall.ID, err)
+		}
+		klog.Infof("successfully deleted Firewall (%d) for cluster %s", firewall.ID, clusterName)
+	}
+
+	for _, nb := range nodeBalancers {
+		if !hasTag(nb.Tags, clusterTag) {
+			continue
+		}
+		if protected[nb.ID] {
+			klog.Infof("skipping deletion of NodeBalancer (%d) as its service is annotated with %s", nb.ID, annLinodeLoadBalancerPreserve)
+			continue
+		}
+
+		if err := l.deleteNodeBalancer(ctx, nb.ID); err != nil {
+			return fmt.Errorf("failed to delete NodeBalancer (%d): %s", nb.ID, err)
+		}
+		klog.Infof("successfully deleted NodeBalancer (%d) for cluster %s", nb.ID, clusterName)
+	}
+	return nil
+}
+
+// getPreservedNodeBalancerIDs returns the IDs of the NodeBalancers which front a
+// LoadBalancer service annotated with preserve.
+func (l *loadbalancers) getPreservedNodeBalancerIDs(ctx context.Context, nodeBalancers []linodego.NodeBalancer) (map[int]bool, error) {
+	if err := l.retrieveKubeClient(); err != nil {
+		return nil, err
+	}
+
+	services, err := l.kubeClient.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	idsByIP := make(map[string]int, len(nodeBalancers))
+	for _, nb := range nodeBalancers {
+		if nb.IPv4 != nil {
+			idsByIP[*nb.IPv4] = nb.ID
+		}
+	}
+
+	protected := make(map[int]bool)
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.Type != v1.ServiceTypeLoadBalancer || !l.shouldPreserveNodeBalancer(service) {
+			continue
+		}
+
+		if id, ok := getNodeBalancerIDAnnotation(service); ok {
+			protected[id] = true
+		}
+		for _, ingress := range service.Status.LoadBalancer.Ingress {
+			if id, ok := idsByIP[ingress.IP]; ok {
+				protected[id] = true
+			}
+		}
+	}
+	return protected, nil
+}
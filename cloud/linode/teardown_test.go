@@ -0,0 +1,114 @@
+package linode
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDeleteAllManaged(t *testing.T) {
+	fakeAPI := newFake(t)
+	ts := httptest.NewServer(fakeAPI)
+	defer ts.Close()
+
+	client := linodego.NewClient(http.DefaultClient)
+	client.SetBaseURL(ts.URL)
+
+	clusterTag := makeClusterTag("linodelb")
+	createNodeBalancer := func(tags ...string) *linodego.NodeBalancer {
+		nb, err := client.CreateNodeBalancer(context.TODO(), linodego.NodeBalancerCreateOptions{
+			Region: "us-west",
+			Tags:   tags,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nb
+	}
+
+	managed := createNodeBalancer(clusterTag)
+	preservedByIP := createNodeBalancer(clusterTag)
+	preservedByID := createNodeBalancer(clusterTag)
+	otherCluster := createNodeBalancer(makeClusterTag("other"))
+	untagged := createNodeBalancer()
+
+	fakeAPI.addFirewall(linodego.Firewall{ID: 1, Label: "managed", Tags: []string{clusterTag}},
+		linodego.FirewallDevice{ID: 11, Entity: linodego.FirewallDeviceEntity{ID: managed.ID, Type: linodego.FirewallDeviceNodeBalancer}})
+	fakeAPI.addFirewall(linodego.Firewall{ID: 2, Label: "preserved", Tags: []string{clusterTag}},
+		linodego.FirewallDevice{ID: 21, Entity: linodego.FirewallDeviceEntity{ID: preservedByIP.ID, Type: linodego.FirewallDeviceNodeBalancer}})
+	fakeAPI.addFirewall(linodego.Firewall{ID: 3, Label: "external"},
+		linodego.FirewallDevice{ID: 31, Entity: linodego.FirewallDeviceEntity{ID: managed.ID, Type: linodego.FirewallDeviceNodeBalancer}})
+
+	preserve := map[string]string{annLinodeLoadBalancerPreserve: "true"}
+	services := []*v1.Service{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "by-ip", Namespace: "default", Annotations: preserve},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{IP: *preservedByIP.IPv4}},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "by-id",
+				Namespace: "kube-system",
+				Annotations: map[string]string{
+					annLinodeLoadBalancerPreserve: "true",
+					annLinodeNodeBalancerID:       strconv.Itoa(preservedByID.ID),
+				},
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-preserved", Namespace: "default"},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+			Status: v1.ServiceStatus{
+				LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{IP: *managed.IPv4}},
+				},
+			},
+		},
+	}
+	kubeClient := fake.NewSimpleClientset()
+	for _, svc := range services {
+		if _, err := kubeClient.CoreV1().Services(svc.Namespace).Create(context.TODO(), svc, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lb := &loadbalancers{client: &client, zone: "us-west", kubeClient: kubeClient}
+	if err := lb.DeleteAllManaged(context.TODO(), "linodelb"); err != nil {
+		t.Fatalf("DeleteAllManaged returned an error: %s", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		id      int
+		deleted bool
+	}{
+		{"managed", managed.ID, true},
+		{"preserved by IP", preservedByIP.ID, false},
+		{"preserved by ID", preservedByID.ID, false},
+		{"other cluster", otherCluster.ID, false},
+		{"untagged", untagged.ID, false},
+	} {
+		if _, found := fakeAPI.nb[strconv.Itoa(tc.id)]; found == tc.deleted {
+			t.Errorf("NodeBalancer %q: expected deleted=%t", tc.name, tc.deleted)
+		}
+	}
+
+	for id, deleted := range map[string]bool{"1": true, "2": false, "3": false} {
+		if _, found := fakeAPI.fw[id]; found == deleted {
+			t.Errorf("Firewall %s: expected deleted=%t", id, deleted)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package linode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// maxTLSSecretFetchAttempts caps how many times getTLSSecret tries to fetch a secret
+// after transient errors.
+const maxTLSSecretFetchAttempts = 4
+
+// tlsSecretFetchRetryInterval is how long getTLSSecret waits before its first retry,
+// doubling for each one after.
+var tlsSecretFetchRetryInterval = 250 * time.Millisecond
+
+// tlsSecretFetchError is returned when a TLS secret could not be fetched because of
+// transient API server errors. It is treated as a transient error, so the reconcile is
+// retried rather than the secret being reported as missing or invalid.
+type tlsSecretFetchError struct {
+	namespace string
+	name      string
+	attempts  int
+	err       error
+}
+
+func (e tlsSecretFetchError) Error() string {
+	return fmt.Sprintf("could not fetch TLS secret %s/%s after %d attempt(s), will retry: %s", e.namespace, e.name, e.attempts, e.err)
+}
+
+func (e tlsSecretFetchError) Unwrap() error {
+	return e.err
+}
+
+// isTransientKubeAPIError reports whether err is a Kubernetes API server or connection
+// error, which may succeed if retried. Errors such as a secret not being found or access
+// being forbidden are not.
+func isTransientKubeAPIError(err error) bool {
+	if apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err) ||
+		apierrors.IsInternalError(err) || apierrors.IsServiceUnavailable(err) || apierrors.IsUnexpectedServerError(err) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// getTLSSecret fetches the secret namespace/name, retrying transient errors for up to
+// maxTLSSecretFetchAttempts attempts and Options.TLSSecretFetchTimeout in all. Other
+// errors, such as the secret not being found, are returned as they are.
+func getTLSSecret(ctx context.Context, kubeClient kubernetes.Interface, namespace, name string) (*v1.Secret, error) {
+	if Options.TLSSecretFetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, Options.TLSSecretFetchTimeout)
+		defer cancel()
+	}
+
+	interval := tlsSecretFetchRetryInterval
+	for attempt := 1; ; attempt++ {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil || !isTransientKubeAPIError(err) {
+			return secret, err
+		}
+
+		if attempt >= maxTLSSecretFetchAttempts {
+			return nil, tlsSecretFetchError{namespace: namespace, name: name, attempts: attempt, err: err}
+		}
+		klog.Warningf("retrying fetch of TLS secret %s/%s after transient error: %s", namespace, name, err)
+		select {
+		case <-ctx.Done():
+			return nil, tlsSecretFetchError{namespace: namespace, name: name, attempts: attempt, err: err}
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
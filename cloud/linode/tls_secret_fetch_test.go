@@ -0,0 +1,94 @@
+package linode
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func Test_getTLSSecretRetries(t *testing.T) {
+	defer func(interval time.Duration) { tlsSecretFetchRetryInterval = interval }(tlsSecretFetchRetryInterval)
+	tlsSecretFetchRetryInterval = time.Millisecond
+
+	newClient := func(failures int, failure error) (*fake.Clientset, *int) {
+		kubeClient := fake.NewSimpleClientset()
+		addTLSSecret(t, kubeClient)
+		gets := 0
+		kubeClient.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gets++
+			if gets <= failures {
+				return true, nil, failure
+			}
+			return false, nil, nil
+		})
+		return kubeClient, &gets
+	}
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		kubeClient, gets := newClient(1, apierrors.NewServiceUnavailable("etcd is restarting"))
+		cert, key, err := getTLSCertInfo(context.TODO(), kubeClient, "", portConfig{TLSSecretName: "tls-secret", Port: 443})
+		if err != nil {
+			t.Fatalf("expected the retry to succeed, got %s", err)
+		}
+		if cert != testCert || key != testKey {
+			t.Error("unexpected certificate or key")
+		}
+		if *gets != 2 {
+			t.Errorf("expected 2 fetches, got %d", *gets)
+		}
+	})
+
+	t.Run("persistent transient error is retriable", func(t *testing.T) {
+		kubeClient, gets := newClient(maxTLSSecretFetchAttempts, apierrors.NewInternalError(errors.New("boom")))
+		_, _, err := getTLSCertInfo(context.TODO(), kubeClient, "", portConfig{TLSSecretName: "tls-secret", Port: 443})
+		var fetchErr tlsSecretFetchError
+		if !errors.As(err, &fetchErr) || !isTransientError(err) {
+			t.Fatalf("expected a transient tlsSecretFetchError, got %v", err)
+		}
+		if *gets != maxTLSSecretFetchAttempts {
+			t.Errorf("expected %d fetches, got %d", maxTLSSecretFetchAttempts, *gets)
+		}
+	})
+
+	t.Run("not found is not retried", func(t *testing.T) {
+		kubeClient, gets := newClient(0, nil)
+		_, _, err := getTLSCertInfo(context.TODO(), kubeClient, "", portConfig{TLSSecretName: "missing", Port: 443})
+		if !apierrors.IsNotFound(err) || isTransientError(err) {
+			t.Fatalf("expected a non-transient not found error, got %v", err)
+		}
+		if *gets != 1 {
+			t.Errorf("expected 1 fetch, got %d", *gets)
+		}
+	})
+}
+
+func Test_getTLSSecretTimeout(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		Options.TLSSecretFetchTimeout = timeout
+		tlsSecretFetchRetryInterval = interval
+	}(Options.TLSSecretFetchTimeout, tlsSecretFetchRetryInterval)
+	Options.TLSSecretFetchTimeout = 10 * time.Millisecond
+	tlsSecretFetchRetryInterval = time.Hour
+
+	kubeClient := fake.NewSimpleClientset(&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "tls-secret"}})
+	kubeClient.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewTooManyRequests("slow down", 1)
+	})
+
+	start := time.Now()
+	_, err := getTLSSecret(context.TODO(), kubeClient, "", "tls-secret")
+	if _, ok := err.(tlsSecretFetchError); !ok {
+		t.Fatalf("expected a tlsSecretFetchError, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the fetch to give up after the timeout, took %s", elapsed)
+	}
+}
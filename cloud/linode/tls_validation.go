@@ -0,0 +1,118 @@
+package linode
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// tlsValidationStrict rejects TLS material which fails validation, while
+	// tlsValidationLenient only logs a warning and uses it anyway.
+	tlsValidationStrict  = "strict"
+	tlsValidationLenient = "lenient"
+)
+
+// tlsValidationRoots returns the CAs a certificate chain may end at besides a
+// self-signed certificate. It is replaced in tests.
+var tlsValidationRoots = x509.SystemCertPool
+
+// validateTLSValidation returns an error if mode is not a usable --tls-validation.
+func validateTLSValidation(mode string) error {
+	switch mode {
+	case tlsValidationStrict, tlsValidationLenient:
+		return nil
+	default:
+		return fmt.Errorf("--tls-validation must be %q or %q, got %q", tlsValidationStrict, tlsValidationLenient, mode)
+	}
+}
+
+// checkTLSMaterial returns the problems with the PEM certificate chain cert and private
+// key key: a key which does not match the certificate, a certificate which has expired
+// or is not yet valid at now, and a chain which is out of order or does not end at a
+// self-signed certificate or one issued by a CA in tlsValidationRoots.
+func checkTLSMaterial(cert, key string, now time.Time) []string {
+	var problems []string
+	if _, err := tls.X509KeyPair([]byte(cert), []byte(key)); err != nil {
+		problems = append(problems, fmt.Sprintf("the certificate and key do not match: %s", err))
+	}
+
+	var chain []*x509.Certificate
+	rest := []byte(cert)
+	for {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return append(problems, fmt.Sprintf("the certificate cannot be parsed: %s", err))
+		}
+		chain = append(chain, parsed)
+	}
+	if len(chain) == 0 {
+		return append(problems, "no certificate was found")
+	}
+
+	leaf := chain[0]
+	if now.After(leaf.NotAfter) {
+		problems = append(problems, fmt.Sprintf("the certificate for %q expired at %s", leaf.Subject.CommonName, leaf.NotAfter.UTC().Format(time.RFC3339)))
+	} else if now.Before(leaf.NotBefore) {
+		problems = append(problems, fmt.Sprintf("the certificate for %q is not valid until %s", leaf.Subject.CommonName, leaf.NotBefore.UTC().Format(time.RFC3339)))
+	}
+
+	for i := 0; i+1 < len(chain); i++ {
+		if !isIssuedBy(chain[i], chain[i+1]) {
+			return append(problems, fmt.Sprintf("the chain is out of order or incomplete: %q is not issued by %q, which follows it",
+				chain[i].Subject.CommonName, chain[i+1].Subject.CommonName))
+		}
+	}
+	if last := chain[len(chain)-1]; !isIssuedBy(last, last) && !isIssuedByRoot(last) {
+		problems = append(problems, fmt.Sprintf("the chain is incomplete: the certificate issuing %q, %q, is missing",
+			last.Subject.CommonName, last.Issuer.CommonName))
+	}
+	return problems
+}
+
+// isIssuedBy reports whether cert is signed by issuer's key.
+func isIssuedBy(cert, issuer *x509.Certificate) bool {
+	return issuer.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
+
+// isIssuedByRoot reports whether cert is issued by a CA in tlsValidationRoots.
+func isIssuedByRoot(cert *x509.Certificate) bool {
+	roots, err := tlsValidationRoots()
+	if err != nil || roots == nil {
+		return false
+	}
+	// cert's own validity is checked separately, so the chain is verified while it is valid
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: cert.NotAfter.Add(-time.Second),
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err == nil
+}
+
+// validateTLSMaterial checks the certificate and key described by source against
+// Options.TLSValidation: in strict mode, material with problems is rejected with an
+// error; otherwise its problems are only logged.
+func validateTLSMaterial(source, cert, key string) error {
+	problems := checkTLSMaterial(cert, key, time.Now())
+	if len(problems) == 0 {
+		return nil
+	}
+	if Options.TLSValidation == tlsValidationStrict {
+		return fmt.Errorf("%s is rejected by strict TLS validation: %s", source, strings.Join(problems, "; "))
+	}
+	klog.Warningf("%s is used despite failing TLS validation: %s", source, strings.Join(problems, "; "))
+	return nil
+}
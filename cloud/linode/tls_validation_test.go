@@ -0,0 +1,133 @@
+package linode
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testTLSMaterial is a PEM certificate and key generated for a test.
+type testTLSMaterial struct {
+	cert, key string
+	parsed    *x509.Certificate
+	signer    *ecdsa.PrivateKey
+}
+
+// newTestTLSMaterial returns a certificate for name valid from notBefore to notAfter,
+// issued by issuer, or self-signed if issuer is nil.
+func newTestTLSMaterial(t *testing.T, name string, notBefore, notAfter time.Time, isCA bool, issuer *testTLSMaterial) testTLSMaterial {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.parsed, issuer.signer
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testTLSMaterial{
+		cert:   string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		key:    string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		parsed: parsed,
+		signer: key,
+	}
+}
+
+func Test_checkTLSMaterial(t *testing.T) {
+	defer func(roots func() (*x509.CertPool, error)) { tlsValidationRoots = roots }(tlsValidationRoots)
+	tlsValidationRoots = func() (*x509.CertPool, error) { return x509.NewCertPool(), nil }
+
+	now := time.Now()
+	valid := newTestTLSMaterial(t, "valid.test", now.Add(-time.Hour), now.Add(time.Hour), false, nil)
+	expired := newTestTLSMaterial(t, "expired.test", now.Add(-2*time.Hour), now.Add(-time.Hour), false, nil)
+	ca := newTestTLSMaterial(t, "ca", now.Add(-time.Hour), now.Add(time.Hour), true, nil)
+	issued := newTestTLSMaterial(t, "issued.test", now.Add(-time.Hour), now.Add(time.Hour), false, &ca)
+
+	testcases := []struct {
+		name     string
+		cert     string
+		key      string
+		expected string
+	}{
+		{"valid", valid.cert, valid.key, ""},
+		{"chain", issued.cert + ca.cert, issued.key, ""},
+		{"expired", expired.cert, expired.key, `the certificate for "expired.test" expired`},
+		{"mismatched key", valid.cert, expired.key, "the certificate and key do not match"},
+		{"missing issuer", issued.cert, issued.key, `the certificate issuing "issued.test", "ca", is missing`},
+		{"out of order", ca.cert + issued.cert, ca.key, "out of order or incomplete"},
+	}
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			problems := strings.Join(checkTLSMaterial(test.cert, test.key, now), "; ")
+			if test.expected == "" && problems != "" {
+				t.Errorf("expected no problems, got %s", problems)
+			}
+			if !strings.Contains(problems, test.expected) {
+				t.Errorf("expected a problem containing %q, got %q", test.expected, problems)
+			}
+		})
+	}
+}
+
+func Test_getTLSCertInfoValidation(t *testing.T) {
+	defer func(mode string) { Options.TLSValidation = mode }(Options.TLSValidation)
+
+	now := time.Now()
+	expired := newTestTLSMaterial(t, "expired.test", now.Add(-2*time.Hour), now.Add(-time.Hour), false, nil)
+	kubeClient := fake.NewSimpleClientset(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "expired"},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey:       []byte(expired.cert),
+			v1.TLSPrivateKeyKey: []byte(expired.key),
+		},
+	})
+	config := portConfig{TLSSecretName: "expired", Port: 443}
+
+	Options.TLSValidation = tlsValidationStrict
+	if _, _, err := getTLSCertInfo(context.TODO(), kubeClient, "", config); err == nil || !strings.Contains(err.Error(), "rejected by strict TLS validation") {
+		t.Errorf("expected strict validation to reject the expired certificate, got %v", err)
+	}
+
+	Options.TLSValidation = tlsValidationLenient
+	cert, key, err := getTLSCertInfo(context.TODO(), kubeClient, "", config)
+	if err != nil {
+		t.Fatalf("expected lenient validation to accept the expired certificate, got %s", err)
+	}
+	if cert != strings.TrimSpace(expired.cert) || key != strings.TrimSpace(expired.key) {
+		t.Error("expected the expired certificate and key to be returned")
+	}
+}
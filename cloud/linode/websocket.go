@@ -0,0 +1,80 @@
+package linode
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// annLinodeWebSocket marks every port of the service as serving WebSockets, or, when
+	// false, none of them, whatever their appProtocol.
+	annLinodeWebSocket = "service.beta.kubernetes.io/linode-loadbalancer-websocket"
+
+	eventReasonWebSocketTCP = "WebSocketTCP"
+)
+
+// webSocketAppProtocols are the appProtocols of ports serving WebSockets.
+var webSocketAppProtocols = map[string]bool{
+	"kubernetes.io/ws":  true,
+	"kubernetes.io/wss": true,
+}
+
+// isWebSocketPort reports whether port of service serves WebSockets, as set by the
+// websocket annotation or else by the port's appProtocol.
+func isWebSocketPort(service *v1.Service, port int) (bool, error) {
+	if raw, ok := getServiceAnnotation(service, annLinodeWebSocket); ok {
+		websocket, err := strconv.ParseBool(raw)
+		if err != nil {
+			return false, fmt.Errorf("invalid value %q for annotation %s, must be true or false", raw, annLinodeWebSocket)
+		}
+		return websocket, nil
+	}
+	for _, servicePort := range service.Spec.Ports {
+		if int(servicePort.Port) == port && servicePort.AppProtocol != nil {
+			return webSocketAppProtocols[*servicePort.AppProtocol], nil
+		}
+	}
+	return false, nil
+}
+
+// getWebSocketProtocol returns the protocol a WebSocket port is served with in place of
+// protocol. NodeBalancers do not pass connection upgrades on to http backends, so such
+// ports are proxied as tcp instead. https ports cannot be, as that would leave their TLS
+// to the backends.
+func getWebSocketProtocol(port int, protocol string) (string, error) {
+	switch linodego.ConfigProtocol(protocol) {
+	case linodego.ProtocolHTTP:
+		return string(linodego.ProtocolTCP), nil
+	case linodego.ProtocolHTTPS:
+		return "", fmt.Errorf("port %d serves WebSockets, which NodeBalancers cannot upgrade with protocol %q; use protocol %q and terminate TLS on the backends", port, protocol, linodego.ProtocolTCP)
+	}
+	return protocol, nil
+}
+
+// warnWebSocketPorts emits a warning event when any of the service's WebSocket ports is
+// proxied as tcp rather than the http it is configured with. The event is emitted again
+// only if those ports change.
+func (l *loadbalancers) warnWebSocketPorts(service *v1.Service) {
+	var forced []string
+	for _, port := range sortedServicePorts(service) {
+		if config, err := getPortConfig(service, int(port.Port)); err == nil && config.WebSocketTCP {
+			forced = append(forced, strconv.Itoa(int(port.Port)))
+		}
+	}
+	warned := strings.Join(forced, ", ")
+
+	warn := false
+	l.states.update(getServiceNn(service), func(state *serviceState) {
+		warn = warned != "" && warned != state.webSocketTCPWarned
+		state.webSocketTCPWarned = warned
+	})
+	if warn {
+		l.recordEvent(service, v1.EventTypeWarning, eventReasonWebSocketTCP,
+			"ports %s serve WebSockets, so they are proxied with protocol %q instead of %q, which does not pass on connection upgrades",
+			warned, linodego.ProtocolTCP, linodego.ProtocolHTTP)
+	}
+}
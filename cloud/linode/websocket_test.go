@@ -0,0 +1,129 @@
+package linode
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linode/linodego"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestGetPortConfigWebSocket(t *testing.T) {
+	ws := "kubernetes.io/ws"
+	testcases := []struct {
+		name         string
+		annotations  map[string]string
+		appProtocol  *string
+		protocol     linodego.ConfigProtocol
+		webSocketTCP bool
+		wantErr      string
+	}{
+		{
+			name:         "websocket annotation forces tcp for http",
+			annotations:  map[string]string{annLinodeWebSocket: "true", annLinodeDefaultProtocol: "http"},
+			protocol:     linodego.ProtocolTCP,
+			webSocketTCP: true,
+		},
+		{
+			name:         "websocket annotation overrides the port's protocol",
+			annotations:  map[string]string{annLinodeWebSocket: "true", annLinodePortConfigPrefix + "80": `{"protocol": "http"}`},
+			protocol:     linodego.ProtocolTCP,
+			webSocketTCP: true,
+		},
+		{
+			name:        "websocket annotation keeps tcp",
+			annotations: map[string]string{annLinodeWebSocket: "true"},
+			protocol:    linodego.ProtocolTCP,
+		},
+		{
+			name:         "ws appProtocol forces tcp for http",
+			annotations:  map[string]string{annLinodeDefaultProtocol: "http"},
+			appProtocol:  &ws,
+			protocol:     linodego.ProtocolTCP,
+			webSocketTCP: true,
+		},
+		{
+			name:        "websocket annotation false overrides the appProtocol",
+			annotations: map[string]string{annLinodeWebSocket: "false", annLinodeDefaultProtocol: "http"},
+			appProtocol: &ws,
+			protocol:    linodego.ProtocolHTTP,
+		},
+		{
+			name: "websocket annotation with https",
+			annotations: map[string]string{
+				annLinodeWebSocket:               "true",
+				annLinodePortConfigPrefix + "80": `{"protocol": "https", "tls-secret-name": "app-tls"}`,
+			},
+			wantErr: "cannot upgrade with protocol \"https\"",
+		},
+		{
+			name:        "invalid websocket annotation",
+			annotations: map[string]string{annLinodeWebSocket: "yes please"},
+			wantErr:     "must be true or false",
+		},
+	}
+
+	for _, test := range testcases {
+		t.Run(test.name, func(t *testing.T) {
+			service := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "test",
+					Annotations: test.annotations,
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{Port: 80, AppProtocol: test.appProtocol}},
+				},
+			}
+
+			config, err := getPortConfig(service, 80)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", test.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Protocol != test.protocol || config.WebSocketTCP != test.webSocketTCP {
+				t.Errorf("expected protocol %q (forced to tcp: %t), got %q (%t)", test.protocol, test.webSocketTCP, config.Protocol, config.WebSocketTCP)
+			}
+		})
+	}
+}
+
+func TestWarnWebSocketPorts(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	lb := &loadbalancers{recorder: recorder}
+
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+			Annotations: map[string]string{
+				annLinodeWebSocket:                 "true",
+				annLinodeDefaultProtocol:           "http",
+				annLinodePortConfigPrefix + "8080": `{"protocol": "tcp"}`,
+			},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 80}, {Port: 8080}},
+		},
+	}
+
+	lb.warnWebSocketPorts(service)
+	lb.warnWebSocketPorts(service)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected a single event, got %d", len(recorder.Events))
+	}
+	if event := <-recorder.Events; !strings.Contains(event, eventReasonWebSocketTCP) || !strings.Contains(event, "ports 80 serve") {
+		t.Errorf("expected a %s event for port 80, got %q", eventReasonWebSocketTCP, event)
+	}
+
+	delete(service.Annotations, annLinodeWebSocket)
+	lb.warnWebSocketPorts(service)
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no event once no port is forced to tcp, got %q", <-recorder.Events)
+	}
+}
@@ -68,6 +68,34 @@ func main() {
 
 	// Add Linode-specific flags
 	command.Flags().BoolVar(&linode.Options.LinodeGoDebug, "linodego-debug", false, "enables debug output for the LinodeAPI wrapper")
+	command.Flags().BoolVar(&linode.Options.RecreateNodeBalancersOnRegionChange, "recreate-nodebalancers-on-region-change", false, "recreates NodeBalancers which are not in the configured region in that region")
+	command.Flags().BoolVar(&linode.Options.ReconcileNodesOnChangeOnly, "reconcile-nodes-on-change-only", false, "only updates NodeBalancer backends when a node's readiness or addresses change")
+	command.Flags().DurationVar(&linode.Options.TransientErrorGracePeriod, "transient-error-grace-period", 10*time.Second, "how long to retry transient Linode API errors while ensuring a NodeBalancer before reporting a failure")
+	command.Flags().BoolVar(&linode.Options.AllowCrossNamespaceTLSSecrets, "allow-cross-namespace-tls-secrets", false, "allows services to use TLS secrets from other namespaces with a namespace/name tls-secret-name")
+	command.Flags().IntVar(&linode.Options.HealthCheckIntervalJitter, "health-check-interval-jitter", 0, "maximum number of seconds added to each NodeBalancer config's health check interval to spread out checks (0 to disable)")
+	command.Flags().DurationVar(&linode.Options.BackendHealthReportInterval, "backend-health-report-interval", 0, "minimum time between events summarizing a service's NodeBalancer backend health (0 to disable)")
+	command.Flags().StringVar(&linode.Options.BackendAddressType, "backend-address-type", "internal", "which node address NodeBalancers use to reach backends: internal or external (vpc is not yet supported); services can override it with the backend-address-type annotation")
+	command.Flags().Var(&linode.Options.NodePortRange, "node-port-range", "the cluster's NodePort range (e.g. 30000-32767), which services' NodePorts are checked against; unset to disable the check")
+	command.Flags().BoolVar(&linode.Options.RejectOutOfRangeNodePorts, "reject-out-of-range-node-ports", false, "fails to reconcile services with NodePorts outside --node-port-range instead of emitting a warning event")
+	command.Flags().StringSliceVar(&linode.Options.FallbackRegions, "nodebalancer-fallback-regions", nil, "ordered regions to create NodeBalancers in when the cluster's region lacks capacity; backends in another region see higher latency and are reached over the public internet")
+	command.Flags().DurationVar(&linode.Options.IPPollTimeout, "nodebalancer-ip-poll-timeout", 30*time.Second, "how long to wait for a new NodeBalancer to be assigned an IPv4 address before deleting it and reporting a failure")
+	command.Flags().DurationVar(&linode.Options.IPPollInterval, "nodebalancer-ip-poll-interval", 2*time.Second, "how often to check whether a new NodeBalancer has been assigned an IPv4 address")
+	command.Flags().Float64Var(&linode.Options.MinBackendRatio, "min-backend-ratio", 0, "fraction (0-1) of a NodeBalancer config's backends a single reconcile must keep; larger removals are spread over several reconciles (0 to disable)")
+	command.Flags().DurationVar(&linode.Options.ReconcileBackoffBase, "reconcile-backoff-base", 0, "how long to wait before reconciling a service again after it fails, doubling with each consecutive failure (0 to disable)")
+	command.Flags().DurationVar(&linode.Options.ReconcileBackoffMax, "reconcile-backoff-max", 5*time.Minute, "the longest to wait before reconciling a service again after consecutive failures")
+	command.Flags().StringVar(&linode.Options.DebugStateAddress, "debug-state-address", "", "address (e.g. 127.0.0.1:10299) to serve each service's reconcile state as JSON on, at /debug/services; unset to disable")
+	command.Flags().DurationVar(&linode.Options.LookupCacheTTL, "lookup-cache-ttl", time.Hour, "how long to cache Linode API lookups which rarely change, such as the list of regions (0 to disable)")
+	command.Flags().StringVar(&linode.Options.NodeBalancerLabelTemplate, "nodebalancer-label-template", "", "Go template (e.g. '{{.Cluster}}-{{.Namespace}}-{{.Name}}-{{.Hash}}') for the labels of new NodeBalancers, with the service's .Cluster, .Namespace, .Name, .UID and a short .Hash of its UID, which must include .Hash or .UID; unset to use ccm-<namespace>-<name>-<hash>")
+	command.Flags().StringVar(&linode.Options.DefaultAnnotationsConfigMap, "default-annotations-configmap", "", "namespace/name of a ConfigMap of default load balancer annotations for the cluster; a ConfigMap of the same name in a service's namespace holds defaults for that namespace, and services' own annotations take precedence over both")
+	command.Flags().DurationVar(&linode.Options.NodeBalancerDrainPeriod, "nodebalancer-drain-period", 0, "how long to wait, after setting a deleted service's NodeBalancer backends to drain, before deleting the NodeBalancer, for in-flight connections to finish; at most 1m (0 to disable)")
+	command.Flags().DurationVar(&linode.Options.NotReadyNodeGracePeriod, "not-ready-node-grace-period", 0, "how long a node which turns NotReady stays a NodeBalancer backend, in drain mode, before it is removed; it returns to accept mode if it becomes Ready in time (0 to disable)")
+	command.Flags().DurationVar(&linode.Options.TLSSecretFetchTimeout, "tls-secret-fetch-timeout", 10*time.Second, "how long to keep fetching a TLS secret, retrying transient Kubernetes API errors, before requeuing the service (0 for no limit besides the retry count)")
+	command.Flags().IntVar(&linode.Options.MaxConnectionThrottle, "max-connection-throttle", 0, "the highest NodeBalancer client connection throttle services may set, for accounts which allow more than 20 (0 for 20, or the detected maximum with --detect-max-connection-throttle)")
+	command.Flags().BoolVar(&linode.Options.DetectMaxConnectionThrottle, "detect-max-connection-throttle", false, "detects the highest NodeBalancer client connection throttle from the account's capabilities at startup, falling back to 20; ignored when --max-connection-throttle is set")
+	command.Flags().DurationVar(&linode.Options.MinReconcileInterval, "min-reconcile-interval", 0, "the least time between reconciles of a service which change its NodeBalancer; changes within it are coalesced into one reconcile at its end (0 to disable)")
+	command.Flags().StringVar(&linode.Options.TLSValidation, "tls-validation", "lenient", "how to treat TLS certificates which have expired, do not match their key or have an incomplete chain: strict to reject them, or lenient to only log a warning")
+	command.Flags().StringVar(&linode.Options.NoBackendNodesPolicy, "no-backend-nodes-policy", "create", "what to do when none of a service's nodes are eligible backends as its NodeBalancer is created: create to create it anyway, or defer to wait for eligible nodes")
+	command.Flags().BoolVar(&linode.Options.MigrateLegacyNodeBalancers, "migrate-legacy-nodebalancers", true, "relabels and tags NodeBalancers created by earlier CCM versions in place, as their services are reconciled, so they are found by label and deleted on cluster teardown like new ones")
 
 	// Make the Linode-specific CCM bits aware of the kubeconfig flag
 	linode.Options.KubeconfigFlag = command.Flags().Lookup("kubeconfig")